@@ -2,17 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/skybi/pluteo/internal/alert"
 	"github.com/skybi/pluteo/internal/api"
 	"github.com/skybi/pluteo/internal/apikey/quota"
 	"github.com/skybi/pluteo/internal/config"
+	"github.com/skybi/pluteo/internal/coverage"
+	"github.com/skybi/pluteo/internal/event"
+	"github.com/skybi/pluteo/internal/export"
+	"github.com/skybi/pluteo/internal/ingest"
+	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/station"
 	"github.com/skybi/pluteo/internal/storage/cache"
 	"github.com/skybi/pluteo/internal/storage/postgres"
 	"github.com/skybi/pluteo/internal/task"
+	"github.com/skybi/pluteo/internal/webhook"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
 )
 
@@ -44,11 +54,65 @@ func main() {
 	}
 	defer pgStorage.Close()
 
+	// Backfill the flight categories of old METARs in the background; the backfill is cancelled on shutdown
+	backfillCtx, cancelBackfill := context.WithCancel(context.Background())
+	defer cancelBackfill()
+	go func() {
+		if err := pgStorage.BackfillFlightCategories(backfillCtx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Error().Err(err).Msg("could not backfill the flight categories of the stored METARs")
+		}
+	}()
+
 	// Initialize the caching storage driver
 	cacheStorage := cache.New(pgStorage)
 	cacheStorage.Initialize(nil)
 	defer cacheStorage.Close()
 
+	// Schedule a task that creates upcoming METAR partitions and retires expired ones
+	partitionPolicy := &postgres.METARPartitionPolicy{
+		Ahead:     cfg.METARPartitionsAhead,
+		Retention: cfg.METARRetention,
+		Mode:      postgres.METARRetentionMode(cfg.METARRetentionMode),
+	}
+	if !partitionPolicy.Mode.IsValid() {
+		log.Fatal().Str("mode", cfg.METARRetentionMode).Msg("unknown METAR retention mode")
+	}
+	maintainPartitions := func() {
+		result, err := pgStorage.MaintainMETARPartitions(context.Background(), partitionPolicy)
+		if err != nil {
+			log.Error().Err(err).Msg("could not maintain the METAR partitions")
+		}
+
+		// The maintenance bypasses the cache, which must not serve retired METARs
+		if len(result.Retired) > 0 || result.RetiredDefault > 0 {
+			cacheStorage.InvalidateMETARs()
+		}
+		if len(result.Created) > 0 || len(result.Retired) > 0 || result.RetiredDefault > 0 {
+			log.Info().
+				Strs("created", result.Created).
+				Strs("retired", result.Retired).
+				Int64("retired_default", result.RetiredDefault).
+				Msg("maintained the METAR partitions")
+		}
+	}
+	maintainPartitions()
+	partitionTask := task.NewRepeating(maintainPartitions, time.Hour)
+	partitionTask.Start()
+	defer partitionTask.Stop(false)
+
+	// Import the station registry if a station file is configured
+	if cfg.StationsFile != "" {
+		log.Info().Str("file", cfg.StationsFile).Msg("importing stations...")
+		result, err := station.ImportFile(context.Background(), cacheStorage.Stations(), cfg.StationsFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("could not import the stations")
+		}
+		for _, rowErr := range result.Skipped {
+			log.Warn().Err(rowErr.Err).Int("line", rowErr.Line).Msg("skipped a malformed station")
+		}
+		log.Info().Int("amount", result.Affected).Int("skipped", len(result.Skipped)).Msg("imported stations")
+	}
+
 	// Create the API key quota tracker and schedule a task that flushes it
 	quotaTracker := quota.NewTracker(cacheStorage.APIKeys())
 	flushingTask := task.NewRepeating(func() {
@@ -62,12 +126,80 @@ func main() {
 	flushingTask.Start()
 	defer flushingTask.Stop(true)
 
+	// Create the broker distributing newly fed METARs to the streaming endpoints
+	metarEvents := event.NewBroker[*metar.METAR]()
+
+	// Start the dispatcher delivering newly fed METARs to the registered webhooks
+	webhookDispatcher := webhook.NewDispatcher(cacheStorage.Webhooks(), cacheStorage.APIKeys(), quotaTracker, metarEvents)
+	webhookDispatcher.Start()
+	defer webhookDispatcher.Stop()
+
+	// Start the evaluator checking newly fed METARs against the alert rules
+	alertEvents := event.NewBroker[*alert.Event]()
+	alertEvaluator := alert.NewEvaluator(cacheStorage.AlertRules(), metarEvents, alertEvents, webhookDispatcher)
+	alertEvaluator.Start()
+	defer alertEvaluator.Stop()
+
+	// Start pulling METARs from the configured upstream sources
+	var ingestSources []*ingest.Source
+	if cfg.IngestSourcesFile != "" {
+		log.Info().Str("file", cfg.IngestSourcesFile).Msg("loading ingest sources...")
+		ingestSources, err = ingest.LoadSources(cfg.IngestSourcesFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("could not load the ingest sources")
+		}
+	}
+	ingester, err := ingest.NewIngester(cacheStorage.METARs(), metarEvents, ingestSources)
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not create the ingester")
+	}
+	ingester.Start()
+	defer ingester.Stop()
+
+	// Backfill the coverage statistics of the whole window they can be queried for and schedule a task that recomputes
+	// the ones of the latest days; refreshes never overlap
+	var coverageMu sync.Mutex
+	refreshCoverage := func(days int) {
+		coverageMu.Lock()
+		defer coverageMu.Unlock()
+		if days < 1 {
+			days = 1
+		}
+		since := time.Now().AddDate(0, 0, 1-days).Unix()
+		n, err := cacheStorage.Coverage().Refresh(context.Background(), since)
+		if err != nil {
+			log.Error().Err(err).Msg("could not refresh the coverage statistics")
+		} else {
+			log.Debug().Int64("amount", n).Int("days", days).Msg("refreshed the coverage statistics")
+		}
+	}
+	go refreshCoverage(coverage.WindowDays)
+	coverageTask := task.NewRepeating(func() {
+		refreshCoverage(cfg.CoverageRefreshDays)
+	}, cfg.CoverageRefreshInterval)
+	coverageTask.Start()
+	defer coverageTask.Stop(false)
+
+	// Start the manager processing bulk METAR export jobs
+	exportManager := export.NewManager(cacheStorage.METARs(), cacheStorage.APIKeys(), quotaTracker, cfg.DataAPIExportDirectory)
+	exportManager.Workers = cfg.DataAPIExportWorkers
+	exportManager.TTL = cfg.DataAPIExportTTL
+	exportManager.MaxRows = cfg.DataAPIExportMaxRows
+	if err := exportManager.Start(); err != nil {
+		log.Fatal().Err(err).Msg("could not start the export manager")
+	}
+	defer exportManager.Stop()
+
 	// Start up the portal & data APIs
 	log.Info().Str("portal_api", cfg.PortalAPIListenAddress).Str("data_api", cfg.DataAPIListenAddress).Msg("starting up portal & data APIs...")
 	apis := &api.Service{
 		Config:       cfg,
 		Storage:      cacheStorage,
 		QuotaTracker: quotaTracker,
+		METAREvents:  metarEvents,
+		AlertEvents:  alertEvents,
+		Exports:      exportManager,
+		Ingester:     ingester,
 	}
 	apiErrs := make(chan error, 1)
 	apis.Startup(apiErrs)
@@ -17,6 +17,7 @@ require (
 	github.com/go-chi/cors v1.2.0
 	github.com/golang-migrate/migrate/v4 v4.15.1
 	github.com/google/uuid v1.3.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/hashicorp/go-memdb v1.3.2
 	github.com/jackc/pgx/v4 v4.15.0
 	golang.org/x/oauth2 v0.0.0-20220223155221-ee480838109b
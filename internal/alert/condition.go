@@ -0,0 +1,233 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skybi/pluteo/internal/metar"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// Metric represents a value of a METAR a condition is evaluated on
+type Metric string
+
+const (
+	// MetricVisibility represents the prevailing visibility in meters
+	MetricVisibility Metric = "visibility"
+
+	// MetricCeiling represents the ceiling in feet (see metar.Report.Ceiling)
+	MetricCeiling Metric = "ceiling"
+
+	// MetricWindSpeed represents the sustained wind speed in knots
+	MetricWindSpeed Metric = "wind_speed"
+
+	// MetricWindGust represents the wind gust speed in knots; reports without gusts use the sustained wind speed
+	MetricWindGust Metric = "wind_gust"
+
+	// MetricCrosswind represents the crosswind component in knots for the runway given in Condition.Runway.
+	// The gust speed is used if gusts are reported and variable wind is treated as a full crosswind.
+	MetricCrosswind Metric = "crosswind"
+
+	// MetricTemperature represents the temperature in degrees Celsius
+	MetricTemperature Metric = "temperature"
+
+	// MetricAltimeter represents the altimeter setting in hectopascals
+	MetricAltimeter Metric = "altimeter"
+
+	// MetricFlightCategory represents the flight category given in Condition.Category.
+	// Categories are compared by their severity, i.e. 'gt' means worse than the given category.
+	MetricFlightCategory Metric = "flight_category"
+
+	// MetricWeather represents the presence of the weather phenomenon given in Condition.Phenomenon (i.e. 'TS' or 'FG').
+	// Only the operators 'eq' (present) and 'ne' (absent) are supported.
+	MetricWeather Metric = "weather"
+)
+
+// Metrics contains all supported metrics
+var Metrics = []Metric{MetricVisibility, MetricCeiling, MetricWindSpeed, MetricWindGust, MetricCrosswind, MetricTemperature, MetricAltimeter, MetricFlightCategory, MetricWeather}
+
+// Operator represents the comparison operator of a condition
+type Operator string
+
+const (
+	OperatorLessThan           Operator = "lt"
+	OperatorLessThanOrEqual    Operator = "lte"
+	OperatorGreaterThan        Operator = "gt"
+	OperatorGreaterThanOrEqual Operator = "gte"
+	OperatorEqual              Operator = "eq"
+	OperatorNotEqual           Operator = "ne"
+)
+
+// Operators contains all supported operators
+var Operators = []Operator{OperatorLessThan, OperatorLessThanOrEqual, OperatorGreaterThan, OperatorGreaterThanOrEqual, OperatorEqual, OperatorNotEqual}
+
+var (
+	runwayPattern     = regexp.MustCompile(`^(0[1-9]|[1-2]\d|3[0-6])[LCR]?$`)
+	phenomenonPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+var (
+	// ErrUnknownMetric is returned by Condition.Validate if the metric is not supported
+	ErrUnknownMetric = errors.New("unknown metric")
+
+	// ErrUnknownOperator is returned by Condition.Validate if the operator is not supported
+	ErrUnknownOperator = errors.New("unknown operator")
+)
+
+// Condition represents a single condition of an alert rule, i.e. 'visibility lt 800'
+type Condition struct {
+	Metric   Metric   `json:"metric"`
+	Operator Operator `json:"operator"`
+
+	// Value is the threshold numeric metrics are compared to
+	Value float64 `json:"value"`
+
+	// Runway is the runway designator (i.e. '25' or '07L') the crosswind component is calculated for
+	Runway string `json:"runway,omitempty"`
+
+	// Category is the flight category that flight category conditions are compared to
+	Category metar.FlightCategory `json:"category,omitempty"`
+
+	// Phenomenon is the two-letter weather phenomenon code weather conditions check for
+	Phenomenon string `json:"phenomenon,omitempty"`
+}
+
+// Validate makes sure that the condition is complete and consistent
+func (condition *Condition) Validate() error {
+	supported := false
+	for _, metric := range Metrics {
+		supported = supported || metric == condition.Metric
+	}
+	if !supported {
+		return ErrUnknownMetric
+	}
+	supported = false
+	for _, operator := range Operators {
+		supported = supported || operator == condition.Operator
+	}
+	if !supported {
+		return ErrUnknownOperator
+	}
+	if math.IsNaN(condition.Value) || math.IsInf(condition.Value, 0) {
+		return errors.New("the value has to be a finite number")
+	}
+
+	switch condition.Metric {
+	case MetricCrosswind:
+		if !runwayPattern.MatchString(condition.Runway) {
+			return fmt.Errorf("the runway '%s' is invalid (expected a designator like '25' or '07L')", condition.Runway)
+		}
+	case MetricFlightCategory:
+		if condition.Category.Severity() < 0 {
+			return fmt.Errorf("the flight category '%s' is invalid", condition.Category)
+		}
+	case MetricWeather:
+		if !phenomenonPattern.MatchString(condition.Phenomenon) {
+			return fmt.Errorf("the weather phenomenon '%s' is invalid (expected a two-letter code like 'TS')", condition.Phenomenon)
+		}
+		if condition.Operator != OperatorEqual && condition.Operator != OperatorNotEqual {
+			return errors.New("weather conditions only support the operators 'eq' and 'ne'")
+		}
+	}
+	return nil
+}
+
+// Evaluate evaluates the condition on a decoded METAR.
+// known is false if the report does not contain the information required to evaluate the condition.
+func (condition *Condition) Evaluate(report *metar.Report) (matched bool, known bool) {
+	switch condition.Metric {
+	case MetricFlightCategory:
+		category := report.FlightCategory()
+		if category == metar.FlightCategoryUnknown {
+			return false, false
+		}
+		return condition.compare(float64(category.Severity()), float64(condition.Category.Severity())), true
+	case MetricWeather:
+		// Some codes (i.e. 'TS', 'SH' and 'FZ') are decoded as the descriptor of a weather group
+		present := false
+		for _, weather := range report.Weather {
+			present = present || weather.Descriptor == condition.Phenomenon
+			for _, phenomenon := range weather.Phenomena {
+				present = present || phenomenon == condition.Phenomenon
+			}
+		}
+		return present == (condition.Operator == OperatorEqual), true
+	}
+
+	value, ok := condition.value(report)
+	if !ok {
+		return false, false
+	}
+	return condition.compare(value, condition.Value), true
+}
+
+// value extracts the numeric value of the metric out of the given report
+func (condition *Condition) value(report *metar.Report) (float64, bool) {
+	switch condition.Metric {
+	case MetricVisibility:
+		if report.CAVOK {
+			return 10000, true
+		}
+		if report.Visibility == nil {
+			return 0, false
+		}
+		return report.Visibility.Meters(), true
+	case MetricCeiling:
+		ceiling, ok := report.Ceiling()
+		if !ok && !report.CAVOK {
+			return 0, false
+		}
+		if ceiling == nil {
+			return math.Inf(1), true
+		}
+		return float64(*ceiling), true
+	case MetricWindSpeed, MetricWindGust, MetricCrosswind:
+		if report.Wind == nil || report.Wind.Speed == nil {
+			return 0, false
+		}
+		speed := float64(*report.Wind.Speed)
+		if condition.Metric != MetricWindSpeed && report.Wind.Gust != nil {
+			speed = float64(*report.Wind.Gust)
+		}
+		speed = report.Wind.Unit.Knots(speed)
+		if condition.Metric != MetricCrosswind {
+			return speed, true
+		}
+		if report.Wind.Direction == nil {
+			return speed, true
+		}
+		heading, _ := strconv.Atoi(condition.Runway[:2])
+		angle := float64(*report.Wind.Direction-heading*10) * math.Pi / 180
+		return math.Abs(speed * math.Sin(angle)), true
+	case MetricTemperature:
+		if report.Temperature == nil {
+			return 0, false
+		}
+		return float64(*report.Temperature), true
+	case MetricAltimeter:
+		if report.Altimeter == nil {
+			return 0, false
+		}
+		return report.Altimeter.Hectopascals(), true
+	}
+	return 0, false
+}
+
+func (condition *Condition) compare(value, threshold float64) bool {
+	switch condition.Operator {
+	case OperatorLessThan:
+		return value < threshold
+	case OperatorLessThanOrEqual:
+		return value <= threshold
+	case OperatorGreaterThan:
+		return value > threshold
+	case OperatorGreaterThanOrEqual:
+		return value >= threshold
+	case OperatorEqual:
+		return value == threshold
+	case OperatorNotEqual:
+		return value != threshold
+	}
+	return false
+}
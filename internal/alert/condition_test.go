@@ -0,0 +1,39 @@
+package alert
+
+import (
+	"github.com/skybi/pluteo/internal/metar"
+	"testing"
+)
+
+func TestConditionEvaluatesWeather(t *testing.T) {
+	cases := []struct {
+		raw        string
+		phenomenon string
+		present    bool
+	}{
+		{raw: "EDDF 121250Z 24012KT 9999 TSRA FEW030CB 18/14 Q1012", phenomenon: "TS", present: true},
+		{raw: "EDDF 121250Z 24012KT 9999 TSRA FEW030CB 18/14 Q1012", phenomenon: "RA", present: true},
+		{raw: "EDDF 121250Z 24012KT 9999 TS FEW030CB 18/14 Q1012", phenomenon: "TS", present: true},
+		{raw: "EDDF 121250Z 24012KT 9999 -SHRA FEW030 18/14 Q1012", phenomenon: "SH", present: true},
+		{raw: "EDDF 121250Z 24012KT 0400 FZFG VV001 M02/M02 Q1022", phenomenon: "FZ", present: true},
+		{raw: "EDDF 121250Z 24012KT 0400 FZFG VV001 M02/M02 Q1022", phenomenon: "FG", present: true},
+		{raw: "EDDF 121250Z 24012KT 9999 -RA FEW030 18/14 Q1012", phenomenon: "TS", present: false},
+		{raw: "EDDF 121250Z 24012KT CAVOK 18/14 Q1012", phenomenon: "TS", present: false},
+	}
+	for _, c := range cases {
+		report, err := metar.Decode(c.raw)
+		if err != nil {
+			t.Fatalf("could not decode %q: %v", c.raw, err)
+		}
+		for _, operator := range []Operator{OperatorEqual, OperatorNotEqual} {
+			condition := &Condition{Metric: MetricWeather, Operator: operator, Phenomenon: c.phenomenon}
+			matched, known := condition.Evaluate(report)
+			if !known {
+				t.Errorf("%s %s on %q is unknown", c.phenomenon, operator, c.raw)
+			}
+			if expected := c.present == (operator == OperatorEqual); matched != expected {
+				t.Errorf("%s %s on %q = %t, expected %t", c.phenomenon, operator, c.raw, matched, expected)
+			}
+		}
+	}
+}
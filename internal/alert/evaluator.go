@@ -0,0 +1,149 @@
+package alert
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/skybi/pluteo/internal/event"
+	"github.com/skybi/pluteo/internal/metar"
+	"sync"
+	"time"
+)
+
+// WebhookSender enqueues a direct delivery of an event to a webhook
+type WebhookSender interface {
+	Enqueue(hookID uuid.UUID, eventType string, data any)
+}
+
+// Evaluator evaluates the enabled alert rules on every newly stored METAR and emits an alert event whenever the state
+// of a rule changes
+type Evaluator struct {
+	repo     Repository
+	metars   *event.Broker[*metar.METAR]
+	alerts   *event.Broker[*Event]
+	webhooks WebhookSender
+
+	mu  sync.Mutex
+	sub *event.Subscription[*metar.METAR]
+	wg  sync.WaitGroup
+}
+
+// NewEvaluator creates a new alert rule evaluator.
+// Alert events are published to the given alerts broker and delivered to the webhook of their rule (if any) using the
+// given webhook sender.
+func NewEvaluator(repo Repository, metars *event.Broker[*metar.METAR], alerts *event.Broker[*Event], webhooks WebhookSender) *Evaluator {
+	return &Evaluator{
+		repo:     repo,
+		metars:   metars,
+		alerts:   alerts,
+		webhooks: webhooks,
+	}
+}
+
+// Start starts evaluating newly stored METARs
+func (evaluator *Evaluator) Start() {
+	evaluator.mu.Lock()
+	defer evaluator.mu.Unlock()
+	if evaluator.sub != nil {
+		return
+	}
+	evaluator.sub = evaluator.metars.Subscribe(4096)
+	evaluator.wg.Add(1)
+	go evaluator.consume(evaluator.sub)
+}
+
+// Stop stops evaluating newly stored METARs and waits for the current evaluation to finish
+func (evaluator *Evaluator) Stop() {
+	evaluator.mu.Lock()
+	sub := evaluator.sub
+	evaluator.sub = nil
+	evaluator.mu.Unlock()
+	if sub == nil {
+		return
+	}
+	sub.Unsubscribe()
+	evaluator.wg.Wait()
+}
+
+func (evaluator *Evaluator) consume(sub *event.Subscription[*metar.METAR]) {
+	defer evaluator.wg.Done()
+	for obj := range sub.Events() {
+		evaluator.evaluate(obj)
+	}
+	if !sub.Lagged() {
+		return
+	}
+
+	// Re-subscribe if the evaluator was not stopped in the meantime
+	log.Warn().Msg("the alert evaluator did not keep up with the newly stored METARs; some were not evaluated")
+	evaluator.mu.Lock()
+	defer evaluator.mu.Unlock()
+	if evaluator.sub == sub {
+		evaluator.sub = evaluator.metars.Subscribe(cap(sub.Events()))
+		evaluator.wg.Add(1)
+		go evaluator.consume(evaluator.sub)
+	}
+}
+
+// evaluate evaluates all enabled rules of the station of the given METAR
+func (evaluator *Evaluator) evaluate(obj *metar.METAR) {
+	ctx := context.Background()
+
+	rules, err := evaluator.repo.GetEnabledByStation(ctx, obj.StationID)
+	if err != nil {
+		log.Error().Err(err).Str("station", obj.StationID).Msg("could not retrieve the alert rules of a station")
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	report, err := obj.Decode()
+	if err != nil {
+		return
+	}
+
+	for _, rule := range rules {
+		// METARs older than the latest evaluated one must not change the state of a rule
+		if obj.IssuedAt < rule.LastEvaluatedAt {
+			continue
+		}
+
+		update := &Update{
+			LastEvaluatedAt: &obj.IssuedAt,
+		}
+		triggered, known := rule.Evaluate(report)
+		var eventType EventType
+		if known && triggered != rule.Triggered {
+			update.Triggered = &triggered
+			if triggered {
+				eventType = EventTypeTriggered
+				update.LastTriggeredAt = &obj.IssuedAt
+			} else {
+				eventType = EventTypeResolved
+			}
+		}
+
+		if _, err := evaluator.repo.Update(ctx, rule.ID, update); err != nil {
+			log.Error().Err(err).Str("rule", rule.ID.String()).Msg("could not update the state of an alert rule")
+			continue
+		}
+		if eventType == "" {
+			continue
+		}
+
+		alert := &Event{
+			Type:      eventType,
+			RuleID:    rule.ID,
+			RuleName:  rule.Name,
+			UserID:    rule.UserID,
+			StationID: rule.StationID,
+			METAR:     obj,
+			Timestamp: time.Now().Unix(),
+		}
+		evaluator.alerts.Publish(alert)
+		if rule.WebhookID != nil && evaluator.webhooks != nil {
+			evaluator.webhooks.Enqueue(*rule.WebhookID, "alert", alert)
+		}
+	}
+}
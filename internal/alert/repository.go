@@ -0,0 +1,56 @@
+package alert
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// Repository defines the alert rule repository API
+type Repository interface {
+	// Get retrieves multiple alert rules
+	Get(ctx context.Context, offset, limit uint64) ([]*Rule, uint64, error)
+
+	// GetByUserID retrieves multiple alert rules of a specific user
+	GetByUserID(ctx context.Context, userID string, offset, limit uint64) ([]*Rule, uint64, error)
+
+	// GetByID retrieves an alert rule by its ID
+	GetByID(ctx context.Context, id uuid.UUID) (*Rule, error)
+
+	// GetEnabledByStation retrieves all enabled alert rules of a specific station
+	GetEnabledByStation(ctx context.Context, stationID string) ([]*Rule, error)
+
+	// Create creates a new alert rule
+	Create(ctx context.Context, create *Create) (*Rule, error)
+
+	// Update updates an alert rule
+	Update(ctx context.Context, id uuid.UUID, update *Update) (*Rule, error)
+
+	// Delete deletes an alert rule by its ID
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// Create is used to create a new alert rule
+type Create struct {
+	UserID     string
+	Name       string
+	StationID  string
+	Conditions []*Condition
+	WebhookID  *uuid.UUID
+}
+
+// Update is used to update an existing alert rule.
+// Changing the station or conditions resets the state of the rule.
+type Update struct {
+	Name       *string
+	StationID  *string
+	Conditions []*Condition
+
+	// WebhookID is left unchanged if it is nil; ClearWebhook removes the webhook
+	WebhookID    *uuid.UUID
+	ClearWebhook bool
+	Enabled      *bool
+
+	Triggered       *bool
+	LastEvaluatedAt *int64
+	LastTriggeredAt *int64
+}
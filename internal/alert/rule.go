@@ -0,0 +1,70 @@
+package alert
+
+import (
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/metar"
+)
+
+// MaxConditions defines the maximum amount of conditions a single rule may consist of
+var MaxConditions = 10
+
+// Rule represents an alert rule that is evaluated on every newly stored METAR of its station
+type Rule struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	StationID string    `json:"station_id"`
+
+	// Conditions have to be met all at once in order to trigger the rule
+	Conditions []*Condition `json:"conditions"`
+
+	// WebhookID is the ID of the webhook the alert events of the rule are delivered to (optional)
+	WebhookID *uuid.UUID `json:"webhook_id"`
+	Enabled   bool       `json:"enabled"`
+
+	// Triggered reflects whether the conditions were met by the latest evaluated METAR.
+	// Alert events are only emitted if this state changes, so that conditions that stay true do not fire repeatedly.
+	Triggered       bool   `json:"triggered"`
+	LastEvaluatedAt int64  `json:"last_evaluated_at"`
+	LastTriggeredAt *int64 `json:"last_triggered_at"`
+	CreatedAt       int64  `json:"created_at"`
+}
+
+// Evaluate evaluates the conditions of the rule on a decoded METAR.
+// known is false if at least one condition could not be evaluated and none of the others is unmet.
+func (rule *Rule) Evaluate(report *metar.Report) (triggered bool, known bool) {
+	known = true
+	for _, condition := range rule.Conditions {
+		matched, conditionKnown := condition.Evaluate(report)
+		if !conditionKnown {
+			known = false
+			continue
+		}
+		if !matched {
+			return false, true
+		}
+	}
+	return known, known
+}
+
+// EventType represents the type of alert event
+type EventType string
+
+const (
+	// EventTypeTriggered is emitted once the conditions of a rule are met
+	EventTypeTriggered EventType = "triggered"
+
+	// EventTypeResolved is emitted once the conditions of a triggered rule are no longer met
+	EventTypeResolved EventType = "resolved"
+)
+
+// Event represents a change of the state of an alert rule
+type Event struct {
+	Type      EventType    `json:"type"`
+	RuleID    uuid.UUID    `json:"rule_id"`
+	RuleName  string       `json:"rule_name"`
+	UserID    string       `json:"user_id"`
+	StationID string       `json:"station_id"`
+	METAR     *metar.METAR `json:"metar"`
+	Timestamp int64        `json:"timestamp"`
+}
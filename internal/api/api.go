@@ -2,10 +2,15 @@ package api
 
 import (
 	"errors"
+	"github.com/skybi/pluteo/internal/alert"
 	"github.com/skybi/pluteo/internal/api/data"
 	"github.com/skybi/pluteo/internal/api/portal"
 	"github.com/skybi/pluteo/internal/apikey/quota"
 	"github.com/skybi/pluteo/internal/config"
+	"github.com/skybi/pluteo/internal/event"
+	"github.com/skybi/pluteo/internal/export"
+	"github.com/skybi/pluteo/internal/ingest"
+	"github.com/skybi/pluteo/internal/metar"
 	"github.com/skybi/pluteo/internal/storage"
 	"net/http"
 )
@@ -15,6 +20,10 @@ type Service struct {
 	Config       *config.Config
 	Storage      storage.Driver
 	QuotaTracker *quota.Tracker
+	METAREvents  *event.Broker[*metar.METAR]
+	AlertEvents  *event.Broker[*alert.Event]
+	Exports      *export.Manager
+	Ingester     *ingest.Ingester
 
 	portal *portal.Service
 	data   *data.Service
@@ -23,8 +32,9 @@ type Service struct {
 // Startup starts up the portal & data APIs
 func (service *Service) Startup(errs chan<- error) {
 	portalService := &portal.Service{
-		Config:  service.Config,
-		Storage: service.Storage,
+		Config:   service.Config,
+		Storage:  service.Storage,
+		Ingester: service.Ingester,
 	}
 	service.portal = portalService
 	go func() {
@@ -37,6 +47,9 @@ func (service *Service) Startup(errs chan<- error) {
 		Config:       service.Config,
 		Storage:      service.Storage,
 		QuotaTracker: service.QuotaTracker,
+		METAREvents:  service.METAREvents,
+		AlertEvents:  service.AlertEvents,
+		Exports:      service.Exports,
 	}
 	service.data = dataService
 	go func() {
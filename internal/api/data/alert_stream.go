@@ -0,0 +1,72 @@
+package data
+
+import (
+	"fmt"
+	"github.com/skybi/pluteo/internal/apikey"
+	"net/http"
+	"time"
+)
+
+// alertStreamBufferSize defines how many alert events may be queued for a single stream before it is closed
+var alertStreamBufferSize = 64
+
+// EndpointStreamAlerts handles the 'GET /v1/alerts/stream' endpoint.
+// It streams the alert events of all rules of the user owning the API key as server-sent events (event type 'alert').
+// Opening a stream counts as a single request regarding the rate limit; every delivered event consumes one unit of the
+// API key's quota. The stream is closed as soon as the quota is exhausted.
+func (service *Service) EndpointStreamAlerts(writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		service.writer.WriteErrors(writer, http.StatusNotImplemented, errStreamingUnsupported)
+		return
+	}
+
+	key := request.Context().Value(contextValueKey).(*apikey.Key)
+
+	sub := service.AlertEvents.Subscribe(alertStreamBufferSize)
+	defer sub.Unsubscribe()
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(metarStreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case alert, ok := <-sub.Events():
+			if !ok {
+				if sub.Lagged() {
+					writeServerSentEvent(writer, "", "error", errStreamLagged)
+					flusher.Flush()
+				}
+				return
+			}
+			if alert.UserID != key.UserID {
+				continue
+			}
+			if err := writeServerSentEvent(writer, "", "alert", alert); err != nil {
+				return
+			}
+			flusher.Flush()
+
+			service.QuotaTracker.Accumulate(key)
+			if key.Quota >= 0 && service.QuotaTracker.Get(key) >= key.Quota {
+				writeServerSentEvent(writer, "", "error", errKeyNoQuotaLeft)
+				flusher.Flush()
+				return
+			}
+		}
+	}
+}
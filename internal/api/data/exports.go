@@ -0,0 +1,224 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/export"
+	"github.com/skybi/pluteo/internal/station"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// exportMaxStations defines how many stations a single export job may be restricted to
+var exportMaxStations = 1000
+
+var (
+	errExportInvalidFormat = func(format string) *schema.Error {
+		return &schema.Error{
+			Type:    "data.exports.invalidFormat",
+			Message: fmt.Sprintf("The export format '%s' is not supported.", format),
+			Details: map[string]any{
+				"format":    format,
+				"supported": export.Formats,
+			},
+		}
+	}
+	errExportInvalidTimeRange = &schema.Error{
+		Type:    "data.exports.invalidTimeRange",
+		Message: "The start of the time range must not be after its end.",
+		Details: map[string]any{},
+	}
+	errExportTooManyStations = func(given, max int) *schema.Error {
+		return &schema.Error{
+			Type:    "data.exports.tooManyStations",
+			Message: fmt.Sprintf("A single export job may only be restricted to %d stations (%d were given).", max, given),
+			Details: map[string]any{
+				"given": given,
+				"max":   max,
+			},
+		}
+	}
+	errExportTooManyActiveJobs = &schema.Error{
+		Type:    "data.exports.tooManyActiveJobs",
+		Message: "The API key already has the maximum amount of pending or running export jobs.",
+		Details: map[string]any{},
+	}
+	errExportQueueFull = &schema.Error{
+		Type:    "data.exports.queueFull",
+		Message: "The export service is busy; please try again later.",
+		Details: map[string]any{},
+	}
+	errExportNotCompleted = func(status export.Status) *schema.Error {
+		return &schema.Error{
+			Type:    "data.exports.notCompleted",
+			Message: fmt.Sprintf("The export job is not completed (status: %s).", status),
+			Details: map[string]any{
+				"status": status,
+			},
+		}
+	}
+)
+
+type endpointCreateExportRequestPayload struct {
+	Stations          []string `json:"stations"`
+	From              *int64   `json:"from" required:"true" min:"0"`
+	To                *int64   `json:"to" required:"true" min:"0"`
+	Format            *string  `json:"format" required:"true"`
+	IncludeSuperseded *bool    `json:"include_superseded"`
+}
+
+// EndpointCreateExport handles the 'POST /v1/exports' endpoint.
+// It queues a job exporting all METARs of the given stations (or all stations) issued inside the given time range.
+// Every exported METAR consumes one unit of the API key's quota; the export is truncated once the quota is exhausted.
+func (service *Service) EndpointCreateExport(writer http.ResponseWriter, request *http.Request) {
+	key := request.Context().Value(contextValueKey).(*apikey.Key)
+
+	body, validationErrs, err := schema.UnmarshalBody[endpointCreateExportRequestPayload](request)
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	format := export.Format(strings.ToLower(*body.Format))
+	if !format.IsValid() {
+		validationErrs = append(validationErrs, errExportInvalidFormat(*body.Format))
+	}
+	if *body.From > *body.To {
+		validationErrs = append(validationErrs, errExportInvalidTimeRange)
+	}
+	stationIDs := make([]string, 0, len(body.Stations))
+	seen := make(map[string]struct{}, len(body.Stations))
+	for _, stationID := range body.Stations {
+		stationID = strings.ToUpper(strings.TrimSpace(stationID))
+		if _, ok := seen[stationID]; ok {
+			continue
+		}
+		if !station.IsValidICAO(stationID) {
+			validationErrs = append(validationErrs, errMETARInvalidStationID(stationID))
+			continue
+		}
+		seen[stationID] = struct{}{}
+		stationIDs = append(stationIDs, stationID)
+	}
+	if len(stationIDs) > exportMaxStations {
+		validationErrs = append(validationErrs, errExportTooManyStations(len(stationIDs), exportMaxStations))
+	}
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	filter := &export.Filter{
+		StationIDs: stationIDs,
+		From:       *body.From,
+		To:         *body.To,
+	}
+	if body.IncludeSuperseded != nil {
+		filter.IncludeSuperseded = *body.IncludeSuperseded
+	}
+
+	job, err := service.Exports.Submit(key.ID, filter, format)
+	if err != nil {
+		switch {
+		case errors.Is(err, export.ErrTooManyActiveJobs):
+			service.writer.WriteErrors(writer, http.StatusTooManyRequests, errExportTooManyActiveJobs)
+		case errors.Is(err, export.ErrQueueFull):
+			service.writer.WriteErrors(writer, http.StatusServiceUnavailable, errExportQueueFull)
+		default:
+			service.writer.WriteInternalError(writer, err)
+		}
+		return
+	}
+
+	writer.Header().Set("Location", "/v1/exports/"+job.ID.String())
+	service.writer.WriteJSONWithCode(writer, http.StatusAccepted, job)
+}
+
+// EndpointGetExports handles the 'GET /v1/exports' endpoint.
+// It lists all export jobs of the API key that did not expire yet.
+func (service *Service) EndpointGetExports(writer http.ResponseWriter, request *http.Request) {
+	key := request.Context().Value(contextValueKey).(*apikey.Key)
+	jobs := service.Exports.GetByKey(key.ID)
+	n := uint64(len(jobs))
+	service.writer.WriteJSON(writer, schema.BuildPaginatedResponse(0, n, n, jobs))
+}
+
+// EndpointGetExport handles the 'GET /v1/exports/{id}' endpoint
+func (service *Service) EndpointGetExport(writer http.ResponseWriter, request *http.Request) {
+	job := service.fetchOwnExport(writer, request)
+	if job == nil {
+		return
+	}
+	service.writer.WriteJSON(writer, job)
+}
+
+// EndpointDownloadExport handles the 'GET /v1/exports/{id}/download' endpoint.
+// It serves the gzip compressed result of a completed export job; downloading it does not consume any quota.
+func (service *Service) EndpointDownloadExport(writer http.ResponseWriter, request *http.Request) {
+	job := service.fetchOwnExport(writer, request)
+	if job == nil {
+		return
+	}
+	if job.Status != export.StatusCompleted {
+		service.writer.WriteErrors(writer, http.StatusConflict, errExportNotCompleted(job.Status))
+		return
+	}
+
+	file, err := os.Open(service.Exports.Path(job))
+	if err != nil {
+		// The result may have been deleted in the meantime
+		if errors.Is(err, os.ErrNotExist) {
+			service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		} else {
+			service.writer.WriteInternalError(writer, err)
+		}
+		return
+	}
+	defer file.Close()
+
+	writer.Header().Set("Content-Type", "application/gzip")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", job.FileName()))
+	http.ServeContent(writer, request, job.FileName(), time.Unix(*job.FinishedAt, 0), file)
+}
+
+// EndpointDeleteExport handles the 'DELETE /v1/exports/{id}' endpoint.
+// Active jobs are cancelled; finished jobs are deleted together with their result.
+func (service *Service) EndpointDeleteExport(writer http.ResponseWriter, request *http.Request) {
+	job := service.fetchOwnExport(writer, request)
+	if job == nil {
+		return
+	}
+	if err := service.Exports.Delete(job.ID); err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// fetchOwnExport retrieves the export job referenced by the 'id' URL parameter if it was submitted by the requesting
+// API key and writes a 404 response otherwise
+func (service *Service) fetchOwnExport(writer http.ResponseWriter, request *http.Request) *export.Job {
+	key := request.Context().Value(contextValueKey).(*apikey.Key)
+
+	id, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return nil
+	}
+	job := service.Exports.GetByID(id)
+	if job == nil || job.KeyID != key.ID {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return nil
+	}
+	return job
+}
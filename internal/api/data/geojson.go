@@ -0,0 +1,89 @@
+package data
+
+import (
+	"context"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/station"
+)
+
+// metarFeatureProperties represents the properties of a GeoJSON feature representing a METAR
+type metarFeatureProperties struct {
+	*metar.METAR
+	*metar.Summary
+	StationName string `json:"station_name,omitempty"`
+
+	// Distance is only set for results of geospatial queries (see areaMETAR)
+	Distance *float64 `json:"distance,omitempty"`
+}
+
+// newMETARFeature creates a GeoJSON feature representing a METAR located at its station.
+// The feature has no geometry if the station is unknown.
+func newMETARFeature(obj *metar.METAR, stationObj *station.Station, distance *float64) *schema.Feature {
+	properties := &metarFeatureProperties{
+		METAR:    obj,
+		Summary:  obj.Summarize(),
+		Distance: distance,
+	}
+	if stationObj == nil {
+		return schema.NewFeature(obj.ID.String(), properties)
+	}
+	properties.StationName = stationObj.Name
+	return schema.NewPointFeature(obj.ID.String(), stationObj.Latitude, stationObj.Longitude, properties)
+}
+
+// stationMETAR wraps a METAR together with its station in order to write it as a GeoJSON feature
+type stationMETAR struct {
+	*metar.METAR
+	Station *station.Station `json:"station"`
+}
+
+// GeoJSONFeature returns the GeoJSON feature representing the METAR
+func (result *stationMETAR) GeoJSONFeature() *schema.Feature {
+	return newMETARFeature(result.METAR, result.Station, nil)
+}
+
+// GeoJSONFeature returns the GeoJSON feature representing the METAR including its distance
+func (result *areaMETAR) GeoJSONFeature() *schema.Feature {
+	return newMETARFeature(result.METAR, result.Station, &result.Distance)
+}
+
+// GeoJSONFeature returns the GeoJSON feature representing the METAR
+func (result *latestMETAR) GeoJSONFeature() *schema.Feature {
+	return newMETARFeature(result.METAR, result.Station, nil)
+}
+
+// stationFeature wraps a station in order to write it as a GeoJSON feature
+type stationFeature struct {
+	*station.Station
+}
+
+// GeoJSONFeature returns the GeoJSON feature representing the station
+func (obj *stationFeature) GeoJSONFeature() *schema.Feature {
+	return schema.NewPointFeature(obj.ICAO, obj.Latitude, obj.Longitude, obj.Station)
+}
+
+// locateMETARs joins the given METARs with their stations
+func (service *Service) locateMETARs(ctx context.Context, metars []*metar.METAR) ([]*stationMETAR, error) {
+	stationIDs := make([]string, 0, len(metars))
+	seen := make(map[string]struct{}, len(metars))
+	for _, obj := range metars {
+		if _, ok := seen[obj.StationID]; !ok {
+			seen[obj.StationID] = struct{}{}
+			stationIDs = append(stationIDs, obj.StationID)
+		}
+	}
+	stations, err := service.Storage.Stations().GetByICAOs(ctx, stationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	located := make([]*stationMETAR, 0, len(metars))
+	for _, obj := range metars {
+		located = append(located, &stationMETAR{
+			METAR:   obj,
+			Station: stations[obj.StationID],
+		})
+	}
+	return located, nil
+}
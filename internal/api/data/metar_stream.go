@@ -0,0 +1,213 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/metar"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// metarStreamBufferSize defines how many METARs may be queued for a single stream before it is closed
+	metarStreamBufferSize = 256
+
+	// metarStreamMaxBacklog defines how many missed METARs are replayed at most when a stream is resumed.
+	// If more METARs were missed, the stream is closed after replaying them so that the client resumes again.
+	metarStreamMaxBacklog = 1000
+
+	// metarStreamHeartbeatInterval defines the interval in which comments are sent to keep idle streams alive
+	metarStreamHeartbeatInterval = 30 * time.Second
+)
+
+var (
+	errStreamingUnsupported = &schema.Error{
+		Type:    "data.stream.unsupported",
+		Message: "The connection does not support streaming responses.",
+	}
+	errStreamLagged = &schema.Error{
+		Type:    "data.stream.lagged",
+		Message: "The stream was closed because the client did not keep up with the events; resume it using the last event ID.",
+	}
+	errMETARInvalidLastEventID = func(id string) *schema.Error {
+		return &schema.Error{
+			Type:    "data.metars.invalidLastEventID",
+			Message: fmt.Sprintf("The last event ID '%s' is invalid (expected a METAR sequence number).", id),
+			Details: map[string]any{
+				"last_event_id": id,
+			},
+		}
+	}
+)
+
+// metarEvent represents the payload of a single event of the METAR stream
+type metarEvent struct {
+	*metar.METAR
+	Decoded *metar.Report `json:"decoded,omitempty"`
+}
+
+// EndpointStreamMETARs handles the 'GET /v1/metars/stream?stations={comma-separated strings?}&decode={bool?:false}&last_event_id={number?}' endpoint.
+// It streams every newly fed METAR as a server-sent event whose ID is the sequence number of the METAR. A stream is
+// resumed using the 'Last-Event-ID' header (or the 'last_event_id' parameter), in which case all METARs stored since
+// that event are replayed first.
+// Opening a stream counts as a single request regarding the rate limit; every delivered event consumes one unit of the
+// API key's quota. The stream is closed as soon as the quota is exhausted.
+func (service *Service) EndpointStreamMETARs(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	rawStations, _ := schema.QueryString(request, "stations", false, "")
+	stationIDs, stationErrs := parseStationIDs(rawStations)
+	validationErrs = append(validationErrs, stationErrs...)
+
+	decode, validationErr := schema.QueryBool(request, "decode", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	lastEventID := int64(-1)
+	rawLastEventID := strings.TrimSpace(request.Header.Get("Last-Event-ID"))
+	if rawLastEventID == "" {
+		rawLastEventID = strings.TrimSpace(request.URL.Query().Get("last_event_id"))
+	}
+	if rawLastEventID != "" {
+		parsed, err := strconv.ParseInt(rawLastEventID, 10, 64)
+		if err != nil || parsed < 0 {
+			validationErrs = append(validationErrs, errMETARInvalidLastEventID(rawLastEventID))
+		}
+		lastEventID = parsed
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		service.writer.WriteErrors(writer, http.StatusNotImplemented, errStreamingUnsupported)
+		return
+	}
+
+	key := request.Context().Value(contextValueKey).(*apikey.Key)
+
+	var stationFilter map[string]struct{}
+	if len(stationIDs) > 0 {
+		stationFilter = make(map[string]struct{}, len(stationIDs))
+		for _, stationID := range stationIDs {
+			stationFilter[stationID] = struct{}{}
+		}
+	} else {
+		stationIDs = nil
+	}
+
+	// Subscribe before the backlog is fetched so that no METAR stored in the meantime is missed
+	sub := service.METAREvents.Subscribe(metarStreamBufferSize)
+	defer sub.Unsubscribe()
+
+	var backlog []*metar.METAR
+	if lastEventID >= 0 {
+		var err error
+		backlog, err = service.Storage.METARs().GetSinceSequence(request.Context(), lastEventID, stationIDs, uint64(metarStreamMaxBacklog)+1)
+		if err != nil {
+			service.writer.WriteInternalError(writer, err)
+			return
+		}
+	}
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// send writes a single METAR event and charges the API key; it returns false if the stream has to be closed
+	send := func(obj *metar.METAR) bool {
+		payload := &metarEvent{METAR: obj}
+		if decode {
+			payload.Decoded, _ = obj.Decode()
+		}
+		if err := writeServerSentEvent(writer, strconv.FormatInt(obj.Sequence, 10), "metar", payload); err != nil {
+			return false
+		}
+		flusher.Flush()
+
+		service.QuotaTracker.Accumulate(key)
+		if key.Quota >= 0 && service.QuotaTracker.Get(key) >= key.Quota {
+			writeServerSentEvent(writer, "", "error", errKeyNoQuotaLeft)
+			flusher.Flush()
+			return false
+		}
+		return true
+	}
+
+	// Replay the METARs the client missed; if there are too many, the client has to resume again afterwards
+	replayed := make(map[uuid.UUID]struct{}, len(backlog))
+	for i, obj := range backlog {
+		if i == metarStreamMaxBacklog {
+			return
+		}
+		if !send(obj) {
+			return
+		}
+		replayed[obj.ID] = struct{}{}
+	}
+
+	heartbeat := time.NewTicker(metarStreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case obj, ok := <-sub.Events():
+			// The subscription is cancelled if the client does not keep up; it may resume using the last event ID
+			if !ok {
+				if sub.Lagged() {
+					writeServerSentEvent(writer, "", "error", errStreamLagged)
+					flusher.Flush()
+				}
+				return
+			}
+			if _, ok := replayed[obj.ID]; ok {
+				continue
+			}
+			if stationFilter != nil {
+				if _, ok := stationFilter[obj.StationID]; !ok {
+					continue
+				}
+			}
+			if !send(obj) {
+				return
+			}
+		}
+	}
+}
+
+// writeServerSentEvent writes a single server-sent event with a JSON payload
+func writeServerSentEvent(writer http.ResponseWriter, id, event string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var builder strings.Builder
+	if id != "" {
+		builder.WriteString("id: " + id + "\n")
+	}
+	builder.WriteString("event: " + event + "\n")
+	builder.WriteString("data: ")
+	builder.Write(data)
+	builder.WriteString("\n\n")
+	_, err = writer.Write([]byte(builder.String()))
+	return err
+}
@@ -1,6 +1,9 @@
 package data
 
 import (
+	"bufio"
+	"context"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"github.com/go-chi/chi/v5"
@@ -8,13 +11,39 @@ import (
 	"github.com/skybi/pluteo/internal/api/schema"
 	"github.com/skybi/pluteo/internal/apikey"
 	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/station"
+	"io"
 	"math"
+	"mime"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var metarFeedBatchMaxSize = 500
 
+// metarFeedTextMaxBodySize defines how many bytes an atomic plain text METAR feed request body may contain.
+// Atomic bodies are buffered completely and stored in a single transaction, so they cannot be streamed.
+var metarFeedTextMaxBodySize int64 = 8 << 20
+
+// metarFeedTextMaxReports defines how many METARs an atomic plain text METAR feed request body may contain
+var metarFeedTextMaxReports = 50000
+
+// metarLatestMaxStations defines how many stations a single latest METAR request may query
+var metarLatestMaxStations = 100
+
+// metarAreaMaxRadius defines the maximum radius (in kilometers) of a geospatial METAR query
+var metarAreaMaxRadius = 1000.0
+
+// metarAreaMaxBBoxSpan defines the maximum latitude and longitude span (in degrees) of a geospatial METAR query's
+// bounding box
+var metarAreaMaxBBoxSpan = 20.0
+
+// metarAreaMaxStations defines how many stations the area of a geospatial METAR query may contain
+var metarAreaMaxStations = 2000
+
 var (
 	errMETARTooLargeBatch = func(given, max int) *schema.Error {
 		return &schema.Error{
@@ -26,6 +55,81 @@ var (
 			},
 		}
 	}
+	errMETARTooManyReports = func(max int) *schema.Error {
+		return &schema.Error{
+			Type:    "data.metars.tooManyReports",
+			Message: fmt.Sprintf("A single atomic plain text METAR request may only feed %d METARs.", max),
+			Details: map[string]any{
+				"max": max,
+			},
+		}
+	}
+	errMETARTooLargeBody = func(max int64) *schema.Error {
+		return &schema.Error{
+			Type:    "data.metars.tooLargeBody",
+			Message: fmt.Sprintf("An atomic plain text METAR request body may only be %d bytes large.", max),
+			Details: map[string]any{
+				"max": max,
+			},
+		}
+	}
+	errMETARInvalidArea = func(message string) *schema.Error {
+		return &schema.Error{
+			Type:    "data.metars.invalidArea",
+			Message: message,
+		}
+	}
+	errMETARTooLargeArea = func(max int) *schema.Error {
+		return &schema.Error{
+			Type:    "data.metars.tooLargeArea",
+			Message: fmt.Sprintf("The area contains more than %d stations.", max),
+			Details: map[string]any{
+				"max": max,
+			},
+		}
+	}
+	errMETARTooManyStations = func(given, max int) *schema.Error {
+		return &schema.Error{
+			Type:    "data.metars.tooManyStations",
+			Message: fmt.Sprintf("A single latest METAR request may only query %d stations (%d were given).", max, given),
+			Details: map[string]any{
+				"given": given,
+				"max":   max,
+			},
+		}
+	}
+	errMETARInvalidStationID = func(stationID string) *schema.Error {
+		return &schema.Error{
+			Type:    "data.metars.invalidStationID",
+			Message: fmt.Sprintf("The station ID '%s' is formatted incorrectly (expected 4 alphanumeric characters).", stationID),
+			Details: map[string]any{
+				"station_id": stationID,
+			},
+		}
+	}
+	errMETARInvalidCursor = func(cursor string) *schema.Error {
+		return &schema.Error{
+			Type:    "data.metars.invalidCursor",
+			Message: fmt.Sprintf("The cursor '%s' is invalid.", cursor),
+			Details: map[string]any{
+				"cursor": cursor,
+			},
+		}
+	}
+	errMETARLineTooLong = &schema.Error{
+		Type:    "data.metars.lineTooLong",
+		Message: "The request body contains a line that exceeds the maximum line length.",
+		Details: map[string]any{},
+	}
+	errMETARDecodeUnsupported = func(format schema.Format) *schema.Error {
+		return &schema.Error{
+			Type:    "data.metars.decodeUnsupported",
+			Message: fmt.Sprintf("Decoded METARs cannot be represented using the '%s' format; its records already contain their summary.", format),
+			Details: map[string]any{
+				"format": format,
+			},
+		}
+	}
 	errMETARInvalidFormat = func(raw string, i int) *schema.Error {
 		return &schema.Error{
 			Type:    "data.metars.invalidFormat",
@@ -37,12 +141,218 @@ var (
 	}
 )
 
-// EndpointGetMETARs handles the 'GET /v1/metars?station_id={string?}&before={timestamp?}&after={timestamp?}&limit={number?:10}' endpoint
+// supportsDecoding returns whether the fully decoded representation of a METAR can be written using the given format.
+// CSV and XML records have a fixed layout and only contain the summary of a METAR.
+func supportsDecoding(format schema.Format) bool {
+	return format != schema.FormatCSV && format != schema.FormatXML
+}
+
+type decodedMETAR struct {
+	*metar.METAR
+	Decoded *metar.Report `json:"decoded"`
+}
+
+// decodeMETARs wraps the given METARs together with their fully decoded representation.
+// METARs that cannot be decoded are included with a nil representation.
+func decodeMETARs(metars []*metar.METAR) []*decodedMETAR {
+	decoded := make([]*decodedMETAR, 0, len(metars))
+	for _, obj := range metars {
+		report, _ := obj.Decode()
+		decoded = append(decoded, &decodedMETAR{
+			METAR:   obj,
+			Decoded: report,
+		})
+	}
+	return decoded
+}
+
+// metarArea represents the area of a geospatial METAR query
+type metarArea struct {
+	bounds *station.Bounds
+
+	// latitude and longitude represent the point the distances of the stations are calculated relative to
+	latitude  float64
+	longitude float64
+
+	// radius is the radius around the point in kilometers; 0 if the area is a bounding box
+	radius float64
+}
+
+// areaMETAR represents the latest METAR of a station inside the area of a geospatial METAR query
+type areaMETAR struct {
+	*metar.METAR
+	Decoded *metar.Report    `json:"decoded,omitempty"`
+	Station *station.Station `json:"station"`
+
+	// Distance is the distance of the station to the requested point (or the center of the bounding box) in kilometers
+	Distance float64 `json:"distance"`
+}
+
+// CSVHeader returns the columns of the METAR followed by the ones describing the station's location
+func (result *areaMETAR) CSVHeader() []string {
+	return append(new(metar.METAR).CSVHeader(), "station_name", "latitude", "longitude", "distance")
+}
+
+// CSVRecord returns the CSV representation of the result (see CSVHeader)
+func (result *areaMETAR) CSVRecord() []string {
+	record := result.METAR.CSVRecord()
+	if result.Station == nil {
+		return append(record, "", "", "", strconv.FormatFloat(result.Distance, 'f', 3, 64))
+	}
+	return append(
+		record,
+		result.Station.Name,
+		strconv.FormatFloat(result.Station.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(result.Station.Longitude, 'f', -1, 64),
+		strconv.FormatFloat(result.Distance, 'f', 3, 64),
+	)
+}
+
+// xmlAreaStation represents the station of an areaMETAR inside its XML representation
+type xmlAreaStation struct {
+	XMLName   xml.Name `xml:"station"`
+	Name      string   `xml:"name"`
+	Latitude  float64  `xml:"latitude"`
+	Longitude float64  `xml:"longitude"`
+}
+
+// xmlAreaDistance represents the distance of an areaMETAR inside its XML representation
+type xmlAreaDistance struct {
+	XMLName xml.Name `xml:"distance"`
+	Value   string   `xml:",chardata"`
+}
+
+// MarshalXML writes the XML representation of the METAR followed by its station's location and distance
+func (result *areaMETAR) MarshalXML(encoder *xml.Encoder, _ xml.StartElement) error {
+	var extra []any
+	if result.Station != nil {
+		extra = append(extra, &xmlAreaStation{
+			Name:      result.Station.Name,
+			Latitude:  result.Station.Latitude,
+			Longitude: result.Station.Longitude,
+		})
+	}
+	extra = append(extra, &xmlAreaDistance{Value: strconv.FormatFloat(result.Distance, 'f', 3, 64)})
+	return result.METAR.EncodeXML(encoder, extra...)
+}
+
+// parseMETARArea extracts the optional area of a geospatial METAR query out of the query parameters of the given
+// request. The area is either given as a point and a radius ('lat', 'lon' and 'radius_km') or as a bounding box in the
+// format 'bbox=min_lon,min_lat,max_lon,max_lat'.
+func parseMETARArea(request *http.Request) (*metarArea, []*schema.Error) {
+	query := request.URL.Query()
+	hasPoint := query.Get("lat") != "" || query.Get("lon") != "" || query.Get("radius_km") != ""
+	rawBBox := strings.TrimSpace(query.Get("bbox"))
+
+	if hasPoint && rawBBox != "" {
+		return nil, []*schema.Error{errMETARInvalidArea("The 'bbox' parameter cannot be combined with the 'lat', 'lon' and 'radius_km' parameters.")}
+	}
+
+	if hasPoint {
+		var validationErrs []*schema.Error
+
+		latitude, validationErr := schema.QueryFloat(request, "lat", true, 0, -90, 90)
+		if validationErr != nil {
+			validationErrs = append(validationErrs, validationErr)
+		}
+
+		longitude, validationErr := schema.QueryFloat(request, "lon", true, 0, -180, 180)
+		if validationErr != nil {
+			validationErrs = append(validationErrs, validationErr)
+		}
+
+		radius, validationErr := schema.QueryFloat(request, "radius_km", true, 0, 0, metarAreaMaxRadius)
+		if validationErr != nil {
+			validationErrs = append(validationErrs, validationErr)
+		}
+
+		if len(validationErrs) > 0 {
+			return nil, validationErrs
+		}
+		return &metarArea{
+			bounds:    station.BoundsAround(latitude, longitude, radius),
+			latitude:  latitude,
+			longitude: longitude,
+			radius:    radius,
+		}, nil
+	}
+
+	if rawBBox != "" {
+		parts := strings.Split(rawBBox, ",")
+		if len(parts) != 4 {
+			return nil, []*schema.Error{errMETARInvalidArea("The 'bbox' parameter has to be given in the format 'min_lon,min_lat,max_lon,max_lat'.")}
+		}
+		values := make([]float64, 0, len(parts))
+		for _, part := range parts {
+			value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+				return nil, []*schema.Error{errMETARInvalidArea("The 'bbox' parameter has to consist of four numbers.")}
+			}
+			values = append(values, value)
+		}
+		area, validationErr := newBBoxMETARArea(values)
+		if validationErr != nil {
+			return nil, []*schema.Error{validationErr}
+		}
+		return area, nil
+	}
+
+	return nil, nil
+}
+
+// newBBoxMETARArea validates a bounding box given in the order 'min_lon,min_lat,max_lon,max_lat' and creates the
+// corresponding METAR query area
+func newBBoxMETARArea(values []float64) (*metarArea, *schema.Error) {
+	if len(values) != 4 {
+		return nil, errMETARInvalidArea("The 'bbox' parameter has to be given in the format 'min_lon,min_lat,max_lon,max_lat'.")
+	}
+	bounds := &station.Bounds{
+		MinLongitude: values[0],
+		MinLatitude:  values[1],
+		MaxLongitude: values[2],
+		MaxLatitude:  values[3],
+	}
+	if math.Abs(bounds.MinLatitude) > 90 || math.Abs(bounds.MaxLatitude) > 90 || math.Abs(bounds.MinLongitude) > 180 || math.Abs(bounds.MaxLongitude) > 180 {
+		return nil, errMETARInvalidArea("The coordinates of the 'bbox' parameter are out of range.")
+	}
+	if bounds.MinLatitude > bounds.MaxLatitude {
+		return nil, errMETARInvalidArea("The minimum latitude of the 'bbox' parameter is greater than its maximum latitude.")
+	}
+	if bounds.MaxLatitude-bounds.MinLatitude > metarAreaMaxBBoxSpan || bounds.LongitudeSpan() > metarAreaMaxBBoxSpan {
+		return nil, errMETARInvalidArea(fmt.Sprintf("The 'bbox' parameter may span at most %g degrees of latitude and longitude.", metarAreaMaxBBoxSpan))
+	}
+	latitude, longitude := bounds.Center()
+	return &metarArea{
+		bounds:    bounds,
+		latitude:  latitude,
+		longitude: longitude,
+	}, nil
+}
+
+// contains returns whether the given station lies inside the area
+func (area *metarArea) contains(obj *station.Station) bool {
+	if area.radius > 0 {
+		return obj.DistanceTo(area.latitude, area.longitude) <= area.radius
+	}
+	return area.bounds.Contains(obj.Latitude, obj.Longitude)
+}
+
+// EndpointGetMETARs handles the 'GET /v1/metars?station_id={string?}&lat={float?}&lon={float?}&radius_km={float?}&bbox={min_lon,min_lat,max_lon,max_lat?}&before={timestamp?}&after={timestamp?}&type={METAR|SPECI?}&corrected={bool?}&automated={bool?}&nil={bool?}&flight_category={VFR|MVFR|IFR|LIFR?}&include_superseded={bool?:false}&cursor={string?}&count={bool?:true}&limit={number?:10}&offset={number?:0}&decode={bool?:false}&format={json|ndjson|csv|xml|geojson?:json}' endpoint.
+// If an area is given (either 'lat', 'lon' and 'radius_km' or 'bbox'), the latest METAR of every station inside the area
+// is returned instead, ordered by the distance of the station to the requested point (or the center of the bounding box).
+// Areas are paginated using 'offset' instead of 'cursor'.
+// Decoding is not supported by the CSV and XML formats.
 func (service *Service) EndpointGetMETARs(writer http.ResponseWriter, request *http.Request) {
 	var validationErrs []*schema.Error
 
 	stationID := strings.ToUpper(strings.TrimSpace(request.URL.Query().Get("station_id")))
 
+	area, areaErrs := parseMETARArea(request)
+	validationErrs = append(validationErrs, areaErrs...)
+	if area != nil && stationID != "" {
+		validationErrs = append(validationErrs, errMETARInvalidArea("The 'station_id' parameter cannot be combined with an area."))
+	}
+
 	before, validationErr := schema.QueryNumber(request, "before", false, -1, 0, math.MaxInt64)
 	if validationErr != nil {
 		validationErrs = append(validationErrs, validationErr)
@@ -53,17 +363,105 @@ func (service *Service) EndpointGetMETARs(writer http.ResponseWriter, request *h
 		validationErrs = append(validationErrs, validationErr)
 	}
 
+	reportType, validationErr := schema.QueryEnum(request, "type", false, "", string(metar.ReportTypeMETAR), string(metar.ReportTypeSPECI))
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	corrected, validationErr := schema.QueryOptionalBool(request, "corrected")
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	automated, validationErr := schema.QueryOptionalBool(request, "automated")
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	isNil, validationErr := schema.QueryOptionalBool(request, "nil")
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	flightCategory, validationErr := schema.QueryEnum(request, "flight_category", false, "",
+		string(metar.FlightCategoryVFR),
+		string(metar.FlightCategoryMVFR),
+		string(metar.FlightCategoryIFR),
+		string(metar.FlightCategoryLIFR),
+	)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	includeSuperseded, validationErr := schema.QueryBool(request, "include_superseded", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	var cursor *metar.Cursor
+	if token := strings.TrimSpace(request.URL.Query().Get("cursor")); token != "" {
+		var err error
+		cursor, err = metar.ParseCursor(token)
+		if err != nil {
+			validationErrs = append(validationErrs, errMETARInvalidCursor(token))
+		}
+	}
+
+	count, validationErr := schema.QueryBool(request, "count", false, true)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
 	limit, validationErr := schema.QueryNumber(request, "limit", false, 10, 1, 100)
 	if validationErr != nil {
 		validationErrs = append(validationErrs, validationErr)
 	}
 
+	offset, validationErr := schema.QueryNumber(request, "offset", false, 0, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	decode, validationErr := schema.QueryBool(request, "decode", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if area != nil && cursor != nil {
+		validationErrs = append(validationErrs, errMETARInvalidArea("The 'cursor' parameter cannot be combined with an area."))
+	}
+	if area == nil && offset > 0 {
+		validationErrs = append(validationErrs, errMETARInvalidArea("The 'offset' parameter can only be used together with an area."))
+	}
+
+	format, validationErr := schema.NegotiateFormat(request, schema.GeoFormats...)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	} else if decode && !supportsDecoding(format) {
+		validationErrs = append(validationErrs, errMETARDecodeUnsupported(format))
+	}
+
 	if len(validationErrs) > 0 {
 		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
 		return
 	}
 
-	filter := &metar.Filter{}
+	filter := &metar.Filter{
+		Cursor:            cursor,
+		SkipCount:         !count,
+		Corrected:         corrected,
+		Automated:         automated,
+		Nil:               isNil,
+		IncludeSuperseded: includeSuperseded,
+	}
+	if reportType != "" {
+		typ := metar.ReportType(reportType)
+		filter.Type = &typ
+	}
+	if flightCategory != "" {
+		category := metar.FlightCategory(flightCategory)
+		filter.FlightCategory = &category
+	}
 	if stationID != "" {
 		filter.StationID = &stationID
 	}
@@ -74,19 +472,273 @@ func (service *Service) EndpointGetMETARs(writer http.ResponseWriter, request *h
 		filter.IssuedAfter = &after
 	}
 
-	metars, n, err := service.Storage.METARs().GetByFilter(request.Context(), filter, uint64(limit))
+	if area != nil {
+		service.writeMETARsInArea(writer, request, area, filter, uint64(offset), uint64(limit), decode, format)
+		return
+	}
+
+	// One more METAR than requested is fetched to determine whether there is a next page
+	metars, n, err := service.Storage.METARs().GetByFilter(request.Context(), filter, uint64(limit)+1)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	nextCursor := ""
+	if len(metars) > int(limit) {
+		metars = metars[:limit]
+		nextCursor = metar.CursorOf(metars[len(metars)-1]).String()
+	}
+	var totalCount *uint64
+	if count {
+		totalCount = &n
+	}
+
+	switch {
+	case format == schema.FormatGeoJSON:
+		located, err := service.locateMETARs(request.Context(), metars)
+		if err != nil {
+			service.writer.WriteInternalError(writer, err)
+			return
+		}
+		service.writer.WriteFormatted(writer, format, schema.BuildCursorPaginatedResponse(uint64(limit), totalCount, nextCursor, located))
+	case decode:
+		service.writer.WriteFormatted(writer, format, schema.BuildCursorPaginatedResponse(uint64(limit), totalCount, nextCursor, decodeMETARs(metars)))
+	default:
+		service.writer.WriteFormatted(writer, format, schema.BuildCursorPaginatedResponse(uint64(limit), totalCount, nextCursor, metars))
+	}
+
+	service.QuotaTracker.Accumulate(request.Context().Value(contextValueKey).(*apikey.Key))
+}
+
+// writeMETARsInArea writes a page of the latest METARs following the given filter of all stations inside the given area
+func (service *Service) writeMETARsInArea(writer http.ResponseWriter, request *http.Request, area *metarArea, filter *metar.Filter, offset, limit uint64, decode bool, format schema.Format) {
+	// One more station than allowed is fetched to determine whether the area is too large
+	stations, err := service.Storage.Stations().GetWithinBounds(request.Context(), area.bounds, uint64(metarAreaMaxStations)+1)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if len(stations) > metarAreaMaxStations {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, errMETARTooLargeArea(metarAreaMaxStations))
+		return
+	}
+
+	// The bounding box of a radius query contains stations outside the radius which have to be filtered out
+	distances := make(map[string]float64, len(stations))
+	byICAO := make(map[string]*station.Station, len(stations))
+	filter.StationIDs = make([]string, 0, len(stations))
+	for _, obj := range stations {
+		distance := obj.DistanceTo(area.latitude, area.longitude)
+		if area.radius > 0 && distance > area.radius {
+			continue
+		}
+		distances[obj.ICAO] = distance
+		byICAO[obj.ICAO] = obj
+		filter.StationIDs = append(filter.StationIDs, obj.ICAO)
+	}
+
+	results := []*areaMETAR{}
+	if len(filter.StationIDs) > 0 {
+		metars, err := service.Storage.METARs().GetLatestByFilter(request.Context(), filter)
+		if err != nil {
+			service.writer.WriteInternalError(writer, err)
+			return
+		}
+		for _, obj := range metars {
+			result := &areaMETAR{
+				METAR:    obj,
+				Station:  byICAO[obj.StationID],
+				Distance: distances[obj.StationID],
+			}
+			results = append(results, result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Distance == results[j].Distance {
+			return results[i].StationID < results[j].StationID
+		}
+		return results[i].Distance < results[j].Distance
+	})
+	n := uint64(len(results))
+	if offset >= n {
+		results = results[:0]
+	} else {
+		results = results[offset:]
+	}
+	if uint64(len(results)) > limit {
+		results = results[:limit]
+	}
+	if decode {
+		for _, result := range results {
+			result.Decoded, _ = result.METAR.Decode()
+		}
+	}
+
+	service.writer.WriteFormatted(writer, format, schema.BuildPaginatedResponse(offset, limit, n, results))
+
+	service.QuotaTracker.Accumulate(request.Context().Value(contextValueKey).(*apikey.Key))
+}
+
+// parseStationIDs parses a comma-separated list of station IDs, keeping their order and skipping duplicates
+func parseStationIDs(raw string) ([]string, []*schema.Error) {
+	var validationErrs []*schema.Error
+	stationIDs := []string{}
+	if raw == "" {
+		return stationIDs, nil
+	}
+	seen := make(map[string]struct{})
+	for _, stationID := range strings.Split(raw, ",") {
+		stationID = strings.ToUpper(strings.TrimSpace(stationID))
+		if _, ok := seen[stationID]; ok {
+			continue
+		}
+		if !station.IsValidICAO(stationID) {
+			validationErrs = append(validationErrs, errMETARInvalidStationID(stationID))
+			continue
+		}
+		seen[stationID] = struct{}{}
+		stationIDs = append(stationIDs, stationID)
+	}
+	return stationIDs, validationErrs
+}
+
+type latestMETAR struct {
+	StationID string `json:"station_id"`
+
+	// METAR is nil if there is no METAR of the station
+	METAR   *metar.METAR  `json:"metar"`
+	Decoded *metar.Report `json:"decoded,omitempty"`
+
+	// Station is only fetched for GeoJSON responses
+	Station *station.Station `json:"-"`
+}
+
+type endpointGetLatestMETARsResponseBody struct {
+	Data []*latestMETAR `json:"data"`
+
+	// Missing contains the IDs of all requested stations without any METAR
+	Missing []string `json:"missing"`
+}
+
+// Entries returns the found METARs (see schema.Collection); stations without any METAR are left out
+func (body endpointGetLatestMETARsResponseBody) Entries() []any {
+	entries := make([]any, 0, len(body.Data))
+	for _, result := range body.Data {
+		if result.METAR != nil {
+			entries = append(entries, result)
+		}
+	}
+	return entries
+}
+
+// CSVHeader returns the columns of the CSV representation of a METAR
+func (result *latestMETAR) CSVHeader() []string {
+	return new(metar.METAR).CSVHeader()
+}
+
+// CSVRecord returns the CSV representation of the METAR
+func (result *latestMETAR) CSVRecord() []string {
+	return result.METAR.CSVRecord()
+}
+
+// MarshalXML writes the XML representation of the METAR
+func (result *latestMETAR) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	return result.METAR.MarshalXML(encoder, start)
+}
+
+// EndpointGetLatestMETARs handles the 'GET /v1/metars/latest?stations={comma-separated strings}&decode={bool?:false}&format={json|ndjson|csv|xml|geojson?:json}' endpoint.
+// All formats except JSON only contain the found METARs. Decoding is not supported by the CSV and XML formats.
+func (service *Service) EndpointGetLatestMETARs(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	rawStations, validationErr := schema.QueryString(request, "stations", true, "")
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	stationIDs, stationErrs := parseStationIDs(rawStations)
+	validationErrs = append(validationErrs, stationErrs...)
+	if len(stationIDs) > metarLatestMaxStations {
+		validationErrs = append(validationErrs, errMETARTooManyStations(len(stationIDs), metarLatestMaxStations))
+	}
+
+	decode, validationErr := schema.QueryBool(request, "decode", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	format, validationErr := schema.NegotiateFormat(request, schema.GeoFormats...)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	} else if decode && !supportsDecoding(format) {
+		validationErrs = append(validationErrs, errMETARDecodeUnsupported(format))
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	latest, err := service.Storage.METARs().GetLatestByStations(request.Context(), stationIDs)
 	if err != nil {
 		service.writer.WriteInternalError(writer, err)
 		return
 	}
 
-	service.writer.WriteJSON(writer, schema.BuildPaginatedResponse(0, uint64(limit), n, metars))
+	body := endpointGetLatestMETARsResponseBody{
+		Data:    make([]*latestMETAR, 0, len(stationIDs)),
+		Missing: []string{},
+	}
+	var stations map[string]*station.Station
+	if format == schema.FormatGeoJSON {
+		stations, err = service.Storage.Stations().GetByICAOs(request.Context(), stationIDs)
+		if err != nil {
+			service.writer.WriteInternalError(writer, err)
+			return
+		}
+	}
+	for _, stationID := range stationIDs {
+		result := &latestMETAR{
+			StationID: stationID,
+			METAR:     latest[stationID],
+			Station:   stations[stationID],
+		}
+		if result.METAR == nil {
+			body.Missing = append(body.Missing, stationID)
+		} else if decode {
+			result.Decoded, _ = result.METAR.Decode()
+		}
+		body.Data = append(body.Data, result)
+	}
+
+	service.writer.WriteFormatted(writer, format, body)
 
 	service.QuotaTracker.Accumulate(request.Context().Value(contextValueKey).(*apikey.Key))
 }
 
-// EndpointGetMETAR handles the 'GET /v1/metars/{id}' endpoint
+// EndpointGetMETAR handles the 'GET /v1/metars/{id}?decode={bool?:false}&format={json|ndjson|csv|xml?:json}' endpoint.
+// Decoding is not supported by the CSV and XML formats.
 func (service *Service) EndpointGetMETAR(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	decode, validationErr := schema.QueryBool(request, "decode", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	format, validationErr := schema.NegotiateFormat(request)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	} else if decode && !supportsDecoding(format) {
+		validationErrs = append(validationErrs, errMETARDecodeUnsupported(format))
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
 	id := chi.URLParam(request, "id")
 	uid, err := uuid.Parse(id)
 	if err != nil {
@@ -100,22 +752,108 @@ func (service *Service) EndpointGetMETAR(writer http.ResponseWriter, request *ht
 		return
 	}
 
-	service.writer.WriteJSON(writer, obj)
+	if decode && obj != nil {
+		service.writer.WriteFormatted(writer, format, decodeMETARs([]*metar.METAR{obj})[0])
+	} else {
+		service.writer.WriteFormatted(writer, format, obj)
+	}
 
 	service.QuotaTracker.Accumulate(request.Context().Value(contextValueKey).(*apikey.Key))
 }
 
 type endpointFeedMETARsRequestPayload struct {
-	Data []string `json:"data" required:"true"`
+	Data          []string `json:"data" required:"true"`
+	ReferenceTime *int64   `json:"reference_time" min:"0"`
+	Atomic        *bool    `json:"atomic"`
 }
 
 type endpointFeedMETARsResponseBody struct {
 	METARs     []*metar.METAR `json:"metars"`
 	Duplicates []uint         `json:"duplicates"`
+
+	// Results contains the outcome of every fed METAR (in the order they were fed)
+	Results []*feedResult `json:"results"`
+}
+
+// feedResult represents the outcome of a single fed METAR
+type feedResult struct {
+	Index  uint               `json:"index"`
+	Status metar.CreateStatus `json:"status"`
+	ID     *uuid.UUID         `json:"metar_id,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// add adds the results of a batch of fed METARs starting at the given index to the response body
+func (body *endpointFeedMETARsResponseBody) add(results []*metar.CreateResult, offset int) {
+	for i, result := range results {
+		index := uint(offset + i)
+		entry := &feedResult{
+			Index:  index,
+			Status: result.Status,
+		}
+		switch result.Status {
+		case metar.CreateStatusCreated:
+			body.METARs = append(body.METARs, result.METAR)
+			entry.ID = &result.METAR.ID
+		case metar.CreateStatusDuplicate:
+			body.Duplicates = append(body.Duplicates, index)
+		case metar.CreateStatusInvalid:
+			entry.Error = result.Error.Error()
+		}
+		body.Results = append(body.Results, entry)
+	}
+}
+
+// feedTextResponseBody represents the response to a plain text METAR feed request (see feedMETARsText)
+type feedTextResponseBody struct {
+	Created    int `json:"created"`
+	Duplicates int `json:"duplicates"`
+	Invalid    int `json:"invalid"`
+
+	// Failures contains the outcome of every METAR that could not be stored (in the order they were fed)
+	Failures []*feedResult `json:"failures"`
+
+	// Aborted is set if the body could not be processed completely
+	Aborted *feedTextAbort `json:"aborted,omitempty"`
+}
+
+// feedTextAbort describes why a plain text METAR feed request was aborted.
+// All METARs before Index were processed (and are reflected by the counts and failures); no METAR starting at Index
+// was stored.
+type feedTextAbort struct {
+	Index uint          `json:"index"`
+	Error *schema.Error `json:"error"`
+}
+
+// add counts the results of a batch of fed METARs starting at the given index
+func (body *feedTextResponseBody) add(results []*metar.CreateResult, offset int) {
+	for i, result := range results {
+		switch result.Status {
+		case metar.CreateStatusCreated:
+			body.Created++
+		case metar.CreateStatusDuplicate:
+			body.Duplicates++
+		case metar.CreateStatusInvalid:
+			body.Invalid++
+			body.Failures = append(body.Failures, &feedResult{
+				Index:  uint(offset + i),
+				Status: result.Status,
+				Error:  result.Error.Error(),
+			})
+		}
+	}
 }
 
-// EndpointFeedMETARs handles the 'POST /v1/metars' endpoint
+// EndpointFeedMETARs handles the 'POST /v1/metars' endpoint.
+// Malformed METARs are reported in the results while the valid ones are stored; if the 'atomic' flag is set, a single
+// malformed METAR rejects the whole batch instead.
+// Besides JSON bodies, plain text bodies (see feedMETARsText) are accepted.
 func (service *Service) EndpointFeedMETARs(writer http.ResponseWriter, request *http.Request) {
+	if mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type")); mediaType == "text/plain" {
+		service.feedMETARsText(writer, request)
+		return
+	}
+
 	body, validationErrs, err := schema.UnmarshalBody[endpointFeedMETARsRequestPayload](request)
 	if len(validationErrs) > 0 {
 		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
@@ -131,7 +869,13 @@ func (service *Service) EndpointFeedMETARs(writer http.ResponseWriter, request *
 		return
 	}
 
-	metars, duplicates, err := service.Storage.METARs().Create(request.Context(), body.Data)
+	// Backfilling feeders may specify the time their METARs' issuing times should be resolved relative to
+	var opts []metar.ParseOption
+	if body.ReferenceTime != nil {
+		opts = append(opts, metar.WithReferenceTime(time.Unix(*body.ReferenceTime, 0)))
+	}
+
+	results, err := service.storeMETARs(request.Context(), request.Context().Value(contextValueKey).(*apikey.Key), body.Data, body.Atomic != nil && *body.Atomic, opts)
 	if err != nil {
 		var formatErr *metar.FormatError
 		if errors.As(err, &formatErr) {
@@ -142,8 +886,198 @@ func (service *Service) EndpointFeedMETARs(writer http.ResponseWriter, request *
 		return
 	}
 
-	service.writer.WriteJSON(writer, endpointFeedMETARsResponseBody{
-		METARs:     metars,
-		Duplicates: duplicates,
-	})
+	response := &endpointFeedMETARsResponseBody{
+		METARs:     []*metar.METAR{},
+		Duplicates: []uint{},
+		Results:    []*feedResult{},
+	}
+	response.add(results, 0)
+	service.writer.WriteJSON(writer, response)
+}
+
+// feedMETARsText handles 'POST /v1/metars?reference_time={int?}&atomic={bool?:false}' requests with a plain text body.
+// The body may contain one METAR per line or WMO bulletins (see metar.ReportScanner). It is streamed and stored in
+// batches of metarFeedBatchMaxSize METARs, so it may be arbitrarily large. If processing the body fails midway, the
+// already stored METARs are not rolled back; the response carries the error status code together with the results
+// gathered so far and the index the body was aborted at.
+// In atomic mode, all METARs are stored in a single transaction instead; as the body has to be buffered completely, it
+// may be at most metarFeedTextMaxBodySize bytes large and contain at most metarFeedTextMaxReports METARs.
+// Only the amounts of created, duplicate and invalid METARs and the failures are returned; their indexes refer to the
+// position of the METARs inside the whole body.
+func (service *Service) feedMETARsText(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	referenceTime, validationErr := schema.QueryNumber(request, "reference_time", false, 0, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	atomic, validationErr := schema.QueryBool(request, "atomic", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	// Backfilling feeders may specify the time their METARs' issuing times should be resolved relative to
+	var opts []metar.ParseOption
+	if referenceTime > 0 {
+		opts = append(opts, metar.WithReferenceTime(time.Unix(referenceTime, 0)))
+	}
+
+	if atomic {
+		service.feedMETARsTextAtomic(writer, request, opts)
+		return
+	}
+
+	key := request.Context().Value(contextValueKey).(*apikey.Key)
+	response := &feedTextResponseBody{
+		Failures: []*feedResult{},
+	}
+	offset := 0
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := service.storeMETARs(request.Context(), key, batch, false, opts)
+		if err != nil {
+			return err
+		}
+		response.add(results, offset)
+		offset += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	scanner := metar.NewReportScanner(request.Body)
+	for scanner.Scan() {
+		batch = append(batch, scanner.Report())
+		if len(batch) == metarFeedBatchMaxSize {
+			if err := flush(); err != nil {
+				service.writeAbortedFeed(writer, response, offset, err)
+				return
+			}
+		}
+	}
+
+	// The METARs read before a scanning error are stored nevertheless
+	if err := flush(); err != nil {
+		service.writeAbortedFeed(writer, response, offset, err)
+		return
+	}
+	if err := scanner.Err(); err != nil {
+		service.writeAbortedFeed(writer, response, offset, err)
+		return
+	}
+	service.writer.WriteJSON(writer, response)
+}
+
+// writeAbortedFeed writes the results of a plain text METAR feed request that was aborted at the given index
+func (service *Service) writeAbortedFeed(writer http.ResponseWriter, response *feedTextResponseBody, index int, err error) {
+	code, schemaErr := http.StatusInternalServerError, schema.ErrInternal
+	if errors.Is(err, bufio.ErrTooLong) {
+		code, schemaErr = http.StatusBadRequest, errMETARLineTooLong
+	} else {
+		service.writer.InternalErrorHook(err)
+	}
+	response.Aborted = &feedTextAbort{
+		Index: uint(index),
+		Error: schemaErr,
+	}
+	service.writer.WriteJSONWithCode(writer, code, response)
+}
+
+// feedMETARsTextAtomic reads a whole plain text METAR feed request body and stores its METARs in a single transaction
+func (service *Service) feedMETARsTextAtomic(writer http.ResponseWriter, request *http.Request, opts []metar.ParseOption) {
+	var raw []string
+	scanner := metar.NewReportScanner(&limitedReader{reader: request.Body, remaining: metarFeedTextMaxBodySize})
+	for scanner.Scan() {
+		if len(raw) == metarFeedTextMaxReports {
+			service.writer.WriteErrors(writer, http.StatusRequestEntityTooLarge, errMETARTooManyReports(metarFeedTextMaxReports))
+			return
+		}
+		raw = append(raw, scanner.Report())
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			service.writer.WriteErrors(writer, http.StatusBadRequest, errMETARLineTooLong)
+		} else if errors.Is(err, errBodyTooLarge) {
+			service.writer.WriteErrors(writer, http.StatusRequestEntityTooLarge, errMETARTooLargeBody(metarFeedTextMaxBodySize))
+		} else {
+			service.writer.WriteInternalError(writer, err)
+		}
+		return
+	}
+
+	response := &feedTextResponseBody{
+		Failures: []*feedResult{},
+	}
+	if len(raw) == 0 {
+		service.writer.WriteJSON(writer, response)
+		return
+	}
+
+	results, err := service.storeMETARs(request.Context(), request.Context().Value(contextValueKey).(*apikey.Key), raw, true, opts)
+	if err != nil {
+		var formatErr *metar.FormatError
+		if errors.As(err, &formatErr) {
+			service.writer.WriteErrors(writer, http.StatusBadRequest, errMETARInvalidFormat(err.Error(), formatErr.Index))
+		} else {
+			service.writer.WriteInternalError(writer, err)
+		}
+		return
+	}
+	response.add(results, 0)
+	service.writer.WriteJSON(writer, response)
+}
+
+// errBodyTooLarge is returned by a limitedReader once its limit was exceeded
+var errBodyTooLarge = errors.New("the request body is too large")
+
+// limitedReader reads from a reader until the given amount of bytes was exceeded
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (reader *limitedReader) Read(p []byte) (int, error) {
+	if reader.remaining < 0 {
+		return 0, errBodyTooLarge
+	}
+	// One more byte than allowed is read to detect bodies exceeding the limit
+	if int64(len(p)) > reader.remaining+1 {
+		p = p[:reader.remaining+1]
+	}
+	n, err := reader.reader.Read(p)
+	reader.remaining -= int64(n)
+	if reader.remaining < 0 {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
+
+// storeMETARs stores a batch of raw METARs fed by the given API key and notifies the subscribers of the METAR stream about the created ones.
+// If atomic is set, a single malformed METAR rejects the whole batch with a *metar.FormatError.
+func (service *Service) storeMETARs(ctx context.Context, key *apikey.Key, raw []string, atomic bool, opts []metar.ParseOption) ([]*metar.CreateResult, error) {
+	var results []*metar.CreateResult
+	var err error
+	if atomic {
+		results, err = service.Storage.METARs().Create(ctx, raw, &key.ID, opts...)
+	} else {
+		results, err = service.Storage.METARs().CreatePartially(ctx, raw, &key.ID, opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Status == metar.CreateStatusCreated {
+			service.METAREvents.Publish(result.METAR)
+		}
+	}
+	return results, nil
 }
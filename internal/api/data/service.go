@@ -6,12 +6,16 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"github.com/skybi/pluteo/internal/alert"
 	"github.com/skybi/pluteo/internal/api/schema"
 	"github.com/skybi/pluteo/internal/apikey"
 	"github.com/skybi/pluteo/internal/apikey/quota"
 	"github.com/skybi/pluteo/internal/config"
+	"github.com/skybi/pluteo/internal/event"
+	"github.com/skybi/pluteo/internal/export"
 	"github.com/skybi/pluteo/internal/function"
 	"github.com/skybi/pluteo/internal/hashmap"
+	"github.com/skybi/pluteo/internal/metar"
 	"github.com/skybi/pluteo/internal/storage"
 	"net/http"
 	"time"
@@ -25,7 +29,17 @@ type Service struct {
 	Storage      storage.Driver
 	QuotaTracker *quota.Tracker
 
+	// METAREvents receives every METAR that was newly fed through the data API
+	METAREvents *event.Broker[*metar.METAR]
+
+	// AlertEvents receives every alert event emitted by the alert rule evaluator
+	AlertEvents *event.Broker[*alert.Event]
+
+	// Exports processes the bulk METAR export jobs
+	Exports *export.Manager
+
 	requestCounter *hashmap.ExpiringMap[uuid.UUID, uint]
+	websockets     *websocketRegistry
 
 	writer *schema.Writer
 }
@@ -43,6 +57,9 @@ func (service *Service) Startup() error {
 	service.requestCounter = hashmap.NewExpiring[uuid.UUID, uint](time.Minute)
 	service.requestCounter.ScheduleCleanupTask(time.Minute)
 
+	// Initialize the WebSocket connection registry
+	service.websockets = newWebsocketRegistry()
+
 	// Create the HTTP router
 	router := chi.NewRouter()
 	router.Use(middleware.RedirectSlashes)
@@ -57,6 +74,7 @@ func (service *Service) Startup() error {
 			http.MethodDelete,
 		},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"X-Pagination-Offset", "X-Pagination-Limit", "X-Pagination-Included-Count", "X-Pagination-Total-Count", "X-Pagination-Next-Cursor"},
 		AllowCredentials: true,
 	}))
 	router.NotFound(func(writer http.ResponseWriter, _ *http.Request) {
@@ -87,6 +105,11 @@ func (service *Service) Shutdown() {
 		service.server.Close()
 		service.server = nil
 	}
+
+	// Hijacked WebSocket connections are not closed by the HTTP server
+	if service.websockets != nil {
+		service.websockets.closeAll()
+	}
 }
 
 func (service *Service) registerEndpoints(router chi.Router) {
@@ -105,6 +128,20 @@ func (service *Service) registerEndpoints(router chi.Router) {
 		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
 		service.MiddlewareVerifyKeyQuota,
 	))
+	router.Get("/v1/metars/stream", function.Nest[http.HandlerFunc](
+		service.EndpointStreamMETARs,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
+		service.MiddlewareVerifyKeyQuota,
+	))
+	router.Get("/v1/metars/latest", function.Nest[http.HandlerFunc](
+		service.EndpointGetLatestMETARs,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
+		service.MiddlewareVerifyKeyQuota,
+	))
 	router.Get("/v1/metars/{id}", function.Nest[http.HandlerFunc](
 		service.EndpointGetMETAR,
 		service.MiddlewareVerifyKey,
@@ -112,10 +149,104 @@ func (service *Service) registerEndpoints(router chi.Router) {
 		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
 		service.MiddlewareVerifyKeyQuota,
 	))
+	router.Get("/v1/alerts/stream", function.Nest[http.HandlerFunc](
+		service.EndpointStreamAlerts,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
+		service.MiddlewareVerifyKeyQuota,
+	))
 	router.Post("/v1/metars", function.Nest[http.HandlerFunc](
 		service.EndpointFeedMETARs,
 		service.MiddlewareVerifyKey,
 		service.MiddlewareVerifyKeyRateLimit,
 		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityFeedMETARs),
 	))
+
+	// Register the bulk export endpoints; exports are charged per exported METAR instead of per request
+	router.Get("/v1/exports", function.Nest[http.HandlerFunc](
+		service.EndpointGetExports,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
+	))
+	router.Post("/v1/exports", function.Nest[http.HandlerFunc](
+		service.EndpointCreateExport,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
+		service.MiddlewareVerifyKeyQuota,
+	))
+	router.Get("/v1/exports/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointGetExport,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
+	))
+	router.Get("/v1/exports/{id}/download", function.Nest[http.HandlerFunc](
+		service.EndpointDownloadExport,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
+	))
+	router.Delete("/v1/exports/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointDeleteExport,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
+	))
+
+	// Register the WebSocket endpoint
+	router.Get("/v1/ws", function.Nest[http.HandlerFunc](
+		service.EndpointWebsocket,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadMETARs),
+		service.MiddlewareVerifyKeyQuota,
+	))
+
+	// Register the TAF controller endpoints
+	router.Get("/v1/tafs", function.Nest[http.HandlerFunc](
+		service.EndpointGetTAFs,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadTAFs),
+		service.MiddlewareVerifyKeyQuota,
+	))
+	router.Get("/v1/tafs/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointGetTAF,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadTAFs),
+		service.MiddlewareVerifyKeyQuota,
+	))
+	router.Get("/v1/tafs/{id}/at", function.Nest[http.HandlerFunc](
+		service.EndpointGetTAFAt,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadTAFs),
+		service.MiddlewareVerifyKeyQuota,
+	))
+	router.Post("/v1/tafs", function.Nest[http.HandlerFunc](
+		service.EndpointFeedTAFs,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityFeedTAFs),
+	))
+
+	// Register the station controller endpoints
+	router.Get("/v1/stations", function.Nest[http.HandlerFunc](
+		service.EndpointGetStations,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadStations),
+		service.MiddlewareVerifyKeyQuota,
+	))
+	router.Get("/v1/stations/{icao}", function.Nest[http.HandlerFunc](
+		service.EndpointGetStation,
+		service.MiddlewareVerifyKey,
+		service.MiddlewareVerifyKeyRateLimit,
+		service.MiddlewareVerifyKeyCapabilities(apikey.CapabilityReadStations),
+		service.MiddlewareVerifyKeyQuota,
+	))
 }
@@ -0,0 +1,102 @@
+package data
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/station"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// EndpointGetStations handles the 'GET /v1/stations?country={string?}&type={string?}&search={string?}&offset={number?:0}&limit={number?:10}&format={json|ndjson|csv|xml|geojson?:json}' endpoint
+func (service *Service) EndpointGetStations(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	country := strings.ToUpper(strings.TrimSpace(request.URL.Query().Get("country")))
+	stationType := strings.ToLower(strings.TrimSpace(request.URL.Query().Get("type")))
+	search := strings.TrimSpace(request.URL.Query().Get("search"))
+
+	offset, validationErr := schema.QueryNumber(request, "offset", false, 0, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	limit, validationErr := schema.QueryNumber(request, "limit", false, 10, 1, 100)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	format, validationErr := schema.NegotiateFormat(request, schema.GeoFormats...)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	filter := &station.Filter{}
+	if country != "" {
+		filter.Country = &country
+	}
+	if stationType != "" {
+		filter.Type = &stationType
+	}
+	if search != "" {
+		filter.Search = &search
+	}
+
+	stations, n, err := service.Storage.Stations().GetByFilter(request.Context(), filter, uint64(offset), uint64(limit))
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	if format == schema.FormatGeoJSON {
+		features := make([]*stationFeature, 0, len(stations))
+		for _, obj := range stations {
+			features = append(features, &stationFeature{Station: obj})
+		}
+		service.writer.WriteFormatted(writer, format, schema.BuildPaginatedResponse(uint64(offset), uint64(limit), n, features))
+	} else {
+		service.writer.WriteFormatted(writer, format, schema.BuildPaginatedResponse(uint64(offset), uint64(limit), n, stations))
+	}
+
+	service.QuotaTracker.Accumulate(request.Context().Value(contextValueKey).(*apikey.Key))
+}
+
+// EndpointGetStation handles the 'GET /v1/stations/{icao}?format={json|ndjson|csv|xml|geojson?:json}' endpoint
+func (service *Service) EndpointGetStation(writer http.ResponseWriter, request *http.Request) {
+	format, validationErr := schema.NegotiateFormat(request, schema.GeoFormats...)
+	if validationErr != nil {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErr)
+		return
+	}
+
+	icao := strings.ToUpper(chi.URLParam(request, "icao"))
+	if !station.IsValidICAO(icao) {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	obj, err := service.Storage.Stations().GetByICAO(request.Context(), icao)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if obj == nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	if format == schema.FormatGeoJSON {
+		service.writer.WriteFormatted(writer, format, &stationFeature{Station: obj})
+	} else {
+		service.writer.WriteFormatted(writer, format, obj)
+	}
+
+	service.QuotaTracker.Accumulate(request.Context().Value(contextValueKey).(*apikey.Key))
+}
@@ -0,0 +1,264 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/taf"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var tafFeedBatchMaxSize = 500
+
+var (
+	errTAFTooLargeBatch = func(given, max int) *schema.Error {
+		return &schema.Error{
+			Type:    "data.tafs.tooLargeBatch",
+			Message: fmt.Sprintf("A single TAF request may only feed %d TAFs (%d were given).", max, given),
+			Details: map[string]any{
+				"given": given,
+				"max":   max,
+			},
+		}
+	}
+	errTAFNoForecast = &schema.Error{
+		Type:    "data.tafs.noForecast",
+		Message: "The requested TAF does not contain a forecast as it is a NIL or cancelled TAF.",
+	}
+	errTAFNotValidAt = func(timestamp, validFrom, validUntil int64) *schema.Error {
+		return &schema.Error{
+			Type:    "data.tafs.notValidAt",
+			Message: fmt.Sprintf("The requested time (%d) lies outside the validity period of the TAF (%d - %d).", timestamp, validFrom, validUntil),
+			Details: map[string]any{
+				"time":        timestamp,
+				"valid_from":  validFrom,
+				"valid_until": validUntil,
+			},
+		}
+	}
+	errTAFInvalidFormat = func(raw string, i int) *schema.Error {
+		return &schema.Error{
+			Type:    "data.tafs.invalidFormat",
+			Message: raw,
+			Details: map[string]any{
+				"index": i,
+			},
+		}
+	}
+)
+
+// EndpointGetTAFs handles the 'GET /v1/tafs?station_id={string?}&before={timestamp?}&after={timestamp?}&valid_at={timestamp?}&limit={number?:10}&format={json|ndjson|csv|xml?:json}' endpoint
+func (service *Service) EndpointGetTAFs(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	stationID := strings.ToUpper(strings.TrimSpace(request.URL.Query().Get("station_id")))
+
+	before, validationErr := schema.QueryNumber(request, "before", false, -1, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	after, validationErr := schema.QueryNumber(request, "after", false, -1, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	validAt, validationErr := schema.QueryNumber(request, "valid_at", false, -1, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	limit, validationErr := schema.QueryNumber(request, "limit", false, 10, 1, 100)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	format, validationErr := schema.NegotiateFormat(request)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	filter := &taf.Filter{}
+	if stationID != "" {
+		filter.StationID = &stationID
+	}
+	if before > 0 {
+		filter.IssuedBefore = &before
+	}
+	if after > 0 {
+		filter.IssuedAfter = &after
+	}
+	if validAt >= 0 {
+		filter.ValidAt = &validAt
+	}
+
+	tafs, n, err := service.Storage.TAFs().GetByFilter(request.Context(), filter, uint64(limit))
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	service.writer.WriteFormatted(writer, format, schema.BuildPaginatedResponse(0, uint64(limit), n, tafs))
+
+	service.QuotaTracker.Accumulate(request.Context().Value(contextValueKey).(*apikey.Key))
+}
+
+// EndpointGetTAF handles the 'GET /v1/tafs/{id}?format={json|ndjson|csv|xml?:json}' endpoint
+func (service *Service) EndpointGetTAF(writer http.ResponseWriter, request *http.Request) {
+	format, validationErr := schema.NegotiateFormat(request)
+	if validationErr != nil {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErr)
+		return
+	}
+
+	id := chi.URLParam(request, "id")
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	obj, err := service.Storage.TAFs().GetByID(request.Context(), uid)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if obj == nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	service.writer.WriteFormatted(writer, format, obj)
+
+	service.QuotaTracker.Accumulate(request.Context().Value(contextValueKey).(*apikey.Key))
+}
+
+type endpointGetTAFAtResponseBody struct {
+	TAF           *taf.TAF           `json:"taf"`
+	Time          int64              `json:"time"`
+	Conditions    *taf.Conditions    `json:"conditions"`
+	ActiveChanges []*taf.ChangeGroup `json:"active_changes"`
+}
+
+// EndpointGetTAFAt handles the 'GET /v1/tafs/{id}/at?time={timestamp?:now}&include_temporary={bool?:false}' endpoint
+func (service *Service) EndpointGetTAFAt(writer http.ResponseWriter, request *http.Request) {
+	id := chi.URLParam(request, "id")
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	var validationErrs []*schema.Error
+
+	timestamp, validationErr := schema.QueryNumber(request, "time", false, time.Now().Unix(), 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	includeTemporary, validationErr := schema.QueryBool(request, "include_temporary", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	obj, err := service.Storage.TAFs().GetByID(request.Context(), uid)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if obj == nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	if obj.Nil || obj.Cancelled {
+		service.writer.WriteErrors(writer, http.StatusUnprocessableEntity, errTAFNoForecast)
+		return
+	}
+	if !obj.IsValidAt(timestamp) {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, errTAFNotValidAt(timestamp, obj.ValidFrom, obj.ValidUntil))
+		return
+	}
+
+	forecast, err := obj.Decode()
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	conditions, active, _ := forecast.ConditionsAt(timestamp, includeTemporary)
+
+	service.writer.WriteJSON(writer, endpointGetTAFAtResponseBody{
+		TAF:           obj,
+		Time:          timestamp,
+		Conditions:    conditions,
+		ActiveChanges: active,
+	})
+
+	service.QuotaTracker.Accumulate(request.Context().Value(contextValueKey).(*apikey.Key))
+}
+
+type endpointFeedTAFsRequestPayload struct {
+	Data          []string `json:"data" required:"true"`
+	ReferenceTime *int64   `json:"reference_time" min:"0"`
+}
+
+type endpointFeedTAFsResponseBody struct {
+	TAFs       []*taf.TAF `json:"tafs"`
+	Duplicates []uint     `json:"duplicates"`
+}
+
+// EndpointFeedTAFs handles the 'POST /v1/tafs' endpoint
+func (service *Service) EndpointFeedTAFs(writer http.ResponseWriter, request *http.Request) {
+	body, validationErrs, err := schema.UnmarshalBody[endpointFeedTAFsRequestPayload](request)
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	if len(body.Data) > tafFeedBatchMaxSize {
+		service.writer.WriteErrors(writer, http.StatusRequestEntityTooLarge, errTAFTooLargeBatch(len(body.Data), tafFeedBatchMaxSize))
+		return
+	}
+
+	// Backfilling feeders may specify the time their TAFs' issuing times should be resolved relative to
+	var opts []taf.ParseOption
+	if body.ReferenceTime != nil {
+		opts = append(opts, taf.WithReferenceTime(time.Unix(*body.ReferenceTime, 0)))
+	}
+
+	tafs, duplicates, err := service.Storage.TAFs().Create(request.Context(), body.Data, opts...)
+	if err != nil {
+		var formatErr *taf.FormatError
+		if errors.As(err, &formatErr) {
+			service.writer.WriteErrors(writer, http.StatusBadRequest, errTAFInvalidFormat(err.Error(), formatErr.Index))
+		} else {
+			service.writer.WriteInternalError(writer, err)
+		}
+		return
+	}
+
+	service.writer.WriteJSON(writer, endpointFeedTAFsResponseBody{
+		TAFs:       tafs,
+		Duplicates: duplicates,
+	})
+}
@@ -0,0 +1,519 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/station"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// websocketMaxSubscriptions defines how many subscriptions a single WebSocket connection may hold
+	websocketMaxSubscriptions = 50
+
+	// websocketMaxMessageSize defines the maximum size (in bytes) of a single message sent by a client
+	websocketMaxMessageSize int64 = 16 * 1024
+
+	// websocketWriteTimeout defines how long writing a single message may take before the connection is closed
+	websocketWriteTimeout = 10 * time.Second
+
+	// websocketPongTimeout defines how long a client may take to answer a ping before the connection is closed
+	websocketPongTimeout = 60 * time.Second
+
+	// websocketPingInterval defines the interval in which pings are sent to the client
+	websocketPingInterval = 50 * time.Second
+)
+
+const (
+	websocketMessageSubscribe    = "subscribe"
+	websocketMessageUnsubscribe  = "unsubscribe"
+	websocketMessageSubscribed   = "subscribed"
+	websocketMessageUnsubscribed = "unsubscribed"
+	websocketMessageMETAR        = "metar"
+	websocketMessageError        = "error"
+)
+
+var (
+	errWebsocketConnectionLimitReached = func(max int) *schema.Error {
+		return &schema.Error{
+			Type:    "data.websocket.connectionLimitReached",
+			Message: fmt.Sprintf("The specified API key may only hold %d concurrent WebSocket connections.", max),
+			Details: map[string]any{
+				"max": max,
+			},
+		}
+	}
+	errWebsocketUpgradeFailed = func(reason string) *schema.Error {
+		return &schema.Error{
+			Type:    "data.websocket.upgradeFailed",
+			Message: reason,
+		}
+	}
+	errWebsocketInvalidMessage = func(reason string) *schema.Error {
+		return &schema.Error{
+			Type:    "data.websocket.invalidMessage",
+			Message: reason,
+		}
+	}
+	errWebsocketTooManySubscriptions = func(max int) *schema.Error {
+		return &schema.Error{
+			Type:    "data.websocket.tooManySubscriptions",
+			Message: fmt.Sprintf("A single WebSocket connection may only hold %d subscriptions.", max),
+			Details: map[string]any{
+				"max": max,
+			},
+		}
+	}
+	errWebsocketUnknownSubscription = func(id uint64) *schema.Error {
+		return &schema.Error{
+			Type:    "data.websocket.unknownSubscription",
+			Message: fmt.Sprintf("There is no subscription with the ID %d.", id),
+			Details: map[string]any{
+				"subscription_id": id,
+			},
+		}
+	}
+)
+
+// websocketArea represents the area of a subscription as sent by the client.
+// The area is either given as a point and a radius ('lat', 'lon' and 'radius_km') or as a bounding box in the format
+// '[min_lon, min_lat, max_lon, max_lat]'.
+type websocketArea struct {
+	Latitude  *float64  `json:"lat,omitempty"`
+	Longitude *float64  `json:"lon,omitempty"`
+	Radius    *float64  `json:"radius_km,omitempty"`
+	BBox      []float64 `json:"bbox,omitempty"`
+}
+
+// websocketClientMessage represents a single message sent by a client
+type websocketClientMessage struct {
+	// Type is either 'subscribe' or 'unsubscribe'
+	Type string `json:"type"`
+
+	// RequestID is an optional client-chosen ID that is echoed in the answer to the message
+	RequestID string `json:"request_id"`
+
+	// Stations and Area define the METARs a new subscription receives; at least one of them has to be given
+	Stations []string       `json:"stations"`
+	Area     *websocketArea `json:"area"`
+
+	// SubscriptionID identifies the subscription to cancel
+	SubscriptionID uint64 `json:"subscription_id"`
+}
+
+// websocketSubscription represents a single subscription of a WebSocket connection
+type websocketSubscription struct {
+	ID       uint64         `json:"id"`
+	Stations []string       `json:"stations"`
+	Area     *websocketArea `json:"area,omitempty"`
+
+	// stations contains the IDs of the given stations and of the stations inside the area at the time of subscribing
+	stations map[string]struct{}
+}
+
+// websocketServerMessage represents a single message sent to a client
+type websocketServerMessage struct {
+	Type      string `json:"type"`
+	RequestID string `json:"request_id,omitempty"`
+
+	Subscription *websocketSubscription `json:"subscription,omitempty"`
+
+	// Subscriptions contains the IDs of all subscriptions an event was delivered for
+	Subscriptions []uint64 `json:"subscriptions,omitempty"`
+
+	Data   any             `json:"data,omitempty"`
+	Errors []*schema.Error `json:"errors,omitempty"`
+}
+
+// websocketRegistry keeps track of the open WebSocket connections
+type websocketRegistry struct {
+	mu          sync.Mutex
+	perKey      map[uuid.UUID]int
+	connections map[*websocket.Conn]struct{}
+}
+
+func newWebsocketRegistry() *websocketRegistry {
+	return &websocketRegistry{
+		perKey:      make(map[uuid.UUID]int),
+		connections: make(map[*websocket.Conn]struct{}),
+	}
+}
+
+// acquire reserves a connection slot for the given API key; it returns false if the key reached the limit
+func (registry *websocketRegistry) acquire(keyID uuid.UUID, max int) bool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if max >= 0 && registry.perKey[keyID] >= max {
+		return false
+	}
+	registry.perKey[keyID]++
+	return true
+}
+
+// track registers an established connection so that it is closed on shutdown
+func (registry *websocketRegistry) track(conn *websocket.Conn) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.connections[conn] = struct{}{}
+}
+
+// release frees the connection slot of the given API key and forgets the given connection (which may be nil)
+func (registry *websocketRegistry) release(keyID uuid.UUID, conn *websocket.Conn) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.perKey[keyID] <= 1 {
+		delete(registry.perKey, keyID)
+	} else {
+		registry.perKey[keyID]--
+	}
+	if conn != nil {
+		delete(registry.connections, conn)
+	}
+}
+
+// closeAll closes every tracked connection
+func (registry *websocketRegistry) closeAll() {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for conn := range registry.connections {
+		conn.Close()
+	}
+}
+
+// websocketSession represents the state of a single WebSocket connection
+type websocketSession struct {
+	// ctx is the context of the upgraded request; it is valid as long as the connection is served
+	ctx     context.Context
+	service *Service
+	conn    *websocket.Conn
+	key     *apikey.Key
+	decode  bool
+
+	subscriptions      map[uint64]*websocketSubscription
+	lastSubscriptionID uint64
+}
+
+// EndpointWebsocket handles the 'GET /v1/ws?decode={bool?:false}' endpoint.
+// It upgrades the connection to a WebSocket connection over which the client manages its subscriptions using JSON
+// messages ('subscribe' with 'stations' and/or an 'area', 'unsubscribe' with a 'subscription_id'). Every newly fed
+// METAR matching at least one subscription is delivered once as a 'metar' message.
+// Opening a connection counts as a single request regarding the rate limit; every delivered event consumes one unit of
+// the API key's quota. The connection is closed as soon as the quota is exhausted.
+// Like every other endpoint, it is authenticated using the 'Authorization' header only, which browsers cannot set for
+// WebSocket connections. Connections sent with an 'Origin' header are thus rejected unless the origin is allowed
+// explicitly (see config.Config.DataAPIWebsocketAllowedOrigins).
+func (service *Service) EndpointWebsocket(writer http.ResponseWriter, request *http.Request) {
+	decode, validationErr := schema.QueryBool(request, "decode", false, false)
+	if validationErr != nil {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErr)
+		return
+	}
+
+	key := request.Context().Value(contextValueKey).(*apikey.Key)
+
+	// Reserve a connection slot before upgrading so that the limit can be reported using a regular response
+	max := service.Config.DataAPIMaxWebsocketConnections
+	if !service.websockets.acquire(key.ID, max) {
+		service.writer.WriteErrors(writer, http.StatusTooManyRequests, errWebsocketConnectionLimitReached(max))
+		return
+	}
+
+	upgrader := &websocket.Upgrader{
+		CheckOrigin: service.isAllowedWebsocketOrigin,
+		Error: func(writer http.ResponseWriter, _ *http.Request, status int, reason error) {
+			service.writer.WriteErrors(writer, status, errWebsocketUpgradeFailed(reason.Error()))
+		},
+	}
+	conn, err := upgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		service.websockets.release(key.ID, nil)
+		return
+	}
+	service.websockets.track(conn)
+	defer func() {
+		conn.Close()
+		service.websockets.release(key.ID, conn)
+	}()
+
+	session := &websocketSession{
+		ctx:           request.Context(),
+		service:       service,
+		conn:          conn,
+		key:           key,
+		decode:        decode,
+		subscriptions: make(map[uint64]*websocketSubscription),
+	}
+	session.run()
+}
+
+// isAllowedWebsocketOrigin returns whether a WebSocket connection may be established for the given request.
+// Clients other than browsers do not send an 'Origin' header and are always allowed.
+func (service *Service) isAllowedWebsocketOrigin(request *http.Request) bool {
+	origin := request.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range service.Config.DataAPIWebsocketAllowedOrigins {
+		if strings.EqualFold(origin, strings.TrimSuffix(strings.TrimSpace(allowed), "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// run serves the connection until it is closed by either side
+func (session *websocketSession) run() {
+	sub := session.service.METAREvents.Subscribe(metarStreamBufferSize)
+	defer sub.Unsubscribe()
+
+	// Client messages are read in a separate goroutine as gorilla/websocket supports one concurrent reader and writer
+	incoming := make(chan []byte)
+	done := make(chan struct{})
+	defer close(done)
+	go session.read(incoming, done)
+
+	ping := time.NewTicker(websocketPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case data, ok := <-incoming:
+			if !ok {
+				return
+			}
+			if err := session.handle(data); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := session.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteTimeout)); err != nil {
+				return
+			}
+		case obj, ok := <-sub.Events():
+			// The subscription is cancelled if the client does not keep up
+			if !ok {
+				if sub.Lagged() {
+					session.close(websocket.CloseTryAgainLater, errStreamLagged)
+				}
+				return
+			}
+			if !session.deliver(obj) {
+				return
+			}
+		}
+	}
+}
+
+// read reads client messages and passes them to the given channel until the connection fails or done is closed
+func (session *websocketSession) read(incoming chan<- []byte, done <-chan struct{}) {
+	defer close(incoming)
+
+	session.conn.SetReadLimit(websocketMaxMessageSize)
+	session.conn.SetReadDeadline(time.Now().Add(websocketPongTimeout))
+	session.conn.SetPongHandler(func(string) error {
+		return session.conn.SetReadDeadline(time.Now().Add(websocketPongTimeout))
+	})
+
+	for {
+		_, data, err := session.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debug().Err(err).Msg("a WebSocket connection of the data API was closed unexpectedly")
+			}
+			return
+		}
+		select {
+		case incoming <- data:
+		case <-done:
+			return
+		}
+	}
+}
+
+// handle handles a single client message; only errors writing to the connection are returned
+func (session *websocketSession) handle(data []byte) error {
+	message := new(websocketClientMessage)
+	if err := json.Unmarshal(data, message); err != nil {
+		return session.write(&websocketServerMessage{
+			Type:   websocketMessageError,
+			Errors: []*schema.Error{errWebsocketInvalidMessage("The message is no valid JSON object.")},
+		})
+	}
+
+	switch message.Type {
+	case websocketMessageSubscribe:
+		subscription, validationErrs := session.subscribe(message)
+		if len(validationErrs) > 0 {
+			return session.write(&websocketServerMessage{
+				Type:      websocketMessageError,
+				RequestID: message.RequestID,
+				Errors:    validationErrs,
+			})
+		}
+		return session.write(&websocketServerMessage{
+			Type:         websocketMessageSubscribed,
+			RequestID:    message.RequestID,
+			Subscription: subscription,
+		})
+	case websocketMessageUnsubscribe:
+		subscription, ok := session.subscriptions[message.SubscriptionID]
+		if !ok {
+			return session.write(&websocketServerMessage{
+				Type:      websocketMessageError,
+				RequestID: message.RequestID,
+				Errors:    []*schema.Error{errWebsocketUnknownSubscription(message.SubscriptionID)},
+			})
+		}
+		delete(session.subscriptions, subscription.ID)
+		return session.write(&websocketServerMessage{
+			Type:         websocketMessageUnsubscribed,
+			RequestID:    message.RequestID,
+			Subscription: subscription,
+		})
+	default:
+		return session.write(&websocketServerMessage{
+			Type:      websocketMessageError,
+			RequestID: message.RequestID,
+			Errors:    []*schema.Error{errWebsocketInvalidMessage(fmt.Sprintf("The message type '%s' is unknown (expected 'subscribe' or 'unsubscribe').", message.Type))},
+		})
+	}
+}
+
+// subscribe validates a subscribe message and creates the corresponding subscription.
+// The stations inside the area of the subscription are resolved once, so stations added to the registry afterwards are
+// not matched.
+func (session *websocketSession) subscribe(message *websocketClientMessage) (*websocketSubscription, []*schema.Error) {
+	if len(session.subscriptions) >= websocketMaxSubscriptions {
+		return nil, []*schema.Error{errWebsocketTooManySubscriptions(websocketMaxSubscriptions)}
+	}
+	if len(message.Stations) == 0 && message.Area == nil {
+		return nil, []*schema.Error{errWebsocketInvalidMessage("A subscription requires at least one station or an area.")}
+	}
+	if len(message.Stations) > metarLatestMaxStations {
+		return nil, []*schema.Error{errMETARTooManyStations(len(message.Stations), metarLatestMaxStations)}
+	}
+
+	stationIDs, validationErrs := parseStationIDs(strings.Join(message.Stations, ","))
+	if len(validationErrs) > 0 {
+		return nil, validationErrs
+	}
+
+	matching := make(map[string]struct{}, len(stationIDs))
+	for _, stationID := range stationIDs {
+		matching[stationID] = struct{}{}
+	}
+	if message.Area != nil {
+		area, validationErr := parseWebsocketArea(message.Area)
+		if validationErr != nil {
+			return nil, []*schema.Error{validationErr}
+		}
+		stations, err := session.service.Storage.Stations().GetWithinBounds(session.ctx, area.bounds, uint64(metarAreaMaxStations)+1)
+		if err != nil {
+			log.Error().Err(err).Msg("could not look up the stations of a WebSocket subscription")
+			return nil, []*schema.Error{schema.ErrInternal}
+		}
+		if len(stations) > metarAreaMaxStations {
+			return nil, []*schema.Error{errMETARTooLargeArea(metarAreaMaxStations)}
+		}
+		for _, obj := range stations {
+			if area.contains(obj) {
+				matching[obj.ICAO] = struct{}{}
+			}
+		}
+	}
+
+	session.lastSubscriptionID++
+	subscription := &websocketSubscription{
+		ID:       session.lastSubscriptionID,
+		Stations: stationIDs,
+		Area:     message.Area,
+		stations: matching,
+	}
+	session.subscriptions[subscription.ID] = subscription
+	return subscription, nil
+}
+
+// parseWebsocketArea validates the area of a subscription and converts it into a METAR query area
+func parseWebsocketArea(raw *websocketArea) (*metarArea, *schema.Error) {
+	hasPoint := raw.Latitude != nil || raw.Longitude != nil || raw.Radius != nil
+	if hasPoint && raw.BBox != nil {
+		return nil, errMETARInvalidArea("The 'bbox' field cannot be combined with the 'lat', 'lon' and 'radius_km' fields.")
+	}
+	if raw.BBox != nil {
+		return newBBoxMETARArea(raw.BBox)
+	}
+
+	if raw.Latitude == nil || raw.Longitude == nil || raw.Radius == nil {
+		return nil, errMETARInvalidArea("An area requires either the 'lat', 'lon' and 'radius_km' fields or the 'bbox' field.")
+	}
+	if *raw.Latitude < -90 || *raw.Latitude > 90 || *raw.Longitude < -180 || *raw.Longitude > 180 {
+		return nil, errMETARInvalidArea("The coordinates of the area are out of range.")
+	}
+	if *raw.Radius <= 0 || *raw.Radius > metarAreaMaxRadius {
+		return nil, errMETARInvalidArea(fmt.Sprintf("The radius of the area has to be greater than 0 and at most %g km.", metarAreaMaxRadius))
+	}
+	return &metarArea{
+		bounds:    station.BoundsAround(*raw.Latitude, *raw.Longitude, *raw.Radius),
+		latitude:  *raw.Latitude,
+		longitude: *raw.Longitude,
+		radius:    *raw.Radius,
+	}, nil
+}
+
+// deliver sends a METAR to the client if it matches at least one subscription and charges the API key; it returns
+// false if the connection has to be closed
+func (session *websocketSession) deliver(obj *metar.METAR) bool {
+	var matching []uint64
+	for id, subscription := range session.subscriptions {
+		if _, ok := subscription.stations[obj.StationID]; ok {
+			matching = append(matching, id)
+		}
+	}
+	if len(matching) == 0 {
+		return true
+	}
+
+	payload := &metarEvent{METAR: obj}
+	if session.decode {
+		payload.Decoded, _ = obj.Decode()
+	}
+	err := session.write(&websocketServerMessage{
+		Type:          websocketMessageMETAR,
+		Subscriptions: matching,
+		Data:          payload,
+	})
+	if err != nil {
+		return false
+	}
+
+	session.service.QuotaTracker.Accumulate(session.key)
+	if session.key.Quota >= 0 && session.service.QuotaTracker.Get(session.key) >= session.key.Quota {
+		session.close(websocket.ClosePolicyViolation, errKeyNoQuotaLeft)
+		return false
+	}
+	return true
+}
+
+// write writes a single message to the client
+func (session *websocketSession) write(message *websocketServerMessage) error {
+	session.conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	return session.conn.WriteJSON(message)
+}
+
+// close sends an error message followed by a close frame with the given code to the client
+func (session *websocketSession) close(code int, err *schema.Error) {
+	if session.write(&websocketServerMessage{Type: websocketMessageError, Errors: []*schema.Error{err}}) != nil {
+		return
+	}
+	message := websocket.FormatCloseMessage(code, err.Type)
+	session.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(websocketWriteTimeout))
+}
@@ -0,0 +1,335 @@
+package portal
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/alert"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/station"
+	"github.com/skybi/pluteo/internal/user"
+	"math"
+	"net/http"
+	"strings"
+)
+
+var (
+	errAlertRuleInvalidName = &schema.Error{
+		Type:    "portal.alertRule.invalidName",
+		Message: "The name of an alert rule must not be empty.",
+		Details: map[string]any{},
+	}
+	errAlertRuleInvalidStationID = func(stationID string) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.alertRule.invalidStationID",
+			Message: fmt.Sprintf("The station ID '%s' is formatted incorrectly (expected 4 alphanumeric characters).", stationID),
+			Details: map[string]any{
+				"station_id": stationID,
+			},
+		}
+	}
+	errAlertRuleInvalidConditions = func(given, max int) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.alertRule.invalidConditions",
+			Message: fmt.Sprintf("An alert rule has to consist of 1 to %d conditions (%d were given).", max, given),
+			Details: map[string]any{
+				"given": given,
+				"max":   max,
+			},
+		}
+	}
+	errAlertRuleInvalidCondition = func(index int, reason string) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.alertRule.invalidCondition",
+			Message: fmt.Sprintf("The condition at index %d is invalid: %s.", index, reason),
+			Details: map[string]any{
+				"index":  index,
+				"reason": reason,
+			},
+		}
+	}
+	errAlertRuleInvalidWebhook = func(id string) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.alertRule.invalidWebhook",
+			Message: fmt.Sprintf("The webhook '%s' does not exist or does not belong to the owner of the alert rule.", id),
+			Details: map[string]any{
+				"webhook_id": id,
+			},
+		}
+	}
+)
+
+type endpointCreateAlertRuleRequestPayload struct {
+	Name       *string             `json:"name" required:"true"`
+	StationID  *string             `json:"station_id" required:"true"`
+	Conditions *[]*alert.Condition `json:"conditions" required:"true"`
+	WebhookID  *string             `json:"webhook_id"`
+}
+
+// EndpointCreateAlertRule handles the 'POST /v1/alert_rules' endpoint
+func (service *Service) EndpointCreateAlertRule(writer http.ResponseWriter, request *http.Request) {
+	payload, validationErrs, err := schema.UnmarshalBody[endpointCreateAlertRuleRequestPayload](request)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	client := request.Context().Value(contextValueUser).(*user.User)
+
+	name := apikey.SanitizeDescription(*payload.Name)
+	if name == "" {
+		validationErrs = append(validationErrs, errAlertRuleInvalidName)
+	}
+	stationID := strings.ToUpper(strings.TrimSpace(*payload.StationID))
+	if !station.IsValidICAO(stationID) {
+		validationErrs = append(validationErrs, errAlertRuleInvalidStationID(stationID))
+	}
+	validationErrs = append(validationErrs, validateAlertRuleConditions(*payload.Conditions)...)
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	create := &alert.Create{
+		UserID:     client.ID,
+		Name:       name,
+		StationID:  stationID,
+		Conditions: *payload.Conditions,
+		WebhookID:  nil,
+	}
+	if payload.WebhookID != nil {
+		hookID, ok := service.resolveAlertRuleWebhook(writer, request, client.ID, *payload.WebhookID)
+		if !ok {
+			return
+		}
+		create.WebhookID = &hookID
+	}
+
+	rule, err := service.Storage.AlertRules().Create(request.Context(), create)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	service.writer.WriteJSONWithCode(writer, http.StatusCreated, rule)
+}
+
+// EndpointGetAlertRules handles the 'GET /v1/alert_rules?offset={number?:0}&limit={number?:10}&user_id={string?}' endpoint
+func (service *Service) EndpointGetAlertRules(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	offset, validationErr := schema.QueryNumber(request, "offset", false, 0, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	limit, validationErr := schema.QueryNumber(request, "limit", false, 10, 1, 1000)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	userID := request.URL.Query().Get("user_id")
+
+	client := request.Context().Value(contextValueUser).(*user.User)
+	if !client.Admin && userID != client.ID {
+		service.writer.WriteErrors(writer, http.StatusForbidden, schema.ErrForbidden)
+		return
+	}
+
+	var rules []*alert.Rule
+	var n uint64
+	var err error
+	if userID == "" {
+		rules, n, err = service.Storage.AlertRules().Get(request.Context(), uint64(offset), uint64(limit))
+	} else {
+		rules, n, err = service.Storage.AlertRules().GetByUserID(request.Context(), userID, uint64(offset), uint64(limit))
+	}
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	service.writer.WriteJSON(writer, schema.BuildPaginatedResponse(uint64(offset), uint64(limit), n, rules))
+}
+
+// EndpointGetAlertRule handles the 'GET /v1/alert_rules/{id}' endpoint
+func (service *Service) EndpointGetAlertRule(writer http.ResponseWriter, request *http.Request) {
+	rule, ok := service.fetchAccessibleAlertRule(writer, request)
+	if !ok {
+		return
+	}
+	service.writer.WriteJSON(writer, rule)
+}
+
+type endpointEditAlertRuleRequestPayload struct {
+	Name       *string             `json:"name"`
+	StationID  *string             `json:"station_id"`
+	Conditions *[]*alert.Condition `json:"conditions"`
+	WebhookID  *string             `json:"webhook_id"`
+	Enabled    *bool               `json:"enabled"`
+}
+
+// EndpointEditAlertRule handles the 'PATCH /v1/alert_rules/{id}' endpoint.
+// Changing the station or conditions resets the state of the rule. An empty webhook ID removes the webhook.
+func (service *Service) EndpointEditAlertRule(writer http.ResponseWriter, request *http.Request) {
+	rule, ok := service.fetchAccessibleAlertRule(writer, request)
+	if !ok {
+		return
+	}
+
+	payload, validationErrs, err := schema.UnmarshalBody[endpointEditAlertRuleRequestPayload](request)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	update := &alert.Update{
+		Enabled: payload.Enabled,
+	}
+	if payload.Name != nil {
+		name := apikey.SanitizeDescription(*payload.Name)
+		if name == "" {
+			validationErrs = append(validationErrs, errAlertRuleInvalidName)
+		}
+		update.Name = &name
+	}
+	if payload.StationID != nil {
+		stationID := strings.ToUpper(strings.TrimSpace(*payload.StationID))
+		if !station.IsValidICAO(stationID) {
+			validationErrs = append(validationErrs, errAlertRuleInvalidStationID(stationID))
+		}
+		update.StationID = &stationID
+	}
+	if payload.Conditions != nil {
+		validationErrs = append(validationErrs, validateAlertRuleConditions(*payload.Conditions)...)
+		update.Conditions = *payload.Conditions
+	}
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+	if payload.WebhookID != nil {
+		if strings.TrimSpace(*payload.WebhookID) == "" {
+			update.ClearWebhook = true
+		} else {
+			hookID, ok := service.resolveAlertRuleWebhook(writer, request, rule.UserID, *payload.WebhookID)
+			if !ok {
+				return
+			}
+			update.WebhookID = &hookID
+		}
+	}
+
+	newObj, err := service.Storage.AlertRules().Update(request.Context(), rule.ID, update)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	service.writer.WriteJSONWithCode(writer, http.StatusOK, newObj)
+}
+
+// EndpointDeleteAlertRule handles the 'DELETE /v1/alert_rules/{id}' endpoint
+func (service *Service) EndpointDeleteAlertRule(writer http.ResponseWriter, request *http.Request) {
+	rule, ok := service.fetchAccessibleAlertRule(writer, request)
+	if !ok {
+		return
+	}
+
+	if err := service.Storage.AlertRules().Delete(request.Context(), rule.ID); err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// fetchAccessibleAlertRule retrieves the alert rule addressed by the 'id' URL parameter and makes sure the client may
+// access it. If not, an error is written and ok is false.
+func (service *Service) fetchAccessibleAlertRule(writer http.ResponseWriter, request *http.Request) (*alert.Rule, bool) {
+	client := request.Context().Value(contextValueUser).(*user.User)
+
+	id := chi.URLParam(request, "id")
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		if client.Admin {
+			service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		} else {
+			service.writer.WriteErrors(writer, http.StatusForbidden, schema.ErrForbidden)
+		}
+		return nil, false
+	}
+
+	obj, err := service.Storage.AlertRules().GetByID(request.Context(), uid)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return nil, false
+	}
+
+	if !client.Admin && (obj == nil || obj.UserID != client.ID) {
+		service.writer.WriteErrors(writer, http.StatusForbidden, schema.ErrForbidden)
+		return nil, false
+	}
+
+	if obj == nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return nil, false
+	}
+
+	return obj, true
+}
+
+// resolveAlertRuleWebhook makes sure that the given webhook exists and belongs to the owner of the alert rule.
+// If not, an error is written and ok is false.
+func (service *Service) resolveAlertRuleWebhook(writer http.ResponseWriter, request *http.Request, userID, raw string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, errAlertRuleInvalidWebhook(raw))
+		return uuid.Nil, false
+	}
+	hook, err := service.Storage.Webhooks().GetByID(request.Context(), id)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return uuid.Nil, false
+	}
+	if hook == nil || hook.UserID != userID {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, errAlertRuleInvalidWebhook(raw))
+		return uuid.Nil, false
+	}
+	return hook.ID, true
+}
+
+// validateAlertRuleConditions validates the conditions an alert rule should consist of
+func validateAlertRuleConditions(conditions []*alert.Condition) []*schema.Error {
+	if len(conditions) == 0 || len(conditions) > alert.MaxConditions {
+		return []*schema.Error{errAlertRuleInvalidConditions(len(conditions), alert.MaxConditions)}
+	}
+
+	var validationErrs []*schema.Error
+	for i, condition := range conditions {
+		if condition == nil {
+			validationErrs = append(validationErrs, errAlertRuleInvalidCondition(i, "the condition must not be null"))
+			continue
+		}
+		condition.Runway = strings.ToUpper(strings.TrimSpace(condition.Runway))
+		condition.Phenomenon = strings.ToUpper(strings.TrimSpace(condition.Phenomenon))
+		condition.Category = metar.FlightCategory(strings.ToUpper(strings.TrimSpace(string(condition.Category))))
+		if err := condition.Validate(); err != nil {
+			validationErrs = append(validationErrs, errAlertRuleInvalidCondition(i, err.Error()))
+		}
+	}
+	return validationErrs
+}
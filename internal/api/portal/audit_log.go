@@ -0,0 +1,46 @@
+package portal
+
+import (
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/audit"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// EndpointGetAuditLog handles the 'GET /v1/audit_log?offset={number?:0}&limit={number?:10}&action={string?}' endpoint
+func (service *Service) EndpointGetAuditLog(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	offset, validationErr := schema.QueryNumber(request, "offset", false, 0, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	limit, validationErr := schema.QueryNumber(request, "limit", false, 10, 1, 1000)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	action := audit.Action(strings.TrimSpace(request.URL.Query().Get("action")))
+
+	var entries []*audit.Entry
+	var n uint64
+	var err error
+	if action == "" {
+		entries, n, err = service.Storage.AuditLog().Get(request.Context(), uint64(offset), uint64(limit))
+	} else {
+		entries, n, err = service.Storage.AuditLog().GetByAction(request.Context(), action, uint64(offset), uint64(limit))
+	}
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	service.writer.WriteJSON(writer, schema.BuildPaginatedResponse(uint64(offset), uint64(limit), n, entries))
+}
@@ -0,0 +1,121 @@
+package portal
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/coverage"
+	"github.com/skybi/pluteo/internal/station"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// coverageDefaultDays defines how many days of statistics are returned if no time range is given
+const coverageDefaultDays = 30
+
+var (
+	errCoverageInvalidStationID = func(stationID string) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.coverage.invalidStationID",
+			Message: fmt.Sprintf("The station ID '%s' is formatted incorrectly (expected 4 alphanumeric characters).", stationID),
+			Details: map[string]any{
+				"station_id": stationID,
+			},
+		}
+	}
+	errCoverageInvalidTimeRange = &schema.Error{
+		Type:    "portal.coverage.invalidTimeRange",
+		Message: "The start of the time range must not be after its end.",
+		Details: map[string]any{},
+	}
+)
+
+// silentStation represents a station that went silent together with the amount of seconds it has been silent for
+type silentStation struct {
+	StationID    string `json:"station_id"`
+	LastIssuedAt int64  `json:"last_issued_at"`
+	SilentFor    int64  `json:"silent_for"`
+}
+
+// EndpointGetSilentStations handles the 'GET /v1/coverage/silent?hours={number?:6}&active_days={number?:7}' endpoint.
+// It lists the stations that issued METARs during the last active_days days but none during the last hours hours.
+// The result is based on the coverage statistics and thus only as recent as their latest refresh.
+func (service *Service) EndpointGetSilentStations(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	hours, validationErr := schema.QueryNumber(request, "hours", false, 6, 1, 24*90)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	activeDays, validationErr := schema.QueryNumber(request, "active_days", false, 7, 1, coverage.WindowDays)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	now := time.Now()
+	silentSince := now.Add(-time.Duration(hours) * time.Hour).Unix()
+	activeSince := now.AddDate(0, 0, -int(activeDays)).Unix()
+	stations, err := service.Storage.Coverage().GetSilent(request.Context(), silentSince, activeSince)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	items := make([]*silentStation, 0, len(stations))
+	for _, obj := range stations {
+		items = append(items, &silentStation{
+			StationID:    obj.StationID,
+			LastIssuedAt: obj.LastIssuedAt,
+			SilentFor:    now.Unix() - obj.LastIssuedAt,
+		})
+	}
+	n := uint64(len(items))
+	service.writer.WriteJSON(writer, schema.BuildPaginatedResponse(0, n, n, items))
+}
+
+// EndpointGetStationCoverage handles the 'GET /v1/coverage/stations/{id}?from={number?}&to={number?}' endpoint.
+// It lists the daily coverage and latency statistics of a station; the last 30 days are returned by default.
+func (service *Service) EndpointGetStationCoverage(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	stationID := strings.ToUpper(chi.URLParam(request, "id"))
+	if !station.IsValidICAO(stationID) {
+		validationErrs = append(validationErrs, errCoverageInvalidStationID(stationID))
+	}
+
+	now := time.Now()
+	to, validationErr := schema.QueryNumber(request, "to", false, now.Unix(), 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	from, validationErr := schema.QueryNumber(request, "from", false, now.AddDate(0, 0, 1-coverageDefaultDays).Unix(), 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) == 0 && from > to {
+		validationErrs = append(validationErrs, errCoverageInvalidTimeRange)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	days, err := service.Storage.Coverage().GetByStation(request.Context(), stationID, from, to)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	n := uint64(len(days))
+	service.writer.WriteJSON(writer, schema.BuildPaginatedResponse(0, n, n, days))
+}
@@ -0,0 +1,14 @@
+package portal
+
+import (
+	"github.com/skybi/pluteo/internal/api/schema"
+	"net/http"
+)
+
+// EndpointGetIngestSources handles the 'GET /v1/ingest/sources' endpoint.
+// It lists the health of every configured ingest source.
+func (service *Service) EndpointGetIngestSources(writer http.ResponseWriter, _ *http.Request) {
+	statuses := service.Ingester.Statuses()
+	n := uint64(len(statuses))
+	service.writer.WriteJSON(writer, schema.BuildPaginatedResponse(0, n, n, statuses))
+}
@@ -0,0 +1,545 @@
+package portal
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/audit"
+	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/user"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// metarFilterParameters contains the query parameters that make up a METAR filter
+var metarFilterParameters = []string{
+	"station_id", "before", "after", "type", "corrected", "automated", "nil", "flight_category", "fed_by",
+	"received_after", "received_before", "include_superseded",
+}
+
+var (
+	errMETARInvalidCursor = func(cursor string) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.metars.invalidCursor",
+			Message: fmt.Sprintf("The cursor '%s' is invalid.", cursor),
+			Details: map[string]any{
+				"cursor": cursor,
+			},
+		}
+	}
+	errMETARInvalidAPIKeyID = func(id string) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.metars.invalidAPIKeyID",
+			Message: fmt.Sprintf("The API key ID '%s' is formatted incorrectly (expected a UUID).", id),
+			Details: map[string]any{
+				"api_key_id": id,
+			},
+		}
+	}
+	errMETAREmptyFilter = &schema.Error{
+		Type:    "portal.metars.emptyFilter",
+		Message: "Deleting METARs in bulk requires at least one filter parameter.",
+		Details: map[string]any{},
+	}
+	errMETARReparseEmptyFilter = &schema.Error{
+		Type:    "portal.metars.emptyFilter",
+		Message: "Reparsing METARs requires at least one filter parameter or 'all=true'.",
+		Details: map[string]any{},
+	}
+	errMETARReparseRunning = &schema.Error{
+		Type:    "portal.metars.reparseRunning",
+		Message: "Another reparse job is still running.",
+		Details: map[string]any{},
+	}
+)
+
+// metarProvenance represents a METAR together with its provenance, which is only exposed to admins
+type metarProvenance struct {
+	*metar.METAR
+	FedBy      *uuid.UUID `json:"fed_by"`
+	ReceivedAt *int64     `json:"received_at"`
+	FeedCount  int        `json:"feed_count"`
+}
+
+func provenanceOf(obj *metar.METAR) *metarProvenance {
+	return &metarProvenance{
+		METAR:      obj,
+		FedBy:      obj.FedBy,
+		ReceivedAt: obj.ReceivedAt,
+		FeedCount:  obj.FeedCount,
+	}
+}
+
+// EndpointGetMETAR handles the 'GET /v1/metars/{id}' endpoint.
+// It returns a METAR together with its provenance.
+func (service *Service) EndpointGetMETAR(writer http.ResponseWriter, request *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	obj, err := service.Storage.METARs().GetByID(request.Context(), id)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if obj == nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	service.writer.WriteJSON(writer, provenanceOf(obj))
+}
+
+// EndpointGetMETARs handles the 'GET /v1/metars?station_id={string?}&before={timestamp?}&after={timestamp?}&type={METAR|SPECI?}&corrected={bool?}&automated={bool?}&nil={bool?}&flight_category={VFR|MVFR|IFR|LIFR?}&fed_by={string?}&received_after={timestamp?}&received_before={timestamp?}&include_superseded={bool?:false}&cursor={string?}&count={bool?:true}&limit={number?:10}' endpoint.
+// It searches METARs using the same filters as the data API and returns them together with their provenance.
+func (service *Service) EndpointGetMETARs(writer http.ResponseWriter, request *http.Request) {
+	filter, validationErrs := parseMETARFilter(request)
+
+	if token := strings.TrimSpace(request.URL.Query().Get("cursor")); token != "" {
+		cursor, err := metar.ParseCursor(token)
+		if err != nil {
+			validationErrs = append(validationErrs, errMETARInvalidCursor(token))
+		}
+		filter.Cursor = cursor
+	}
+
+	count, validationErr := schema.QueryBool(request, "count", false, true)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	limit, validationErr := schema.QueryNumber(request, "limit", false, 10, 1, 100)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+	filter.SkipCount = !count
+
+	// One more METAR than requested is fetched to determine whether there is a next page
+	metars, n, err := service.Storage.METARs().GetByFilter(request.Context(), filter, uint64(limit)+1)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	nextCursor := ""
+	if len(metars) > int(limit) {
+		metars = metars[:limit]
+		nextCursor = metar.CursorOf(metars[len(metars)-1]).String()
+	}
+	var totalCount *uint64
+	if count {
+		totalCount = &n
+	}
+
+	items := make([]*metarProvenance, 0, len(metars))
+	for _, obj := range metars {
+		items = append(items, provenanceOf(obj))
+	}
+	service.writer.WriteJSON(writer, schema.BuildCursorPaginatedResponse(uint64(limit), totalCount, nextCursor, items))
+}
+
+// EndpointDeleteMETAR handles the 'DELETE /v1/metars/{id}' endpoint
+func (service *Service) EndpointDeleteMETAR(writer http.ResponseWriter, request *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	obj, err := service.Storage.METARs().GetByID(request.Context(), id)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if obj == nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	// The deleted METAR itself is recorded so that it can be restored if necessary
+	target := obj.ID.String()
+	entry := auditEntryOf(request, &audit.Create{
+		Action: audit.ActionMETARDelete,
+		Target: &target,
+		Parameters: map[string]any{
+			"station_id": obj.StationID,
+			"issued_at":  obj.IssuedAt,
+			"type":       obj.Type,
+			"raw":        obj.Raw,
+		},
+		Affected: 1,
+	})
+	if err := service.Storage.METARs().Delete(request.Context(), obj.ID, obj.IssuedAt, entry); err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// EndpointDeleteMETARs handles the 'DELETE /v1/metars?station_id={string?}&before={timestamp?}&after={timestamp?}&type={METAR|SPECI?}&corrected={bool?}&automated={bool?}&nil={bool?}&flight_category={VFR|MVFR|IFR|LIFR?}&fed_by={string?}&received_after={timestamp?}&received_before={timestamp?}&include_superseded={bool?:false}&dry_run={bool?:false}' endpoint.
+// It deletes all METARs following the filter, which must not be empty. If dry_run is set, the METARs are only counted.
+func (service *Service) EndpointDeleteMETARs(writer http.ResponseWriter, request *http.Request) {
+	filter, validationErrs := parseMETARFilter(request)
+
+	dryRun, validationErr := schema.QueryBool(request, "dry_run", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) == 0 && filter.IsEmpty() {
+		validationErrs = append(validationErrs, errMETAREmptyFilter)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	var n int64
+	if dryRun {
+		_, count, err := service.Storage.METARs().GetByFilter(request.Context(), filter, 1)
+		if err != nil {
+			service.writer.WriteInternalError(writer, err)
+			return
+		}
+		n = int64(count)
+	} else {
+		entry := auditEntryOf(request, &audit.Create{
+			Action:     audit.ActionMETARBulkDelete,
+			Parameters: metarFilterParametersOf(request),
+		})
+		var err error
+		n, err = service.Storage.METARs().DeleteByFilter(request.Context(), filter, entry)
+		if err != nil {
+			service.writer.WriteInternalError(writer, err)
+			return
+		}
+	}
+
+	service.writer.WriteJSON(writer, map[string]any{
+		"deleted": n,
+		"dry_run": dryRun,
+	})
+}
+
+// EndpointReparseMETARs handles the 'POST /v1/metars/reparse?station_id={string?}&before={timestamp?}&after={timestamp?}&type={METAR|SPECI?}&corrected={bool?}&automated={bool?}&nil={bool?}&flight_category={VFR|MVFR|IFR|LIFR?}&fed_by={string?}&received_after={timestamp?}&received_before={timestamp?}&include_superseded={bool?:false}&all={bool?:false}&dry_run={bool?:false}' endpoint.
+// It re-derives the report modifiers and flight categories of all METARs following the filter from their raw text,
+// e.g. after the decoder was improved. The filter must not be empty unless all is set.
+// The METARs are reparsed by a background job after the action was recorded in the audit trail (with the amount of
+// matching METARs as its affected count); only one job may run at a time. If dry_run is set, the METARs are only
+// counted.
+func (service *Service) EndpointReparseMETARs(writer http.ResponseWriter, request *http.Request) {
+	filter, validationErrs := parseMETARFilter(request)
+
+	all, validationErr := schema.QueryBool(request, "all", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	dryRun, validationErr := schema.QueryBool(request, "dry_run", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) == 0 && !all && filter.IsEmpty() {
+		validationErrs = append(validationErrs, errMETARReparseEmptyFilter)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	_, matched, err := service.Storage.METARs().GetByFilter(request.Context(), filter, 1)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if dryRun {
+		service.writer.WriteJSON(writer, map[string]any{
+			"matched": matched,
+			"dry_run": true,
+		})
+		return
+	}
+
+	if !service.reparsing.TryLock() {
+		service.writer.WriteErrors(writer, http.StatusConflict, errMETARReparseRunning)
+		return
+	}
+
+	parameters := metarFilterParametersOf(request)
+	if all {
+		parameters["all"] = true
+	}
+	entry, err := service.Storage.AuditLog().Create(request.Context(), auditEntryOf(request, &audit.Create{
+		Action:     audit.ActionMETARReparse,
+		Parameters: parameters,
+		Affected:   int64(matched),
+	}))
+	if err != nil {
+		service.reparsing.Unlock()
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	// The job must not be cancelled together with the request
+	go func() {
+		defer service.reparsing.Unlock()
+		result, err := service.Storage.METARs().Reparse(context.Background(), filter)
+		if err != nil {
+			log.Error().Err(err).Str("audit_entry", entry.ID.String()).Msg("could not reparse METARs")
+			return
+		}
+		log.Info().
+			Str("audit_entry", entry.ID.String()).
+			Int64("processed", result.Processed).
+			Int64("changed", result.Changed).
+			Int64("failed", result.Failed).
+			Msg("reparsed METARs")
+	}()
+
+	service.writer.WriteJSONWithCode(writer, http.StatusAccepted, map[string]any{
+		"audit_entry_id": entry.ID,
+		"matched":        matched,
+		"dry_run":        false,
+	})
+}
+
+// EndpointGetAPIKeyMETARs handles the 'GET /v1/api_keys/{id}/metars?received_after={number?}&received_before={number?}&limit={number?:10}&cursor={string?}' endpoint.
+// It lists the METARs (including superseded ones) first fed by an API key, which does not have to exist anymore.
+func (service *Service) EndpointGetAPIKeyMETARs(writer http.ResponseWriter, request *http.Request) {
+	keyID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	filter, validationErrs := receiveWindowFilter(request, keyID)
+
+	var cursor *metar.Cursor
+	if token := strings.TrimSpace(request.URL.Query().Get("cursor")); token != "" {
+		cursor, err = metar.ParseCursor(token)
+		if err != nil {
+			validationErrs = append(validationErrs, errMETARInvalidCursor(token))
+		}
+	}
+
+	limit, validationErr := schema.QueryNumber(request, "limit", false, 10, 1, 100)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+	filter.Cursor = cursor
+
+	// One more METAR than requested is fetched to determine whether there is a next page
+	metars, n, err := service.Storage.METARs().GetByFilter(request.Context(), filter, uint64(limit)+1)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	nextCursor := ""
+	if len(metars) > int(limit) {
+		metars = metars[:limit]
+		nextCursor = metar.CursorOf(metars[len(metars)-1]).String()
+	}
+
+	items := make([]*metarProvenance, 0, len(metars))
+	for _, obj := range metars {
+		items = append(items, provenanceOf(obj))
+	}
+	service.writer.WriteJSON(writer, schema.BuildCursorPaginatedResponse(uint64(limit), &n, nextCursor, items))
+}
+
+// EndpointDeleteAPIKeyMETARs handles the 'DELETE /v1/api_keys/{id}/metars?received_after={number?}&received_before={number?}' endpoint.
+// It deletes all METARs first fed by an API key (e.g. a compromised one) inside the given receive window. METARs that
+// were fed by other API keys too are deleted as well.
+func (service *Service) EndpointDeleteAPIKeyMETARs(writer http.ResponseWriter, request *http.Request) {
+	keyID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return
+	}
+
+	filter, validationErrs := receiveWindowFilter(request, keyID)
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	parameters := metarFilterParametersOf(request)
+	parameters["fed_by"] = keyID.String()
+	entry := auditEntryOf(request, &audit.Create{
+		Action:     audit.ActionMETARBulkDelete,
+		Parameters: parameters,
+	})
+	n, err := service.Storage.METARs().DeleteByFilter(request.Context(), filter, entry)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	service.writer.WriteJSON(writer, map[string]any{
+		"deleted": n,
+	})
+}
+
+// receiveWindowFilter builds a filter matching the METARs first fed by the given API key inside the receive window
+// specified by the 'received_after' and 'received_before' query parameters (both exclusive)
+func receiveWindowFilter(request *http.Request, keyID uuid.UUID) (*metar.Filter, []*schema.Error) {
+	var validationErrs []*schema.Error
+
+	after, validationErr := schema.QueryNumber(request, "received_after", false, -1, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	before, validationErr := schema.QueryNumber(request, "received_before", false, -1, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	filter := &metar.Filter{
+		FedBy:             &keyID,
+		IncludeSuperseded: true,
+	}
+	if after >= 0 {
+		filter.ReceivedAfter = &after
+	}
+	if before >= 0 {
+		filter.ReceivedBefore = &before
+	}
+	return filter, validationErrs
+}
+
+// parseMETARFilter builds a METAR filter using the filter query parameters (see metarFilterParameters)
+func parseMETARFilter(request *http.Request) (*metar.Filter, []*schema.Error) {
+	var validationErrs []*schema.Error
+
+	before, validationErr := schema.QueryNumber(request, "before", false, -1, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	after, validationErr := schema.QueryNumber(request, "after", false, -1, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	reportType, validationErr := schema.QueryEnum(request, "type", false, "", string(metar.ReportTypeMETAR), string(metar.ReportTypeSPECI))
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	corrected, validationErr := schema.QueryOptionalBool(request, "corrected")
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	automated, validationErr := schema.QueryOptionalBool(request, "automated")
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	isNil, validationErr := schema.QueryOptionalBool(request, "nil")
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	flightCategory, validationErr := schema.QueryEnum(request, "flight_category", false, "",
+		string(metar.FlightCategoryVFR),
+		string(metar.FlightCategoryMVFR),
+		string(metar.FlightCategoryIFR),
+		string(metar.FlightCategoryLIFR),
+	)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	receivedAfter, validationErr := schema.QueryNumber(request, "received_after", false, -1, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	receivedBefore, validationErr := schema.QueryNumber(request, "received_before", false, -1, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	includeSuperseded, validationErr := schema.QueryBool(request, "include_superseded", false, false)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	filter := &metar.Filter{
+		Corrected:         corrected,
+		Automated:         automated,
+		Nil:               isNil,
+		IncludeSuperseded: includeSuperseded,
+	}
+	if stationID := strings.ToUpper(strings.TrimSpace(request.URL.Query().Get("station_id"))); stationID != "" {
+		filter.StationID = &stationID
+	}
+	if before >= 0 {
+		filter.IssuedBefore = &before
+	}
+	if after >= 0 {
+		filter.IssuedAfter = &after
+	}
+	if reportType != "" {
+		typ := metar.ReportType(reportType)
+		filter.Type = &typ
+	}
+	if flightCategory != "" {
+		category := metar.FlightCategory(flightCategory)
+		filter.FlightCategory = &category
+	}
+	if fedBy := strings.TrimSpace(request.URL.Query().Get("fed_by")); fedBy != "" {
+		keyID, err := uuid.Parse(fedBy)
+		if err != nil {
+			validationErrs = append(validationErrs, errMETARInvalidAPIKeyID(fedBy))
+		} else {
+			filter.FedBy = &keyID
+		}
+	}
+	if receivedAfter >= 0 {
+		filter.ReceivedAfter = &receivedAfter
+	}
+	if receivedBefore >= 0 {
+		filter.ReceivedBefore = &receivedBefore
+	}
+	return filter, validationErrs
+}
+
+// metarFilterParametersOf returns the given filter query parameters of a request
+func metarFilterParametersOf(request *http.Request) map[string]any {
+	parameters := make(map[string]any)
+	for _, key := range metarFilterParameters {
+		if value := strings.TrimSpace(request.URL.Query().Get(key)); value != "" {
+			parameters[key] = value
+		}
+	}
+	return parameters
+}
+
+// auditEntryOf completes an audit trail entry on behalf of the requesting user.
+// It has to be recorded before or together with the action it describes so that no action goes unrecorded.
+func auditEntryOf(request *http.Request, create *audit.Create) *audit.Create {
+	create.UserID = request.Context().Value(contextValueUser).(*user.User).ID
+	return create
+}
@@ -12,9 +12,11 @@ import (
 	"github.com/skybi/pluteo/internal/api/schema"
 	"github.com/skybi/pluteo/internal/config"
 	"github.com/skybi/pluteo/internal/function"
+	"github.com/skybi/pluteo/internal/ingest"
 	"github.com/skybi/pluteo/internal/storage"
 	"golang.org/x/oauth2"
 	"net/http"
+	"sync"
 )
 
 // Service represents the portal API service
@@ -24,12 +26,18 @@ type Service struct {
 	Config  *config.Config
 	Storage storage.Driver
 
+	// Ingester pulls METARs from the configured upstream sources
+	Ingester *ingest.Ingester
+
 	oidcOAuth2Config        *oauth2.Config
 	oidcProvider            *oidc.Provider
 	oidcIDTokenVerifier     *oidc.IDTokenVerifier
 	oidcLogoutTokenVerifier *oidc.LogoutTokenVerifier
 	sessionStorage          session.Storage
 
+	// reparsing is held while a METAR reparse job runs in the background
+	reparsing sync.Mutex
+
 	writer *schema.Writer
 }
 
@@ -181,4 +189,135 @@ func (service *Service) registerEndpoints(router chi.Router) {
 		service.MiddlewareVerifySession,
 		service.MiddlewareFetchUser,
 	))
+	router.Get("/v1/api_keys/{id}/metars", function.Nest[http.HandlerFunc](
+		service.EndpointGetAPIKeyMETARs,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+	router.Delete("/v1/api_keys/{id}/metars", function.Nest[http.HandlerFunc](
+		service.EndpointDeleteAPIKeyMETARs,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+
+	// Register the webhook controller endpoints
+	router.Post("/v1/webhooks", function.Nest[http.HandlerFunc](
+		service.EndpointCreateWebhook,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+	router.Get("/v1/webhooks", function.Nest[http.HandlerFunc](
+		service.EndpointGetWebhooks,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+	router.Get("/v1/webhooks/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointGetWebhook,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+	router.Patch("/v1/webhooks/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointEditWebhook,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+	router.Delete("/v1/webhooks/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointDeleteWebhook,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+	router.Get("/v1/webhooks/{id}/attempts", function.Nest[http.HandlerFunc](
+		service.EndpointGetWebhookAttempts,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+
+	// Register the alert rule controller endpoints
+	router.Post("/v1/alert_rules", function.Nest[http.HandlerFunc](
+		service.EndpointCreateAlertRule,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+	router.Get("/v1/alert_rules", function.Nest[http.HandlerFunc](
+		service.EndpointGetAlertRules,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+	router.Get("/v1/alert_rules/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointGetAlertRule,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+	router.Patch("/v1/alert_rules/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointEditAlertRule,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+	router.Delete("/v1/alert_rules/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointDeleteAlertRule,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+	))
+
+	// Register the METAR management endpoints
+	router.Get("/v1/metars", function.Nest[http.HandlerFunc](
+		service.EndpointGetMETARs,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+	router.Delete("/v1/metars", function.Nest[http.HandlerFunc](
+		service.EndpointDeleteMETARs,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+	router.Post("/v1/metars/reparse", function.Nest[http.HandlerFunc](
+		service.EndpointReparseMETARs,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+	router.Get("/v1/metars/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointGetMETAR,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+	router.Delete("/v1/metars/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointDeleteMETAR,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+	router.Get("/v1/audit_log", function.Nest[http.HandlerFunc](
+		service.EndpointGetAuditLog,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+
+	// Register the coverage report endpoints
+	router.Get("/v1/coverage/silent", function.Nest[http.HandlerFunc](
+		service.EndpointGetSilentStations,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+	router.Get("/v1/coverage/stations/{id}", function.Nest[http.HandlerFunc](
+		service.EndpointGetStationCoverage,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
+
+	// Register the ingest source endpoints
+	router.Get("/v1/ingest/sources", function.Nest[http.HandlerFunc](
+		service.EndpointGetIngestSources,
+		service.MiddlewareVerifySession,
+		service.MiddlewareFetchUser,
+		service.MiddlewareCheckAdmin,
+	))
 }
@@ -0,0 +1,349 @@
+package portal
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/api/schema"
+	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/station"
+	"github.com/skybi/pluteo/internal/user"
+	"github.com/skybi/pluteo/internal/webhook"
+	"math"
+	"net/http"
+	"strings"
+)
+
+var (
+	errWebhookInvalidURL = func(url string) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.webhook.invalidURL",
+			Message: fmt.Sprintf("The webhook URL '%s' is invalid (expected an absolute HTTP(S) URL without credentials).", url),
+			Details: map[string]any{
+				"url": url,
+			},
+		}
+	}
+	errWebhookInvalidStations = func(given, max int) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.webhook.invalidStations",
+			Message: fmt.Sprintf("A webhook has to be bound to 1 to %d stations (%d were given).", max, given),
+			Details: map[string]any{
+				"given": given,
+				"max":   max,
+			},
+		}
+	}
+	errWebhookInvalidStationID = func(stationID string) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.webhook.invalidStationID",
+			Message: fmt.Sprintf("The station ID '%s' is formatted incorrectly (expected 4 alphanumeric characters).", stationID),
+			Details: map[string]any{
+				"station_id": stationID,
+			},
+		}
+	}
+	errWebhookInvalidAPIKey = func(id string) *schema.Error {
+		return &schema.Error{
+			Type:    "portal.webhook.invalidAPIKey",
+			Message: fmt.Sprintf("The API key '%s' does not exist or is not allowed to read METARs.", id),
+			Details: map[string]any{
+				"api_key_id": id,
+			},
+		}
+	}
+)
+
+type endpointCreateWebhookRequestPayload struct {
+	APIKeyID    *string   `json:"api_key_id" required:"true"`
+	URL         *string   `json:"url" required:"true"`
+	Description *string   `json:"description"`
+	StationIDs  *[]string `json:"station_ids" required:"true"`
+}
+
+type endpointCreateWebhookResponse struct {
+	*webhook.Webhook
+	Secret string `json:"secret"`
+}
+
+// EndpointCreateWebhook handles the 'POST /v1/webhooks' endpoint.
+// The secret used to sign the webhook requests is only included in the response of this endpoint.
+func (service *Service) EndpointCreateWebhook(writer http.ResponseWriter, request *http.Request) {
+	payload, validationErrs, err := schema.UnmarshalBody[endpointCreateWebhookRequestPayload](request)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	url := strings.TrimSpace(*payload.URL)
+	if !webhook.IsValidURL(url) {
+		validationErrs = append(validationErrs, errWebhookInvalidURL(url))
+	}
+	stationIDs, stationErrs := validateWebhookStations(*payload.StationIDs)
+	validationErrs = append(validationErrs, stationErrs...)
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	// The webhook has to be bound to an API key of the client that is allowed to read METARs
+	client := request.Context().Value(contextValueUser).(*user.User)
+	keyID, err := uuid.Parse(*payload.APIKeyID)
+	var key *apikey.Key
+	if err == nil {
+		key, err = service.Storage.APIKeys().GetByID(request.Context(), keyID)
+		if err != nil {
+			service.writer.WriteInternalError(writer, err)
+			return
+		}
+	}
+	if key == nil || (!client.Admin && key.UserID != client.ID) || !key.Capabilities.Has(apikey.CapabilityReadMETARs) {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, errWebhookInvalidAPIKey(*payload.APIKeyID))
+		return
+	}
+
+	create := &webhook.Create{
+		UserID:      key.UserID,
+		KeyID:       key.ID,
+		URL:         url,
+		Description: "",
+		StationIDs:  stationIDs,
+	}
+	if payload.Description != nil {
+		create.Description = apikey.SanitizeDescription(*payload.Description)
+	}
+
+	hook, err := service.Storage.Webhooks().Create(request.Context(), create)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	service.writer.WriteJSONWithCode(writer, http.StatusCreated, endpointCreateWebhookResponse{
+		Webhook: hook,
+		Secret:  hook.Secret,
+	})
+}
+
+// EndpointGetWebhooks handles the 'GET /v1/webhooks?offset={number?:0}&limit={number?:10}&user_id={string?}' endpoint
+func (service *Service) EndpointGetWebhooks(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	offset, validationErr := schema.QueryNumber(request, "offset", false, 0, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	limit, validationErr := schema.QueryNumber(request, "limit", false, 10, 1, 1000)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	userID := request.URL.Query().Get("user_id")
+
+	client := request.Context().Value(contextValueUser).(*user.User)
+	if !client.Admin && userID != client.ID {
+		service.writer.WriteErrors(writer, http.StatusForbidden, schema.ErrForbidden)
+		return
+	}
+
+	var hooks []*webhook.Webhook
+	var n uint64
+	var err error
+	if userID == "" {
+		hooks, n, err = service.Storage.Webhooks().Get(request.Context(), uint64(offset), uint64(limit))
+	} else {
+		hooks, n, err = service.Storage.Webhooks().GetByUserID(request.Context(), userID, uint64(offset), uint64(limit))
+	}
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	service.writer.WriteJSON(writer, schema.BuildPaginatedResponse(uint64(offset), uint64(limit), n, hooks))
+}
+
+// EndpointGetWebhook handles the 'GET /v1/webhooks/{id}' endpoint
+func (service *Service) EndpointGetWebhook(writer http.ResponseWriter, request *http.Request) {
+	hook, ok := service.fetchAccessibleWebhook(writer, request)
+	if !ok {
+		return
+	}
+	service.writer.WriteJSON(writer, hook)
+}
+
+type endpointEditWebhookRequestPayload struct {
+	URL         *string   `json:"url"`
+	Description *string   `json:"description"`
+	StationIDs  *[]string `json:"station_ids"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+// EndpointEditWebhook handles the 'PATCH /v1/webhooks/{id}' endpoint.
+// Re-enabling a webhook resets its consecutive failure counter.
+func (service *Service) EndpointEditWebhook(writer http.ResponseWriter, request *http.Request) {
+	hook, ok := service.fetchAccessibleWebhook(writer, request)
+	if !ok {
+		return
+	}
+
+	payload, validationErrs, err := schema.UnmarshalBody[endpointEditWebhookRequestPayload](request)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	update := &webhook.Update{
+		Enabled: payload.Enabled,
+	}
+	if payload.URL != nil {
+		url := strings.TrimSpace(*payload.URL)
+		if !webhook.IsValidURL(url) {
+			validationErrs = append(validationErrs, errWebhookInvalidURL(url))
+		}
+		update.URL = &url
+	}
+	if payload.StationIDs != nil {
+		stationIDs, stationErrs := validateWebhookStations(*payload.StationIDs)
+		validationErrs = append(validationErrs, stationErrs...)
+		update.StationIDs = stationIDs
+	}
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+	if payload.Description != nil {
+		desc := apikey.SanitizeDescription(*payload.Description)
+		update.Description = &desc
+	}
+	if payload.Enabled != nil && *payload.Enabled && !hook.Enabled {
+		failures := 0
+		update.ConsecutiveFailures = &failures
+	}
+
+	newObj, err := service.Storage.Webhooks().Update(request.Context(), hook.ID, update)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+	service.writer.WriteJSONWithCode(writer, http.StatusOK, newObj)
+}
+
+// EndpointDeleteWebhook handles the 'DELETE /v1/webhooks/{id}' endpoint
+func (service *Service) EndpointDeleteWebhook(writer http.ResponseWriter, request *http.Request) {
+	hook, ok := service.fetchAccessibleWebhook(writer, request)
+	if !ok {
+		return
+	}
+
+	if err := service.Storage.Webhooks().Delete(request.Context(), hook.ID); err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// EndpointGetWebhookAttempts handles the 'GET /v1/webhooks/{id}/attempts?offset={number?:0}&limit={number?:10}' endpoint
+func (service *Service) EndpointGetWebhookAttempts(writer http.ResponseWriter, request *http.Request) {
+	var validationErrs []*schema.Error
+
+	offset, validationErr := schema.QueryNumber(request, "offset", false, 0, 0, math.MaxInt64)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	limit, validationErr := schema.QueryNumber(request, "limit", false, 10, 1, 1000)
+	if validationErr != nil {
+		validationErrs = append(validationErrs, validationErr)
+	}
+
+	if len(validationErrs) > 0 {
+		service.writer.WriteErrors(writer, http.StatusBadRequest, validationErrs...)
+		return
+	}
+
+	hook, ok := service.fetchAccessibleWebhook(writer, request)
+	if !ok {
+		return
+	}
+
+	attempts, n, err := service.Storage.Webhooks().GetAttempts(request.Context(), hook.ID, uint64(offset), uint64(limit))
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return
+	}
+
+	service.writer.WriteJSON(writer, schema.BuildPaginatedResponse(uint64(offset), uint64(limit), n, attempts))
+}
+
+// fetchAccessibleWebhook retrieves the webhook addressed by the 'id' URL parameter and makes sure the client may
+// access it. If not, an error is written and ok is false.
+func (service *Service) fetchAccessibleWebhook(writer http.ResponseWriter, request *http.Request) (*webhook.Webhook, bool) {
+	client := request.Context().Value(contextValueUser).(*user.User)
+
+	id := chi.URLParam(request, "id")
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		if client.Admin {
+			service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		} else {
+			service.writer.WriteErrors(writer, http.StatusForbidden, schema.ErrForbidden)
+		}
+		return nil, false
+	}
+
+	obj, err := service.Storage.Webhooks().GetByID(request.Context(), uid)
+	if err != nil {
+		service.writer.WriteInternalError(writer, err)
+		return nil, false
+	}
+
+	if !client.Admin && (obj == nil || obj.UserID != client.ID) {
+		service.writer.WriteErrors(writer, http.StatusForbidden, schema.ErrForbidden)
+		return nil, false
+	}
+
+	if obj == nil {
+		service.writer.WriteErrors(writer, http.StatusNotFound, schema.ErrNotFound)
+		return nil, false
+	}
+
+	return obj, true
+}
+
+// validateWebhookStations normalizes the station IDs a webhook should be bound to, skipping duplicates
+func validateWebhookStations(raw []string) ([]string, []*schema.Error) {
+	if len(raw) == 0 || len(raw) > webhook.MaxStations {
+		return nil, []*schema.Error{errWebhookInvalidStations(len(raw), webhook.MaxStations)}
+	}
+
+	var validationErrs []*schema.Error
+	stationIDs := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, stationID := range raw {
+		stationID = strings.ToUpper(strings.TrimSpace(stationID))
+		if _, ok := seen[stationID]; ok {
+			continue
+		}
+		if !station.IsValidICAO(stationID) {
+			validationErrs = append(validationErrs, errWebhookInvalidStationID(stationID))
+			continue
+		}
+		seen[stationID] = struct{}{}
+		stationIDs = append(stationIDs, stationID)
+	}
+	return stationIDs, validationErrs
+}
@@ -0,0 +1,135 @@
+package schema
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Format represents a response format of the API
+type Format string
+
+const (
+	// FormatJSON is the default response format
+	FormatJSON Format = "json"
+
+	// FormatNDJSON writes every entry of a collection as a single JSON object per line
+	FormatNDJSON Format = "ndjson"
+
+	// FormatCSV writes every entry of a collection as a single CSV record, preceded by a header record.
+	// Entries have to implement CSVRecord.
+	FormatCSV Format = "csv"
+
+	// FormatXML writes the entries of a collection inside a 'response' document similar to the layout of the aviation
+	// weather data server. Entries have to implement xml.Marshaler.
+	FormatXML Format = "xml"
+
+	// FormatGeoJSON writes the entries of a collection as a GeoJSON feature collection.
+	// Entries have to implement GeoFeature; endpoints have to opt in using GeoFormats.
+	FormatGeoJSON Format = "geojson"
+)
+
+// Formats contains the response formats supported by every data endpoint
+var Formats = []Format{FormatJSON, FormatNDJSON, FormatCSV, FormatXML}
+
+// GeoFormats contains the response formats supported by endpoints whose entries have a location
+var GeoFormats = []Format{FormatJSON, FormatNDJSON, FormatCSV, FormatXML, FormatGeoJSON}
+
+// ContentType returns the MIME type responses of the format are sent with
+func (format Format) ContentType() string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXML:
+		return "application/xml; charset=utf-8"
+	case FormatGeoJSON:
+		return "application/geo+json"
+	}
+	return "application/json"
+}
+
+// mediaTypeFormats maps the accepted media types to the response format they select
+var mediaTypeFormats = map[string]Format{
+	"application/json":     FormatJSON,
+	"application/*":        FormatJSON,
+	"*/*":                  FormatJSON,
+	"application/x-ndjson": FormatNDJSON,
+	"application/ndjson":   FormatNDJSON,
+	"application/jsonl":    FormatNDJSON,
+	"text/csv":             FormatCSV,
+	"application/xml":      FormatXML,
+	"text/xml":             FormatXML,
+	"application/geo+json": FormatGeoJSON,
+}
+
+// CSVRecord is implemented by entries that can be written using FormatCSV
+type CSVRecord interface {
+	// CSVHeader returns the column names of the record; it must not depend on the fields of the receiver, as it is also
+	// called on zero values to write the header of empty collections
+	CSVHeader() []string
+
+	// CSVRecord returns the values of the record in the order of CSVHeader
+	CSVRecord() []string
+}
+
+// Collection is implemented by response bodies that wrap a list of entries.
+// All formats except FormatJSON only write the entries of a collection.
+type Collection interface {
+	Entries() []any
+}
+
+// NegotiateFormat determines the response format of the given request out of the given supported formats (Formats if
+// none are given). The 'format' query parameter takes precedence over the 'Accept' header; FormatJSON is used if
+// neither selects a supported format.
+func NegotiateFormat(request *http.Request, supported ...Format) (Format, *Error) {
+	if len(supported) == 0 {
+		supported = Formats
+	}
+	isSupported := func(format Format) bool {
+		for _, candidate := range supported {
+			if candidate == format {
+				return true
+			}
+		}
+		return false
+	}
+
+	if raw := strings.ToLower(strings.TrimSpace(request.URL.Query().Get("format"))); raw != "" {
+		allowed := make([]string, 0, len(supported))
+		for _, format := range supported {
+			if raw == string(format) {
+				return format, nil
+			}
+			allowed = append(allowed, string(format))
+		}
+		return FormatJSON, errQueryParameterInvalidValue("format", raw, allowed)
+	}
+
+	// Pick the supported media range with the highest quality; earlier ones win ties
+	best := FormatJSON
+	bestQuality := 0.0
+	for _, accepted := range strings.Split(request.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaTypeFormats[mediaType]
+		if !ok || !isSupported(format) {
+			continue
+		}
+		quality := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality > bestQuality {
+			best = format
+			bestQuality = quality
+		}
+	}
+	return best, nil
+}
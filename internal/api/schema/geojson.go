@@ -0,0 +1,51 @@
+package schema
+
+// GeoFeature is implemented by entries that can be written using FormatGeoJSON
+type GeoFeature interface {
+	// GeoJSONFeature returns the GeoJSON feature representing the entry
+	GeoJSONFeature() *Feature
+}
+
+// Feature represents a GeoJSON feature
+type Feature struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+
+	// Geometry is nil if the location of the entry is unknown
+	Geometry   *Geometry `json:"geometry"`
+	Properties any       `json:"properties"`
+}
+
+// Geometry represents a GeoJSON geometry object
+type Geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// FeatureCollection represents a GeoJSON feature collection.
+// The pagination metadata of paginated responses is included as a foreign member.
+type FeatureCollection struct {
+	Type       string              `json:"type"`
+	Pagination *PaginationMetadata `json:"pagination,omitempty"`
+	Features   []*Feature          `json:"features"`
+}
+
+// NewFeature creates a new GeoJSON feature without a geometry
+func NewFeature(id string, properties any) *Feature {
+	return &Feature{
+		Type:       "Feature",
+		ID:         id,
+		Geometry:   nil,
+		Properties: properties,
+	}
+}
+
+// NewPointFeature creates a new GeoJSON feature whose geometry is the given point
+func NewPointFeature(id string, latitude, longitude float64, properties any) *Feature {
+	feature := NewFeature(id, properties)
+	feature.Geometry = &Geometry{
+		Type:        "Point",
+		Coordinates: []float64{longitude, latitude},
+	}
+	return feature
+}
@@ -1,5 +1,7 @@
 package schema
 
+import "reflect"
+
 // PaginatedResponse represents a unified paginated API response
 type PaginatedResponse[T any] struct {
 	Pagination *PaginationMetadata `json:"pagination"`
@@ -8,10 +10,47 @@ type PaginatedResponse[T any] struct {
 
 // PaginationMetadata represents the metadata present in a PaginatedResponse
 type PaginationMetadata struct {
-	Offset        uint64 `json:"offset"`
-	Limit         uint64 `json:"limit"`
-	TotalCount    uint64 `json:"total_count"`
-	IncludedCount int    `json:"included_count"`
+	Offset uint64 `json:"offset" xml:"offset,attr"`
+	Limit  uint64 `json:"limit" xml:"limit,attr"`
+
+	// TotalCount is nil if counting the total amount of entries was skipped
+	TotalCount    *uint64 `json:"total_count,omitempty" xml:"total_count,attr,omitempty"`
+	IncludedCount int     `json:"included_count" xml:"included_count,attr"`
+
+	// NextCursor is the opaque token to pass in order to retrieve the next page of a cursor-based paginated response.
+	// It is empty if there are no more entries.
+	NextCursor string `json:"next_cursor,omitempty" xml:"next_cursor,attr,omitempty"`
+}
+
+// paginatedCollection is implemented by PaginatedResponse in order to expose its metadata to the non-JSON formats
+type paginatedCollection interface {
+	metadata() *PaginationMetadata
+}
+
+// entryTemplate is implemented by PaginatedResponse in order to write the CSV header of empty pages
+type entryTemplate interface {
+	emptyEntry() any
+}
+
+// Entries returns the entries of the page (see Collection)
+func (response *PaginatedResponse[T]) Entries() []any {
+	entries := make([]any, 0, len(response.Data))
+	for _, entry := range response.Data {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (response *PaginatedResponse[T]) metadata() *PaginationMetadata {
+	return response.Pagination
+}
+
+func (response *PaginatedResponse[T]) emptyEntry() any {
+	var empty T
+	if typ := reflect.TypeOf(empty); typ != nil && typ.Kind() == reflect.Pointer {
+		return reflect.New(typ.Elem()).Interface()
+	}
+	return empty
 }
 
 // BuildPaginatedResponse builds a unified paginated API response
@@ -19,9 +58,23 @@ func BuildPaginatedResponse[T any](offset, limit, totalCount uint64, data []T) *
 	return &PaginatedResponse[T]{
 		Pagination: &PaginationMetadata{
 			Offset:        offset,
+			Limit:         limit,
+			TotalCount:    &totalCount,
+			IncludedCount: len(data),
+		},
+		Data: data,
+	}
+}
+
+// BuildCursorPaginatedResponse builds a unified cursor-based paginated API response.
+// totalCount may be nil if counting the total amount of entries was skipped.
+func BuildCursorPaginatedResponse[T any](limit uint64, totalCount *uint64, nextCursor string, data []T) *PaginatedResponse[T] {
+	return &PaginatedResponse[T]{
+		Pagination: &PaginationMetadata{
 			Limit:         limit,
 			TotalCount:    totalCount,
 			IncludedCount: len(data),
+			NextCursor:    nextCursor,
 		},
 		Data: data,
 	}
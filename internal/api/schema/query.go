@@ -2,8 +2,10 @@ package schema
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 var (
@@ -27,6 +29,36 @@ var (
 			},
 		}
 	}
+	errQueryParameterInvalidValue = func(name, value string, allowed []string) *Error {
+		return &Error{
+			Type:    "validation.query.parameter.invalidValue",
+			Message: fmt.Sprintf("The query parameter '%s' ('%s') is not one of the allowed values (%s).", name, value, strings.Join(allowed, ", ")),
+			Details: map[string]any{
+				"parameter": name,
+				"value":     value,
+				"allowed":   allowed,
+			},
+		}
+	}
+	errQueryParameterFloatOutOfRange = func(name string, value, min, max float64) *Error {
+		comparison := ""
+		if value < min {
+			comparison = fmt.Sprintf("%g [given] < %g [min]", value, min)
+		} else if value > max {
+			comparison = fmt.Sprintf("%g [given] > %g [max]", value, max)
+		}
+
+		return &Error{
+			Type:    "validation.query.parameter.number.outOfRange",
+			Message: fmt.Sprintf("The query parameter '%s' is out of the required range (%s).", name, comparison),
+			Details: map[string]any{
+				"parameter": name,
+				"value":     value,
+				"min":       min,
+				"max":       max,
+			},
+		}
+	}
 	errQueryParameterNumberOutOfRange = func(name string, value, min, max int64) *Error {
 		comparison := ""
 		if value < min {
@@ -48,6 +80,18 @@ var (
 	}
 )
 
+// QueryString extracts a trimmed string value out of the query parameters of the given request
+func QueryString(request *http.Request, key string, required bool, def string) (string, *Error) {
+	value := strings.TrimSpace(request.URL.Query().Get(key))
+	if value == "" {
+		if required {
+			return "", errQueryParameterMissing(key)
+		}
+		return def, nil
+	}
+	return value, nil
+}
+
 // QueryNumber extracts and validates an integer value out of the query parameters of the given request
 func QueryNumber(request *http.Request, key string, required bool, def, min, max int64) (int64, *Error) {
 	// Extract the raw string value
@@ -72,3 +116,84 @@ func QueryNumber(request *http.Request, key string, required bool, def, min, max
 
 	return parsed, nil
 }
+
+// QueryFloat extracts and validates a floating-point value out of the query parameters of the given request
+func QueryFloat(request *http.Request, key string, required bool, def, min, max float64) (float64, *Error) {
+	// Extract the raw string value
+	value := request.URL.Query().Get(key)
+	if value == "" {
+		if required {
+			return 0, errQueryParameterMissing(key)
+		}
+		return def, nil
+	}
+
+	// Try to parse the value
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return 0, errQueryParameterInvalidType(key, value, "float")
+	}
+
+	// Check if the parsed value is in the required range
+	if parsed < min || parsed > max {
+		return 0, errQueryParameterFloatOutOfRange(key, parsed, min, max)
+	}
+
+	return parsed, nil
+}
+
+// QueryBool extracts and validates a boolean value out of the query parameters of the given request
+func QueryBool(request *http.Request, key string, required, def bool) (bool, *Error) {
+	// Extract the raw string value
+	value := request.URL.Query().Get(key)
+	if value == "" {
+		if required {
+			return false, errQueryParameterMissing(key)
+		}
+		return def, nil
+	}
+
+	// Try to parse the value
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errQueryParameterInvalidType(key, value, "boolean")
+	}
+
+	return parsed, nil
+}
+
+// QueryOptionalBool extracts and validates an optional boolean value out of the query parameters of the given request.
+// The returned value is nil if the parameter is not present.
+func QueryOptionalBool(request *http.Request, key string) (*bool, *Error) {
+	if request.URL.Query().Get(key) == "" {
+		return nil, nil
+	}
+	value, err := QueryBool(request, key, true, false)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// QueryEnum extracts and validates a string value that has to be one of the given allowed values (case-insensitive)
+// out of the query parameters of the given request.
+// The returned value is always written exactly like the matching allowed value.
+func QueryEnum(request *http.Request, key string, required bool, def string, allowed ...string) (string, *Error) {
+	// Extract the raw string value
+	value := strings.TrimSpace(request.URL.Query().Get(key))
+	if value == "" {
+		if required {
+			return "", errQueryParameterMissing(key)
+		}
+		return def, nil
+	}
+
+	// Check if the value is one of the allowed ones
+	for _, candidate := range allowed {
+		if strings.EqualFold(value, candidate) {
+			return candidate, nil
+		}
+	}
+
+	return "", errQueryParameterInvalidValue(key, value, allowed)
+}
@@ -1,8 +1,14 @@
 package schema
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
 )
 
 // Writer helps writing unified API responses
@@ -24,6 +30,147 @@ func (writer *Writer) WriteJSON(rw http.ResponseWriter, value any) {
 	writer.WriteJSONWithCode(rw, http.StatusOK, value)
 }
 
+// xmlResponse represents the document written by FormatXML
+type xmlResponse struct {
+	XMLName    xml.Name            `xml:"response"`
+	Pagination *PaginationMetadata `xml:"pagination,omitempty"`
+	Data       *xmlData            `xml:"data"`
+}
+
+type xmlData struct {
+	NumResults int   `xml:"num_results,attr"`
+	Entries    []any `xml:"entry"`
+}
+
+// WriteFormatted writes value using the given response format (see Format).
+// Collections (see Collection) are written entry by entry by all formats except FormatJSON, in which case pagination
+// metadata is sent using the 'X-Pagination-*' headers; other non-nil values are written as a single entry.
+func (writer *Writer) WriteFormatted(rw http.ResponseWriter, format Format, value any) {
+	if format == FormatJSON {
+		writer.WriteJSON(rw, value)
+		return
+	}
+
+	var entries []any
+	var empty any
+	if collection, ok := value.(Collection); ok {
+		entries = collection.Entries()
+		if template, ok := value.(entryTemplate); ok {
+			empty = template.emptyEntry()
+		}
+	} else if !isNil(value) {
+		entries = []any{value}
+	} else {
+		entries = []any{}
+	}
+	var pagination *PaginationMetadata
+	if paginated, ok := value.(paginatedCollection); ok {
+		pagination = paginated.metadata()
+	}
+
+	var body bytes.Buffer
+	switch format {
+	case FormatNDJSON:
+		encoder := json.NewEncoder(&body)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				writer.WriteInternalError(rw, err)
+				return
+			}
+		}
+	case FormatCSV:
+		if err := writeCSV(&body, entries, empty); err != nil {
+			writer.WriteInternalError(rw, err)
+			return
+		}
+	case FormatGeoJSON:
+		collection := &FeatureCollection{
+			Type:       "FeatureCollection",
+			Pagination: pagination,
+			Features:   make([]*Feature, 0, len(entries)),
+		}
+		for _, entry := range entries {
+			feature, ok := entry.(GeoFeature)
+			if !ok {
+				writer.WriteInternalError(rw, fmt.Errorf("%T does not support the GeoJSON format", entry))
+				return
+			}
+			collection.Features = append(collection.Features, feature.GeoJSONFeature())
+		}
+		if err := json.NewEncoder(&body).Encode(collection); err != nil {
+			writer.WriteInternalError(rw, err)
+			return
+		}
+	case FormatXML:
+		body.WriteString(xml.Header)
+		err := xml.NewEncoder(&body).Encode(&xmlResponse{
+			Pagination: pagination,
+			Data: &xmlData{
+				NumResults: len(entries),
+				Entries:    entries,
+			},
+		})
+		if err != nil {
+			writer.WriteInternalError(rw, err)
+			return
+		}
+	}
+
+	if pagination != nil {
+		rw.Header().Set("X-Pagination-Offset", strconv.FormatUint(pagination.Offset, 10))
+		rw.Header().Set("X-Pagination-Limit", strconv.FormatUint(pagination.Limit, 10))
+		rw.Header().Set("X-Pagination-Included-Count", strconv.Itoa(pagination.IncludedCount))
+		if pagination.TotalCount != nil {
+			rw.Header().Set("X-Pagination-Total-Count", strconv.FormatUint(*pagination.TotalCount, 10))
+		}
+		if pagination.NextCursor != "" {
+			rw.Header().Set("X-Pagination-Next-Cursor", pagination.NextCursor)
+		}
+	}
+	rw.Header().Set("Content-Type", format.ContentType())
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(body.Bytes())
+}
+
+// writeCSV writes the given entries as CSV records; empty is used to write the header if there are no entries
+func writeCSV(buffer *bytes.Buffer, entries []any, empty any) error {
+	if len(entries) > 0 {
+		empty = entries[0]
+	}
+	first, ok := empty.(CSVRecord)
+	if !ok {
+		if empty == nil {
+			return nil
+		}
+		return fmt.Errorf("%T does not support the CSV format", empty)
+	}
+
+	csvWriter := csv.NewWriter(buffer)
+	if err := csvWriter.Write(first.CSVHeader()); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		record, ok := entry.(CSVRecord)
+		if !ok {
+			return fmt.Errorf("%T does not support the CSV format", entry)
+		}
+		if err := csvWriter.Write(record.CSVRecord()); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// isNil returns whether value is nil or a nil pointer
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	ref := reflect.ValueOf(value)
+	return ref.Kind() == reflect.Pointer && ref.IsNil()
+}
+
 // WriteErrors sends an error response
 func (writer *Writer) WriteErrors(rw http.ResponseWriter, code int, errors ...*Error) {
 	if errors == nil {
@@ -5,4 +5,7 @@ import "github.com/skybi/pluteo/internal/bitflag"
 const (
 	CapabilityReadMETARs bitflag.Flag = 1 << iota
 	CapabilityFeedMETARs
+	CapabilityReadTAFs
+	CapabilityFeedTAFs
+	CapabilityReadStations
 )
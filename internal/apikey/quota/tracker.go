@@ -33,7 +33,12 @@ func (tracker *Tracker) Get(key *apikey.Key) int64 {
 
 // Accumulate accumulates the used API quota of a specific API key by 1
 func (tracker *Tracker) Accumulate(key *apikey.Key) {
-	tracker.usedQuotas.Set(key.ID, tracker.Get(key)+1)
+	tracker.AccumulateBy(key, 1)
+}
+
+// AccumulateBy accumulates the used API quota of a specific API key by the given amount
+func (tracker *Tracker) AccumulateBy(key *apikey.Key, amount int64) {
+	tracker.usedQuotas.Set(key.ID, tracker.Get(key)+amount)
 }
 
 // Flush sends all changed API quota to the database and resets the counters
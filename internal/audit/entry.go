@@ -0,0 +1,35 @@
+package audit
+
+import "github.com/google/uuid"
+
+// Action represents an administrative action that is recorded in the audit trail
+type Action string
+
+const (
+	// ActionMETARDelete represents the deletion of a single METAR
+	ActionMETARDelete Action = "metar.delete"
+
+	// ActionMETARBulkDelete represents the deletion of all METARs following a filter
+	ActionMETARBulkDelete Action = "metar.bulk_delete"
+
+	// ActionMETARReparse represents re-parsing the raw text of all METARs following a filter
+	ActionMETARReparse Action = "metar.reparse"
+)
+
+// Entry represents a single entry of the audit trail
+type Entry struct {
+	ID     uuid.UUID `json:"id"`
+	UserID string    `json:"user_id"`
+	Action Action    `json:"action"`
+
+	// Target is the ID of the object the action was performed on; it is nil for actions affecting multiple objects
+	Target *string `json:"target"`
+
+	// Parameters contains the parameters the action was performed with (e.g. the filter of a bulk deletion)
+	Parameters map[string]any `json:"parameters"`
+
+	// Affected is the amount of objects affected by the action
+	Affected int64 `json:"affected"`
+
+	CreatedAt int64 `json:"created_at"`
+}
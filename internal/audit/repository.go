@@ -0,0 +1,25 @@
+package audit
+
+import "context"
+
+// Repository defines the audit trail repository API
+type Repository interface {
+	// Get retrieves multiple audit trail entries, ordered by their creation date (descending)
+	Get(ctx context.Context, offset, limit uint64) ([]*Entry, uint64, error)
+
+	// GetByAction retrieves multiple audit trail entries of a specific action, ordered by their creation date
+	// (descending)
+	GetByAction(ctx context.Context, action Action, offset, limit uint64) ([]*Entry, uint64, error)
+
+	// Create appends a new entry to the audit trail
+	Create(ctx context.Context, create *Create) (*Entry, error)
+}
+
+// Create is used to append a new entry to the audit trail
+type Create struct {
+	UserID     string
+	Action     Action
+	Target     *string
+	Parameters map[string]any
+	Affected   int64
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"strings"
+	"time"
 )
 
 // Config represents the application configuration structure
@@ -12,6 +13,10 @@ type Config struct {
 
 	PostgresDSN string `split_words:"true"`
 
+	METARPartitionsAhead int           `default:"3" split_words:"true"`
+	METARRetention       time.Duration `default:"0" split_words:"true"`
+	METARRetentionMode   string        `default:"detach" split_words:"true"`
+
 	PortalAPIListenAddress string `default:":8081" split_words:"true"`
 	PortalAPIBaseAddress   string `default:"http://localhost:8081" split_words:"true"`
 	PortalAPIAllowedOrigin string `default:"http://localhost:3000" split_words:"true"`
@@ -20,7 +25,20 @@ type Config struct {
 	OIDCClientID     string `split_words:"true"`
 	OIDCClientSecret string `split_words:"true"`
 
-	DataAPIListenAddress string `default:":8082" split_words:"true"`
+	DataAPIListenAddress           string        `default:":8082" split_words:"true"`
+	DataAPIMaxWebsocketConnections int           `default:"5" split_words:"true"`
+	DataAPIWebsocketAllowedOrigins []string      `split_words:"true"`
+	DataAPIExportDirectory         string        `default:"./exports" split_words:"true"`
+	DataAPIExportWorkers           int           `default:"2" split_words:"true"`
+	DataAPIExportTTL               time.Duration `default:"24h" split_words:"true"`
+	DataAPIExportMaxRows           int64         `default:"1000000" split_words:"true"`
+
+	StationsFile string `split_words:"true"`
+
+	IngestSourcesFile string `split_words:"true"`
+
+	CoverageRefreshInterval time.Duration `default:"15m" split_words:"true"`
+	CoverageRefreshDays     int           `default:"2" split_words:"true"`
 }
 
 // LoadFromEnv loads a new configuration structure using environment variables and an optional .env file
@@ -0,0 +1,44 @@
+package coverage
+
+import "time"
+
+// WindowDays defines how many of the latest days (including the current one) the statistics are kept available for;
+// they are backfilled for the whole window at startup
+const WindowDays = 90
+
+// Day contains the coverage and latency statistics of the METARs of a single station issued on a single day (UTC)
+type Day struct {
+	StationID string `json:"station_id"`
+
+	// Day is the unix timestamp of the start of the day (00:00 UTC)
+	Day int64 `json:"day"`
+
+	// Reports is the amount of stored METARs (including superseded ones) issued on the day
+	Reports       int64 `json:"reports"`
+	FirstIssuedAt int64 `json:"first_issued_at"`
+	LastIssuedAt  int64 `json:"last_issued_at"`
+
+	// LargestGap is the largest amount of seconds between the issuing times of two consecutive METARs, where the later
+	// one was issued on the day. It is nil if no preceding METAR is known for any METAR of the day.
+	LargestGap *int64 `json:"largest_gap"`
+
+	// LatencyP50, LatencyP90 and LatencyP99 are percentiles of the amount of seconds between the issuing and receiving
+	// time of the METARs of the day. They are nil if no METAR of the day has a known receiving time.
+	LatencyP50 *int64 `json:"latency_p50"`
+	LatencyP90 *int64 `json:"latency_p90"`
+	LatencyP99 *int64 `json:"latency_p99"`
+
+	// RefreshedAt is the time the statistics were computed at
+	RefreshedAt int64 `json:"refreshed_at"`
+}
+
+// SilentStation represents a station that did not issue any METARs for a while
+type SilentStation struct {
+	StationID    string `json:"station_id"`
+	LastIssuedAt int64  `json:"last_issued_at"`
+}
+
+// StartOfDay returns the unix timestamp of the start of the day (UTC) containing the given unix timestamp
+func StartOfDay(timestamp int64) int64 {
+	return time.Unix(timestamp, 0).UTC().Truncate(24 * time.Hour).Unix()
+}
@@ -0,0 +1,19 @@
+package coverage
+
+import "context"
+
+// Repository defines the coverage rollup repository API
+type Repository interface {
+	// GetByStation retrieves the statistics of a station for all days between from and to (both inclusive unix
+	// timestamps), ordered by the day (ascending)
+	GetByStation(ctx context.Context, stationID string, from, to int64) ([]*Day, error)
+
+	// GetSilent retrieves all stations whose latest METAR was issued after activeSince but not after silentSince,
+	// ordered by the issuing time of their latest METAR (ascending).
+	// Only the stored statistics are considered; METARs stored after the latest refresh are thus not taken into account.
+	GetSilent(ctx context.Context, silentSince, activeSince int64) ([]*SilentStation, error)
+
+	// Refresh recomputes the statistics of all days starting with the day containing the given unix timestamp.
+	// It returns the amount of refreshed station days.
+	Refresh(ctx context.Context, since int64) (int64, error)
+}
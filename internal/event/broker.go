@@ -0,0 +1,90 @@
+package event
+
+import "sync"
+
+// Broker distributes published events to all of its subscribers.
+// Publishing never blocks: subscribers that do not keep up with the published events are unsubscribed automatically.
+type Broker[T any] struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription[T]]struct{}
+}
+
+// NewBroker creates a new event broker without any subscribers
+func NewBroker[T any]() *Broker[T] {
+	return &Broker[T]{
+		subscribers: make(map[*Subscription[T]]struct{}),
+	}
+}
+
+// Subscribe creates a new subscription that buffers up to the given amount of events
+func (broker *Broker[T]) Subscribe(buffer int) *Subscription[T] {
+	sub := &Subscription[T]{
+		broker: broker,
+		events: make(chan T, buffer),
+	}
+	broker.mu.Lock()
+	broker.subscribers[sub] = struct{}{}
+	broker.mu.Unlock()
+	return sub
+}
+
+// Publish publishes an event to all subscribers
+func (broker *Broker[T]) Publish(event T) {
+	var lagging []*Subscription[T]
+
+	broker.mu.RLock()
+	for sub := range broker.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			lagging = append(lagging, sub)
+		}
+	}
+	broker.mu.RUnlock()
+
+	for _, sub := range lagging {
+		sub.cancel(true)
+	}
+}
+
+// Subscribers returns the current amount of subscribers
+func (broker *Broker[T]) Subscribers() int {
+	broker.mu.RLock()
+	defer broker.mu.RUnlock()
+	return len(broker.subscribers)
+}
+
+// Subscription represents a single subscription to an event broker
+type Subscription[T any] struct {
+	broker *Broker[T]
+	events chan T
+	once   sync.Once
+	lagged bool
+}
+
+// Events returns the channel the events are delivered to.
+// It is closed as soon as the subscription was cancelled.
+func (sub *Subscription[T]) Events() <-chan T {
+	return sub.events
+}
+
+// Lagged returns whether the subscription was cancelled because its buffer was full.
+// It must only be called after the events channel was closed.
+func (sub *Subscription[T]) Lagged() bool {
+	return sub.lagged
+}
+
+// Unsubscribe cancels the subscription and closes its events channel
+func (sub *Subscription[T]) Unsubscribe() {
+	sub.cancel(false)
+}
+
+func (sub *Subscription[T]) cancel(lagged bool) {
+	sub.once.Do(func() {
+		sub.lagged = lagged
+		sub.broker.mu.Lock()
+		delete(sub.broker.subscribers, sub)
+		close(sub.events)
+		sub.broker.mu.Unlock()
+	})
+}
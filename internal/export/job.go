@@ -0,0 +1,112 @@
+package export
+
+import (
+	"github.com/google/uuid"
+)
+
+// Format represents the file format of an export
+type Format string
+
+const (
+	// FormatCSV exports one METAR per CSV row, using the CSV representation of the data API
+	FormatCSV Format = "csv"
+
+	// FormatNDJSON exports one METAR per line, using the JSON representation of the data API
+	FormatNDJSON Format = "ndjson"
+
+	// FormatColumnar exports the METARs in a Parquet-like columnar layout: every line is a JSON encoded row group
+	// containing the typed values of every column
+	FormatColumnar Format = "columnar"
+)
+
+// Formats contains all supported export formats
+var Formats = []Format{FormatCSV, FormatNDJSON, FormatColumnar}
+
+// IsValid returns whether the format is a supported export format
+func (format Format) IsValid() bool {
+	for _, supported := range Formats {
+		if format == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Extension returns the file extension of an uncompressed export of this format
+func (format Format) Extension() string {
+	switch format {
+	case FormatColumnar:
+		return "columnar.ndjson"
+	default:
+		return string(format)
+	}
+}
+
+// Status represents the processing status of an export job
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// IsActive returns whether a job of this status is still waiting to be processed or being processed
+func (status Status) IsActive() bool {
+	return status == StatusPending || status == StatusRunning
+}
+
+// Filter defines which METARs are exported
+type Filter struct {
+	// StationIDs restricts the export to the given stations; all stations are exported if it is empty
+	StationIDs []string `json:"stations"`
+
+	// From and To define the range of issuing times (both inclusive) of the exported METARs
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+
+	// IncludeSuperseded defines whether METARs superseded by a correction should be exported
+	IncludeSuperseded bool `json:"include_superseded"`
+}
+
+// Job represents an asynchronous METAR export of a single API key.
+// Exported METARs are ordered by their issuing date and ID (descending), just like the results of the METAR endpoint.
+type Job struct {
+	ID     uuid.UUID `json:"id"`
+	KeyID  uuid.UUID `json:"key_id"`
+	Filter *Filter   `json:"filter"`
+	Format Format    `json:"format"`
+	Status Status    `json:"status"`
+
+	// Rows is the amount of METARs exported so far; every exported METAR consumes one unit of the API key's quota
+	Rows int64 `json:"rows"`
+
+	// Truncated is set if the export stopped early because the row limit or the API key's quota was reached
+	Truncated bool `json:"truncated"`
+
+	// Size is the size of the compressed result file in bytes; it is set as soon as the job is completed
+	Size int64 `json:"size"`
+
+	// Error describes why the job failed
+	Error string `json:"error,omitempty"`
+
+	CreatedAt  int64  `json:"created_at"`
+	StartedAt  *int64 `json:"started_at"`
+	FinishedAt *int64 `json:"finished_at"`
+
+	// ExpiresAt is the time the job and its result are deleted at; it is set as soon as the job is finished
+	ExpiresAt *int64 `json:"expires_at"`
+}
+
+// FileName returns the name of the gzip compressed result file of the job
+func (job *Job) FileName() string {
+	return "export-" + job.ID.String() + "." + job.Format.Extension() + ".gz"
+}
+
+// copy creates a snapshot of the job that is safe to use without holding the manager's lock
+func (job *Job) copy() *Job {
+	snapshot := *job
+	return &snapshot
+}
@@ -0,0 +1,433 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/apikey/quota"
+	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/task"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTooManyActiveJobs is returned by Manager.Submit if the API key already has the maximum amount of active jobs
+	ErrTooManyActiveJobs = errors.New("too many active export jobs")
+
+	// ErrQueueFull is returned by Manager.Submit if the job queue is full
+	ErrQueueFull = errors.New("export job queue is full")
+
+	// ErrNotRunning is returned by Manager.Submit if the manager is not running
+	ErrNotRunning = errors.New("export manager is not running")
+)
+
+// entry holds a job together with the function cancelling its processing
+type entry struct {
+	job    *Job
+	cancel context.CancelFunc
+}
+
+// Manager processes export jobs on a pool of workers and writes their gzip compressed results to a local directory.
+// Jobs are held in memory only; the result files of a previous run are deleted on start.
+type Manager struct {
+	// Directory is the directory the result files are written to
+	Directory string
+
+	// Workers defines how many jobs are processed concurrently
+	Workers int
+
+	// TTL defines how long finished jobs and their results are kept
+	TTL time.Duration
+
+	// MaxRows defines how many METARs a single job exports at most
+	MaxRows int64
+
+	// MaxActiveJobsPerKey defines how many pending or running jobs a single API key may have
+	MaxActiveJobsPerKey int
+
+	// QueueSize defines how many jobs may wait to be processed
+	QueueSize int
+
+	// PageSize defines how many METARs are fetched from the database at once
+	PageSize uint64
+
+	metars metar.Repository
+	keys   apikey.Repository
+	quota  *quota.Tracker
+
+	mu      sync.Mutex
+	jobs    map[uuid.UUID]*entry
+	queue   chan *entry
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+	cleaner *task.RepeatingTask
+}
+
+// NewManager creates a new export manager with default settings writing to the given directory.
+// Every exported METAR consumes one unit of the quota of the API key the job was submitted by.
+func NewManager(metars metar.Repository, keys apikey.Repository, quotaTracker *quota.Tracker, directory string) *Manager {
+	return &Manager{
+		Directory:           directory,
+		Workers:             2,
+		TTL:                 24 * time.Hour,
+		MaxRows:             1000000,
+		MaxActiveJobsPerKey: 3,
+		QueueSize:           256,
+		PageSize:            1000,
+		metars:              metars,
+		keys:                keys,
+		quota:               quotaTracker,
+		jobs:                make(map[uuid.UUID]*entry),
+	}
+}
+
+// Start prepares the result directory and starts the workers and the task deleting expired jobs
+func (manager *Manager) Start() error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if manager.queue != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(manager.Directory, 0o750); err != nil {
+		return err
+	}
+	stale, err := filepath.Glob(filepath.Join(manager.Directory, "export-*"))
+	if err != nil {
+		return err
+	}
+	for _, file := range stale {
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+
+	manager.queue = make(chan *entry, manager.QueueSize)
+	manager.ctx, manager.cancel = context.WithCancel(context.Background())
+	for i := 0; i < manager.Workers; i++ {
+		manager.workers.Add(1)
+		go manager.work(manager.queue)
+	}
+
+	manager.cleaner = task.NewRepeating(manager.cleanup, time.Minute)
+	manager.cleaner.Start()
+	return nil
+}
+
+// Stop cancels all active jobs and waits for the workers to shut down
+func (manager *Manager) Stop() {
+	manager.mu.Lock()
+	if manager.queue == nil {
+		manager.mu.Unlock()
+		return
+	}
+	close(manager.queue)
+	manager.queue = nil
+	manager.cancel()
+	manager.mu.Unlock()
+
+	manager.workers.Wait()
+	manager.cleaner.Stop(false)
+}
+
+// Submit creates a new job exporting the METARs following the given filter and queues it
+func (manager *Manager) Submit(keyID uuid.UUID, filter *Filter, format Format) (*Job, error) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if manager.queue == nil {
+		return nil, ErrNotRunning
+	}
+
+	active := 0
+	for _, ent := range manager.jobs {
+		if ent.job.KeyID == keyID && ent.job.Status.IsActive() {
+			active++
+		}
+	}
+	if active >= manager.MaxActiveJobsPerKey {
+		return nil, ErrTooManyActiveJobs
+	}
+
+	ent := &entry{
+		job: &Job{
+			ID:        uuid.New(),
+			KeyID:     keyID,
+			Filter:    filter,
+			Format:    format,
+			Status:    StatusPending,
+			CreatedAt: time.Now().Unix(),
+		},
+	}
+	select {
+	case manager.queue <- ent:
+	default:
+		return nil, ErrQueueFull
+	}
+	manager.jobs[ent.job.ID] = ent
+	return ent.job.copy(), nil
+}
+
+// GetByID retrieves a snapshot of a job by its ID
+func (manager *Manager) GetByID(id uuid.UUID) *Job {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	ent, ok := manager.jobs[id]
+	if !ok {
+		return nil
+	}
+	return ent.job.copy()
+}
+
+// GetByKey retrieves snapshots of all jobs of an API key, ordered by their creation date (descending)
+func (manager *Manager) GetByKey(keyID uuid.UUID) []*Job {
+	manager.mu.Lock()
+	jobs := []*Job{}
+	for _, ent := range manager.jobs {
+		if ent.job.KeyID == keyID {
+			jobs = append(jobs, ent.job.copy())
+		}
+	}
+	manager.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].CreatedAt == jobs[j].CreatedAt {
+			return jobs[i].ID.String() > jobs[j].ID.String()
+		}
+		return jobs[i].CreatedAt > jobs[j].CreatedAt
+	})
+	return jobs
+}
+
+// Delete cancels an active job or deletes a finished job together with its result
+func (manager *Manager) Delete(id uuid.UUID) error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	ent, ok := manager.jobs[id]
+	if !ok {
+		return nil
+	}
+
+	switch ent.job.Status {
+	case StatusPending:
+		manager.finish(ent.job, StatusCancelled, "the job was cancelled")
+	case StatusRunning:
+		// The worker marks the job as cancelled and removes the partial result
+		ent.cancel()
+	default:
+		delete(manager.jobs, id)
+		if err := os.Remove(manager.Path(ent.job)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Path returns the path of the result file of a job
+func (manager *Manager) Path(job *Job) string {
+	return filepath.Join(manager.Directory, job.FileName())
+}
+
+// work processes queued jobs until the given queue is closed
+func (manager *Manager) work(queue <-chan *entry) {
+	defer manager.workers.Done()
+	for ent := range queue {
+		manager.mu.Lock()
+		if ent.job.Status != StatusPending {
+			manager.mu.Unlock()
+			continue
+		}
+		if manager.ctx.Err() != nil {
+			manager.finish(ent.job, StatusCancelled, "the export service was shut down")
+			manager.mu.Unlock()
+			continue
+		}
+		ctx, cancel := context.WithCancel(manager.ctx)
+		ent.cancel = cancel
+		now := time.Now().Unix()
+		ent.job.Status = StatusRunning
+		ent.job.StartedAt = &now
+		manager.mu.Unlock()
+
+		manager.process(ctx, ent.job)
+		cancel()
+	}
+}
+
+// process runs a single job and records its outcome
+func (manager *Manager) process(ctx context.Context, job *Job) {
+	path := manager.Path(job)
+	size, reason, err := manager.export(ctx, job, path+".tmp")
+	if err == nil && reason == "" {
+		err = os.Rename(path+".tmp", path)
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	switch {
+	case err == nil && reason == "":
+		job.Size = size
+		manager.finish(job, StatusCompleted, "")
+		return
+	case ctx.Err() != nil:
+		manager.finish(job, StatusCancelled, "the job was cancelled")
+	case err != nil:
+		log.Error().Err(err).Str("job", job.ID.String()).Msg("could not process an export job")
+		manager.finish(job, StatusFailed, "an internal error occurred")
+	default:
+		manager.finish(job, StatusFailed, reason)
+	}
+	os.Remove(path + ".tmp")
+	os.Remove(path)
+}
+
+// export writes the METARs of a job to the given file and charges the API key's quota for every exported METAR.
+// A non-empty reason is returned if the job cannot be completed due to the state of its API key.
+func (manager *Manager) export(ctx context.Context, job *Job, path string) (int64, string, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+	buffered := bufio.NewWriter(file)
+	compressed := gzip.NewWriter(buffered)
+	rows, err := newRowWriter(job.Format, compressed)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// The filter of a job is inclusive while the one of the METAR repository is exclusive; bounds that cannot be made
+	// exclusive without overflowing do not restrict anything and are omitted
+	filter := &metar.Filter{
+		IncludeSuperseded: job.Filter.IncludeSuperseded,
+		SkipCount:         true,
+	}
+	if job.Filter.To < math.MaxInt64 {
+		before := job.Filter.To + 1
+		filter.IssuedBefore = &before
+	}
+	if job.Filter.From > math.MinInt64 {
+		after := job.Filter.From - 1
+		filter.IssuedAfter = &after
+	}
+	if len(job.Filter.StationIDs) > 0 {
+		filter.StationIDs = job.Filter.StationIDs
+	}
+
+	var exported int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, "", err
+		}
+
+		// The API key is refreshed for every page as its quota or capabilities may change while the job is running
+		key, err := manager.keys.GetByID(ctx, job.KeyID)
+		if err != nil {
+			return 0, "", err
+		}
+		if key == nil || !key.Capabilities.Has(apikey.CapabilityReadMETARs) {
+			return 0, "the API key does not exist anymore or lacks the capability to read METARs", nil
+		}
+
+		limit := int64(manager.PageSize)
+		if remaining := manager.MaxRows - exported; remaining < limit {
+			limit = remaining
+		}
+		if key.Quota >= 0 {
+			if remaining := key.Quota - manager.quota.Get(key); remaining < limit {
+				limit = remaining
+			}
+		}
+		if limit <= 0 {
+			if exported == 0 {
+				return 0, "the API key has no quota left", nil
+			}
+			manager.mu.Lock()
+			job.Truncated = true
+			manager.mu.Unlock()
+			break
+		}
+
+		// One more METAR than allowed is fetched to determine whether the export is complete
+		metars, _, err := manager.metars.GetByFilter(ctx, filter, uint64(limit)+1)
+		if err != nil {
+			return 0, "", err
+		}
+		more := int64(len(metars)) > limit
+		if more {
+			metars = metars[:limit]
+		}
+		for _, obj := range metars {
+			if err := rows.Write(obj); err != nil {
+				return 0, "", err
+			}
+		}
+		exported += int64(len(metars))
+		manager.quota.AccumulateBy(key, int64(len(metars)))
+		manager.mu.Lock()
+		job.Rows = exported
+		manager.mu.Unlock()
+
+		if !more {
+			break
+		}
+		if limit < int64(manager.PageSize) {
+			manager.mu.Lock()
+			job.Truncated = true
+			manager.mu.Unlock()
+			break
+		}
+		filter.Cursor = metar.CursorOf(metars[len(metars)-1])
+	}
+
+	if err := rows.Flush(); err != nil {
+		return 0, "", err
+	}
+	if err := compressed.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := buffered.Flush(); err != nil {
+		return 0, "", err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+	return info.Size(), "", file.Close()
+}
+
+// finish marks a job as finished; the manager has to be locked
+func (manager *Manager) finish(job *Job, status Status, reason string) {
+	now := time.Now()
+	finishedAt := now.Unix()
+	expiresAt := now.Add(manager.TTL).Unix()
+	job.Status = status
+	job.Error = reason
+	job.FinishedAt = &finishedAt
+	job.ExpiresAt = &expiresAt
+}
+
+// cleanup deletes all expired jobs together with their results
+func (manager *Manager) cleanup() {
+	now := time.Now().Unix()
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	for id, ent := range manager.jobs {
+		if ent.job.ExpiresAt == nil || *ent.job.ExpiresAt > now {
+			continue
+		}
+		delete(manager.jobs, id)
+		if err := os.Remove(manager.Path(ent.job)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Error().Err(err).Str("job", id.String()).Msg("could not delete the result of an expired export job")
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/skybi/pluteo/internal/metar"
+	"io"
+)
+
+// columnarRowGroupSize defines how many rows a single row group of a columnar export contains at most
+var columnarRowGroupSize = 10000
+
+// rowWriter writes exported METARs to an underlying writer
+type rowWriter interface {
+	// Write writes a single METAR
+	Write(obj *metar.METAR) error
+
+	// Flush writes any buffered rows; it has to be called once all METARs were written
+	Flush() error
+}
+
+// newRowWriter creates a new row writer of the given format
+func newRowWriter(format Format, out io.Writer) (rowWriter, error) {
+	switch format {
+	case FormatCSV:
+		writer := &csvRowWriter{
+			writer: csv.NewWriter(out),
+		}
+		if err := writer.writer.Write(new(metar.METAR).CSVHeader()); err != nil {
+			return nil, err
+		}
+		return writer, nil
+	case FormatColumnar:
+		return &columnarRowWriter{
+			encoder: json.NewEncoder(out),
+		}, nil
+	default:
+		return &ndjsonRowWriter{
+			encoder: json.NewEncoder(out),
+		}, nil
+	}
+}
+
+type csvRowWriter struct {
+	writer *csv.Writer
+}
+
+func (writer *csvRowWriter) Write(obj *metar.METAR) error {
+	return writer.writer.Write(obj.CSVRecord())
+}
+
+func (writer *csvRowWriter) Flush() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+type ndjsonRowWriter struct {
+	encoder *json.Encoder
+}
+
+func (writer *ndjsonRowWriter) Write(obj *metar.METAR) error {
+	return writer.encoder.Encode(obj)
+}
+
+func (writer *ndjsonRowWriter) Flush() error {
+	return nil
+}
+
+// column defines a single column of a columnar export
+type column struct {
+	name  string
+	typ   string
+	value func(obj *metar.METAR, summary *metar.Summary) any
+}
+
+// columns contains the columns of a columnar export; they match the ones of the CSV representation of a METAR
+var columns = []*column{
+	{"id", "string", func(obj *metar.METAR, _ *metar.Summary) any { return obj.ID }},
+	{"station_id", "string", func(obj *metar.METAR, _ *metar.Summary) any { return obj.StationID }},
+	{"issued_at", "int64", func(obj *metar.METAR, _ *metar.Summary) any { return obj.IssuedAt }},
+	{"type", "string", func(obj *metar.METAR, _ *metar.Summary) any { return obj.Type }},
+	{"corrected", "bool", func(obj *metar.METAR, _ *metar.Summary) any { return obj.Corrected }},
+	{"automated", "bool", func(obj *metar.METAR, _ *metar.Summary) any { return obj.Automated }},
+	{"nil", "bool", func(obj *metar.METAR, _ *metar.Summary) any { return obj.Nil }},
+	{"flight_category", "string", func(obj *metar.METAR, _ *metar.Summary) any { return obj.FlightCategory }},
+	{"sequence", "int64", func(obj *metar.METAR, _ *metar.Summary) any { return obj.Sequence }},
+	{"superseded_by", "string", func(obj *metar.METAR, _ *metar.Summary) any { return obj.SupersededBy }},
+	{"raw", "string", func(obj *metar.METAR, _ *metar.Summary) any { return obj.Raw }},
+	{"wind_direction", "int32", func(_ *metar.METAR, summary *metar.Summary) any { return summary.WindDirection }},
+	{"wind_speed_kt", "float64", func(_ *metar.METAR, summary *metar.Summary) any { return summary.WindSpeed }},
+	{"wind_gust_kt", "float64", func(_ *metar.METAR, summary *metar.Summary) any { return summary.WindGust }},
+	{"visibility_m", "float64", func(_ *metar.METAR, summary *metar.Summary) any { return summary.Visibility }},
+	{"ceiling_ft", "int32", func(_ *metar.METAR, summary *metar.Summary) any { return summary.Ceiling }},
+	{"temperature_c", "int32", func(_ *metar.METAR, summary *metar.Summary) any { return summary.Temperature }},
+	{"dew_point_c", "int32", func(_ *metar.METAR, summary *metar.Summary) any { return summary.DewPoint }},
+	{"altimeter_hpa", "float64", func(_ *metar.METAR, summary *metar.Summary) any { return summary.Altimeter }},
+	{"weather", "string", func(_ *metar.METAR, summary *metar.Summary) any { return summary.Weather }},
+}
+
+// columnarRowGroup represents a single line of a columnar export
+type columnarRowGroup struct {
+	Rows    int               `json:"rows"`
+	Columns []*columnarColumn `json:"columns"`
+}
+
+type columnarColumn struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Values []any  `json:"values"`
+}
+
+// columnarRowWriter buffers up to columnarRowGroupSize rows and writes them as a single row group
+type columnarRowWriter struct {
+	encoder *json.Encoder
+	group   *columnarRowGroup
+}
+
+func (writer *columnarRowWriter) Write(obj *metar.METAR) error {
+	if writer.group == nil {
+		writer.group = &columnarRowGroup{
+			Columns: make([]*columnarColumn, 0, len(columns)),
+		}
+		for _, col := range columns {
+			writer.group.Columns = append(writer.group.Columns, &columnarColumn{
+				Name:   col.name,
+				Type:   col.typ,
+				Values: make([]any, 0, columnarRowGroupSize),
+			})
+		}
+	}
+
+	summary := obj.Summarize()
+	for i, col := range columns {
+		writer.group.Columns[i].Values = append(writer.group.Columns[i].Values, col.value(obj, summary))
+	}
+	writer.group.Rows++
+
+	if writer.group.Rows >= columnarRowGroupSize {
+		return writer.Flush()
+	}
+	return nil
+}
+
+func (writer *columnarRowWriter) Flush() error {
+	if writer.group == nil {
+		return nil
+	}
+	group := writer.group
+	writer.group = nil
+	return writer.encoder.Encode(group)
+}
@@ -0,0 +1,179 @@
+package ingest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/skybi/pluteo/internal/metar"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Report represents a single raw METAR extracted from an upstream file
+type Report struct {
+	Raw string
+
+	// ReferenceTime is the time the issuing time of the METAR is resolved relative to; the time of the pull is used if
+	// it is zero
+	ReferenceTime time.Time
+}
+
+// Format extracts the raw METARs of an upstream file
+type Format interface {
+	Parse(reader io.Reader) ([]*Report, error)
+}
+
+// FormatFunc is a function implementing the Format interface
+type FormatFunc func(reader io.Reader) ([]*Report, error)
+
+// Parse extracts the raw METARs of an upstream file
+func (fn FormatFunc) Parse(reader io.Reader) ([]*Report, error) {
+	return fn(reader)
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Format{
+		"station_text": FormatFunc(ParseStationText),
+		"csv_cache":    FormatFunc(ParseCSVCache),
+		"wmo_bulletin": FormatFunc(ParseWMOBulletins),
+	}
+)
+
+// RegisterFormat registers a format under the given name so that sources may refer to it
+func RegisterFormat(name string, format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[name] = format
+}
+
+// LookupFormat looks up a registered format by its name
+func LookupFormat(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	format, ok := formats[name]
+	return format, ok
+}
+
+// FormatNames returns the names of all registered formats in alphabetical order
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stationTextDatePattern matches the date line preceding the report in a per-station text file
+var stationTextDatePattern = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}$`)
+
+// ParseStationText parses per-station text files which consist of a date line ('2006/01/02 15:04', UTC) followed by the
+// report, which may span multiple lines. Multiple of these entries may be concatenated.
+// The date line is used as the reference time of the report.
+func ParseStationText(reader io.Reader) ([]*Report, error) {
+	reports := []*Report{}
+	var current *Report
+	var lines []string
+	flush := func() {
+		if current != nil && len(lines) > 0 {
+			current.Raw = strings.Join(lines, " ")
+			reports = append(reports, current)
+		}
+		current = nil
+		lines = lines[:0]
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if stationTextDatePattern.MatchString(line) {
+			flush()
+			referenceTime, err := time.Parse("2006/01/02 15:04", line)
+			if err != nil {
+				return nil, err
+			}
+			current = &Report{
+				ReferenceTime: referenceTime,
+			}
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("report '%s' is not preceded by a date line", line)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return reports, nil
+}
+
+// ParseCSVCache parses CSV files in the format of the aviation weather data server's METAR cache.
+// Any lines preceding the header row (which has to contain a 'raw_text' column) are skipped.
+func ParseCSVCache(reader io.Reader) ([]*Report, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.ReuseRecord = true
+
+	column := -1
+	reports := []*Report{}
+	for {
+		record, err := csvReader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		if column < 0 {
+			for i, name := range record {
+				if strings.TrimSpace(strings.ToLower(name)) == "raw_text" {
+					column = i
+					break
+				}
+			}
+			continue
+		}
+
+		if column >= len(record) {
+			continue
+		}
+		if raw := strings.TrimSpace(record[column]); raw != "" {
+			reports = append(reports, &Report{
+				Raw: raw,
+			})
+		}
+	}
+	if column < 0 {
+		return nil, errors.New("missing header row with a 'raw_text' column")
+	}
+	return reports, nil
+}
+
+// ParseWMOBulletins parses files consisting of WMO bulletins or one report per line (see metar.ReportScanner)
+func ParseWMOBulletins(reader io.Reader) ([]*Report, error) {
+	reports := []*Report{}
+	scanner := metar.NewReportScanner(reader)
+	for scanner.Scan() {
+		reports = append(reports, &Report{
+			Raw: scanner.Report(),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
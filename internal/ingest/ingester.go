@@ -0,0 +1,354 @@
+package ingest
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/skybi/pluteo/internal/event"
+	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/task"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errNotModified is returned by Ingester.open if the upstream file did not change since the last pull
+var errNotModified = errors.New("not modified")
+
+// Status represents the health of a single source
+type Status struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Format   string `json:"format"`
+	Interval int64  `json:"interval"`
+
+	// Healthy is set if the latest pull of the source succeeded
+	Healthy             bool   `json:"healthy"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	LastError           string `json:"last_error,omitempty"`
+	LastAttemptAt       *int64 `json:"last_attempt_at"`
+	LastSuccessAt       *int64 `json:"last_success_at"`
+
+	// LastCreated, LastDuplicates and LastInvalid contain the amount of METARs of the latest successful pull that were
+	// stored, already known or malformed
+	LastCreated    int `json:"last_created"`
+	LastDuplicates int `json:"last_duplicates"`
+	LastInvalid    int `json:"last_invalid"`
+}
+
+// sourceState holds a source together with its status and the validators of its latest upstream file
+type sourceState struct {
+	source *Source
+	format Format
+
+	// pulling prevents overlapping pulls of the same source
+	pulling sync.Mutex
+
+	status       Status
+	etag         string
+	lastModified string
+}
+
+// Ingester periodically pulls METARs from upstream files and stores them.
+// Newly stored METARs are published to the event broker just like the ones fed through the data API.
+type Ingester struct {
+	// Client is the HTTP client used to pull remote sources
+	Client *http.Client
+
+	// BatchSize defines how many METARs are stored at once
+	BatchSize int
+
+	repo   metar.Repository
+	events *event.Broker[*metar.METAR]
+
+	mu      sync.Mutex
+	sources []*sourceState
+	tasks   []*task.RepeatingTask
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	// pulls tracks the running pulls so that Stop can wait for them
+	pulls sync.WaitGroup
+}
+
+// NewIngester creates a new ingester pulling from the given sources.
+// It returns an error if a source refers to an unknown format.
+func NewIngester(repo metar.Repository, events *event.Broker[*metar.METAR], sources []*Source) (*Ingester, error) {
+	states := make([]*sourceState, 0, len(sources))
+	for _, source := range sources {
+		format, ok := LookupFormat(source.Format)
+		if !ok {
+			return nil, fmt.Errorf("source '%s' has an unknown format '%s'", source.Name, source.Format)
+		}
+		states = append(states, &sourceState{
+			source: source,
+			format: format,
+			status: Status{
+				Name:     source.Name,
+				Location: source.Location,
+				Format:   source.Format,
+				Interval: int64(source.Interval.Seconds()),
+			},
+		})
+	}
+	return &Ingester{
+		Client: &http.Client{
+			Timeout: time.Minute,
+		},
+		BatchSize: 500,
+		repo:      repo,
+		events:    events,
+		sources:   states,
+	}, nil
+}
+
+// Start pulls every source once and schedules the repeating pulls
+func (ingester *Ingester) Start() {
+	ingester.mu.Lock()
+	defer ingester.mu.Unlock()
+	if ingester.ctx != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ingester.ctx, ingester.cancel = ctx, cancel
+	for _, state := range ingester.sources {
+		state := state
+		ingester.pulls.Add(1)
+		go func() {
+			defer ingester.pulls.Done()
+			ingester.pull(ctx, state)
+		}()
+
+		pulling := task.NewRepeating(func() {
+			ingester.repeatPull(ctx, state)
+		}, state.source.Interval)
+		pulling.Start()
+		ingester.tasks = append(ingester.tasks, pulling)
+	}
+}
+
+// Stop stops the repeating pulls, cancels the running ones and waits for them to return
+func (ingester *Ingester) Stop() {
+	ingester.mu.Lock()
+	if ingester.ctx == nil {
+		ingester.mu.Unlock()
+		return
+	}
+
+	for _, pulling := range ingester.tasks {
+		pulling.Stop(false)
+	}
+	ingester.tasks = nil
+	ingester.cancel()
+	ingester.ctx = nil
+
+	// The running pulls need the lock to record their outcome
+	ingester.mu.Unlock()
+	ingester.pulls.Wait()
+}
+
+// Statuses returns the status of every source
+func (ingester *Ingester) Statuses() []*Status {
+	statuses := make([]*Status, 0, len(ingester.sources))
+	for _, state := range ingester.sources {
+		ingester.mu.Lock()
+		status := state.status
+		ingester.mu.Unlock()
+		statuses = append(statuses, &status)
+	}
+	return statuses
+}
+
+// repeatPull pulls a source once unless the ingester was stopped in the meantime.
+// A repeating task may still fire right after being stopped, so the pull is only tracked while ctx is the active one.
+func (ingester *Ingester) repeatPull(ctx context.Context, state *sourceState) {
+	ingester.mu.Lock()
+	if ingester.ctx != ctx {
+		ingester.mu.Unlock()
+		return
+	}
+	ingester.pulls.Add(1)
+	ingester.mu.Unlock()
+	defer ingester.pulls.Done()
+
+	ingester.pull(ctx, state)
+}
+
+// pull pulls a source once and records the outcome in its status.
+// If the previous pull of the source is still running, this is a no-op.
+func (ingester *Ingester) pull(ctx context.Context, state *sourceState) {
+	if !state.pulling.TryLock() {
+		return
+	}
+	defer state.pulling.Unlock()
+
+	attemptedAt := time.Now().Unix()
+	created, duplicates, invalid, err := ingester.ingest(ctx, state)
+	if errors.Is(err, errNotModified) {
+		err = nil
+	}
+
+	ingester.mu.Lock()
+	defer ingester.mu.Unlock()
+	status := &state.status
+	status.LastAttemptAt = &attemptedAt
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		status.Healthy = false
+		status.ConsecutiveFailures++
+		status.LastError = err.Error()
+		log.Warn().Err(err).Str("source", state.source.Name).Int("failures", status.ConsecutiveFailures).Msg("could not pull METARs from an ingest source")
+		return
+	}
+	status.Healthy = true
+	status.ConsecutiveFailures = 0
+	status.LastError = ""
+	status.LastSuccessAt = &attemptedAt
+	status.LastCreated = created
+	status.LastDuplicates = duplicates
+	status.LastInvalid = invalid
+	log.Debug().Str("source", state.source.Name).Int("created", created).Int("duplicates", duplicates).Int("invalid", invalid).Msg("pulled METARs from an ingest source")
+}
+
+// ingest fetches and parses the upstream file of a source and stores its METARs.
+// It returns the amount of created, duplicate and malformed METARs.
+func (ingester *Ingester) ingest(ctx context.Context, state *sourceState) (int, int, int, error) {
+	file, err := ingester.open(ctx, state)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer file.Close()
+
+	reader, err := decompress(file)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	reports, err := state.format.Parse(reader)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	// Malformed METARs are skipped so that they do not prevent the other ones from being stored. METARs sharing the
+	// same reference time are stored together.
+	now := time.Now()
+	created, duplicates, invalid := 0, 0, 0
+	var batch []string
+	var batchReferenceTime time.Time
+	store := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := ingester.repo.CreatePartially(ctx, batch, nil, metar.WithReferenceTime(batchReferenceTime))
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			switch result.Status {
+			case metar.CreateStatusCreated:
+				ingester.events.Publish(result.METAR)
+				created++
+			case metar.CreateStatusDuplicate:
+				duplicates++
+			default:
+				invalid++
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for _, report := range reports {
+		referenceTime := report.ReferenceTime
+		if referenceTime.IsZero() {
+			referenceTime = now
+		}
+		if len(batch) >= ingester.BatchSize || (len(batch) > 0 && !referenceTime.Equal(batchReferenceTime)) {
+			if err := store(); err != nil {
+				return created, duplicates, invalid, err
+			}
+		}
+		batch = append(batch, report.Raw)
+		batchReferenceTime = referenceTime
+	}
+	if err := store(); err != nil {
+		return created, duplicates, invalid, err
+	}
+
+	// The validators are only remembered once the file was processed successfully so that a failed pull is repeated
+	if remote, ok := file.(*httpFile); ok {
+		ingester.mu.Lock()
+		state.etag = remote.etag
+		state.lastModified = remote.lastModified
+		ingester.mu.Unlock()
+	}
+	return created, duplicates, invalid, nil
+}
+
+// httpFile represents the body of a remote upstream file together with its validators
+type httpFile struct {
+	io.ReadCloser
+	etag         string
+	lastModified string
+}
+
+// open opens the upstream file of a source.
+// Remote files are requested conditionally; errNotModified is returned if they did not change since the last pull.
+func (ingester *Ingester) open(ctx context.Context, state *sourceState) (io.ReadCloser, error) {
+	location := state.source.Location
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.Open(strings.TrimPrefix(location, "file://"))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", "Pluteo-Ingest")
+	ingester.mu.Lock()
+	if state.etag != "" {
+		request.Header.Set("If-None-Match", state.etag)
+	}
+	if state.lastModified != "" {
+		request.Header.Set("If-Modified-Since", state.lastModified)
+	}
+	ingester.mu.Unlock()
+
+	response, err := ingester.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case response.StatusCode == http.StatusNotModified:
+		response.Body.Close()
+		return nil, errNotModified
+	case response.StatusCode < 200 || response.StatusCode >= 300:
+		response.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+	return &httpFile{
+		ReadCloser:   response.Body,
+		etag:         response.Header.Get("ETag"),
+		lastModified: response.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// decompress transparently decompresses gzip compressed files
+func decompress(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
@@ -0,0 +1,75 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// minInterval defines how often a single source may be pulled at most
+const minInterval = 10 * time.Second
+
+// Source represents an upstream file METARs are pulled from periodically.
+// Its location is either an HTTP(S) URL or a local path; gzip compressed files are decompressed transparently.
+type Source struct {
+	Name     string
+	Location string
+	Format   string
+	Interval time.Duration
+}
+
+// sourceDefinition represents the JSON representation of a source inside a sources file
+type sourceDefinition struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Format   string `json:"format"`
+	Interval string `json:"interval"`
+}
+
+// LoadSources loads the sources defined in the JSON file at the given path.
+// The file has to contain a list of objects with the 'name', 'location', 'format' (see FormatNames) and 'interval'
+// (a duration string like '5m') keys.
+func LoadSources(path string) ([]*Source, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var definitions []*sourceDefinition
+	if err := json.Unmarshal(raw, &definitions); err != nil {
+		return nil, err
+	}
+
+	sources := make([]*Source, 0, len(definitions))
+	names := make(map[string]struct{}, len(definitions))
+	for i, definition := range definitions {
+		if definition.Name == "" {
+			return nil, fmt.Errorf("source no. %d has no name", i)
+		}
+		if _, ok := names[definition.Name]; ok {
+			return nil, fmt.Errorf("duplicate source name '%s'", definition.Name)
+		}
+		if definition.Location == "" {
+			return nil, fmt.Errorf("source '%s' has no location", definition.Name)
+		}
+		if _, ok := LookupFormat(definition.Format); !ok {
+			return nil, fmt.Errorf("source '%s' has an unknown format '%s' (supported: %v)", definition.Name, definition.Format, FormatNames())
+		}
+		interval, err := time.ParseDuration(definition.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("source '%s' has an invalid interval: %w", definition.Name, err)
+		}
+		if interval < minInterval {
+			return nil, fmt.Errorf("the interval of source '%s' must be at least %s", definition.Name, minInterval)
+		}
+
+		names[definition.Name] = struct{}{}
+		sources = append(sources, &Source{
+			Name:     definition.Name,
+			Location: definition.Location,
+			Format:   definition.Format,
+			Interval: interval,
+		})
+	}
+	return sources, nil
+}
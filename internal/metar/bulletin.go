@@ -0,0 +1,136 @@
+package metar
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// maxBulletinLineLength defines how long a single line of a report stream may be
+const maxBulletinLineLength = 1024 * 1024
+
+// bulletinHeadingPattern matches the abbreviated heading of a WMO bulletin ('TTAAii CCCC YYGGgg [BBB]'), e.g.
+// 'SAUS70 KWBC 121200' or 'SAUS70 KWBC 121200 RRA'
+var bulletinHeadingPattern = regexp.MustCompile(`^[A-Z]{4}\d{2} [A-Z]{4} \d{6}( [A-Z]{3})?$`)
+
+// bulletinSequencePattern matches the channel sequence numbers transmitted in front of WMO bulletins
+var bulletinSequencePattern = regexp.MustCompile(`^\d{3,5}$`)
+
+// ReportScanner splits a stream of raw text into single reports. The stream may either contain one report per line or
+// WMO bulletins, whose reports are terminated by '=' and may span multiple lines. Bulletin headings, channel sequence
+// numbers, start/end of message characters and the report type line ('METAR' or 'SPECI') are stripped; the report type
+// of a bulletin is prepended to every report of it that does not specify a type itself.
+// Its usage follows the one of bufio.Scanner.
+type ReportScanner struct {
+	lines *bufio.Scanner
+
+	// inBulletin is set while the lines of a WMO bulletin are read; reports are only terminated by '=' then
+	inBulletin bool
+	reportType ReportType
+	partial    []string
+
+	queue  []string
+	report string
+}
+
+// NewReportScanner creates a new report scanner reading from the given reader
+func NewReportScanner(reader io.Reader) *ReportScanner {
+	lines := bufio.NewScanner(reader)
+	lines.Buffer(make([]byte, 0, 64*1024), maxBulletinLineLength)
+	return &ReportScanner{
+		lines: lines,
+	}
+}
+
+// Scan advances the scanner to the next report, which will then be available through Report.
+// It returns false once the end of the stream is reached or an error occurred.
+func (scanner *ReportScanner) Scan() bool {
+	for len(scanner.queue) == 0 {
+		if !scanner.lines.Scan() {
+			scanner.endBulletin()
+			if len(scanner.queue) == 0 {
+				return false
+			}
+			break
+		}
+		scanner.consume(scanner.lines.Text())
+	}
+	scanner.report = scanner.queue[0]
+	scanner.queue = scanner.queue[1:]
+	return true
+}
+
+// Report returns the report read by the last call to Scan
+func (scanner *ReportScanner) Report() string {
+	return scanner.report
+}
+
+// Err returns the first error that occurred while reading the stream
+func (scanner *ReportScanner) Err() error {
+	return scanner.lines.Err()
+}
+
+// consume processes a single line of the stream
+func (scanner *ReportScanner) consume(line string) {
+	// The start of heading character introduces and the end of text character terminates a bulletin
+	if strings.ContainsRune(line, '\x01') {
+		scanner.endBulletin()
+		line = strings.ReplaceAll(line, "\x01", "")
+	}
+	terminated := strings.ContainsRune(line, '\x03')
+	line = strings.TrimSpace(strings.ReplaceAll(line, "\x03", ""))
+	defer func() {
+		if terminated {
+			scanner.endBulletin()
+		}
+	}()
+
+	switch {
+	case line == "" || line == "NNNN" || bulletinSequencePattern.MatchString(line):
+		return
+	case bulletinHeadingPattern.MatchString(line):
+		scanner.endBulletin()
+		scanner.inBulletin = true
+		return
+	case line == string(ReportTypeMETAR) || line == string(ReportTypeSPECI):
+		scanner.flush()
+		scanner.reportType = ReportType(line)
+		return
+	}
+
+	for {
+		part, rest, found := strings.Cut(line, "=")
+		if part = strings.TrimSpace(part); part != "" {
+			scanner.partial = append(scanner.partial, part)
+		}
+		if !found {
+			break
+		}
+		scanner.flush()
+		line = rest
+	}
+	if !scanner.inBulletin {
+		scanner.flush()
+	}
+}
+
+// flush queues the report that is currently being read
+func (scanner *ReportScanner) flush() {
+	if len(scanner.partial) == 0 {
+		return
+	}
+	report := strings.Join(scanner.partial, " ")
+	scanner.partial = scanner.partial[:0]
+	if scanner.reportType != "" && !strings.HasPrefix(report, string(ReportTypeMETAR)) && !strings.HasPrefix(report, string(ReportTypeSPECI)) {
+		report = string(scanner.reportType) + " " + report
+	}
+	scanner.queue = append(scanner.queue, report)
+}
+
+// endBulletin queues the report that is currently being read and leaves the current bulletin
+func (scanner *ReportScanner) endBulletin() {
+	scanner.flush()
+	scanner.inBulletin = false
+	scanner.reportType = ""
+}
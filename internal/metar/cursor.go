@@ -0,0 +1,53 @@
+package metar
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor is returned by ParseCursor if the given string is no valid cursor token
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor represents the position of a METAR inside the history of METARs ordered by their issuing date (descending).
+// As multiple METARs may share the same issuing time, the METAR ID is used as a tie-breaker.
+type Cursor struct {
+	IssuedAt int64
+	ID       uuid.UUID
+}
+
+// CursorOf returns the cursor pointing to the given METAR
+func CursorOf(obj *METAR) *Cursor {
+	return &Cursor{
+		IssuedAt: obj.IssuedAt,
+		ID:       obj.ID,
+	}
+}
+
+// ParseCursor parses a cursor token created using Cursor.String
+func ParseCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	issuedAt, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	cursor := new(Cursor)
+	if cursor.IssuedAt, err = strconv.ParseInt(issuedAt, 10, 64); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// String encodes the cursor into an opaque token
+func (cursor *Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", cursor.IssuedAt, cursor.ID)))
+}
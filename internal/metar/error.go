@@ -1,6 +1,10 @@
 package metar
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
 
 // FormatError represents an error in a METAR format
 type FormatError struct {
@@ -12,8 +16,29 @@ func (err *FormatError) Error() string {
 	return err.Wrapping.Error()
 }
 
+// MarshalJSON implements the json.Marshaler interface
+func (err *FormatError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"index":   err.Index,
+		"message": err.Error(),
+	})
+}
+
 var (
-	ErrNoASCIIString    = &FormatError{Wrapping: errors.New("the given string has non-ASCII characters")}
-	ErrMETARIncomplete  = &FormatError{Wrapping: errors.New("the METAR is incomplete (end of string reached before time was found)")}
-	ErrInvalidMETARTime = &FormatError{Wrapping: errors.New("the METAR's issuing time is formatted incorrectly (expected 'ddddddZ')")}
+	ErrNoASCIIString     = &FormatError{Wrapping: errors.New("the given string has non-ASCII characters")}
+	ErrMETARIncomplete   = &FormatError{Wrapping: errors.New("the METAR is incomplete (end of string reached before time was found)")}
+	ErrInvalidMETARTime  = &FormatError{Wrapping: errors.New("the METAR's issuing time is formatted incorrectly (expected 'ddddddZ')")}
+	ErrInvalidStationID  = &FormatError{Wrapping: errors.New("the METAR's station ID is formatted incorrectly (expected 4 alphanumeric characters)")}
+	errUnrecognizedGroup = func(group string, index int) *FormatError {
+		return &FormatError{
+			Wrapping: fmt.Errorf("unrecognized group '%s' at offset %d", group, index),
+			Index:    index,
+		}
+	}
+	errInvalidGroup = func(group, reason string, index int) *FormatError {
+		return &FormatError{
+			Wrapping: fmt.Errorf("invalid group '%s' at offset %d: %s", group, index, reason),
+			Index:    index,
+		}
+	}
 )
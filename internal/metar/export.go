@@ -0,0 +1,238 @@
+package metar
+
+import (
+	"encoding/xml"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader contains the columns of the CSV representation of a METAR
+var csvHeader = []string{
+	"id", "station_id", "issued_at", "type", "corrected", "automated", "nil", "flight_category", "sequence",
+	"superseded_by", "raw", "wind_direction", "wind_speed_kt", "wind_gust_kt", "visibility_m", "ceiling_ft",
+	"temperature_c", "dew_point_c", "altimeter_hpa", "weather",
+}
+
+// CSVHeader returns the columns of the CSV representation of a METAR.
+// Besides the stored fields, the CSV representation contains the summary of the METAR (see Summary).
+func (obj *METAR) CSVHeader() []string {
+	header := make([]string, len(csvHeader))
+	copy(header, csvHeader)
+	return header
+}
+
+// CSVRecord returns the CSV representation of the METAR (see CSVHeader)
+func (obj *METAR) CSVRecord() []string {
+	supersededBy := ""
+	if obj.SupersededBy != nil {
+		supersededBy = obj.SupersededBy.String()
+	}
+	summary := obj.Summarize()
+	return []string{
+		obj.ID.String(),
+		obj.StationID,
+		strconv.FormatInt(obj.IssuedAt, 10),
+		string(obj.Type),
+		strconv.FormatBool(obj.Corrected),
+		strconv.FormatBool(obj.Automated),
+		strconv.FormatBool(obj.Nil),
+		string(obj.FlightCategory),
+		strconv.FormatInt(obj.Sequence, 10),
+		supersededBy,
+		obj.Raw,
+		formatOptionalInt(summary.WindDirection),
+		formatOptionalFloat(summary.WindSpeed),
+		formatOptionalFloat(summary.WindGust),
+		formatOptionalFloat(summary.Visibility),
+		formatOptionalInt(summary.Ceiling),
+		formatOptionalInt(summary.Temperature),
+		formatOptionalInt(summary.DewPoint),
+		formatOptionalFloat(summary.Altimeter),
+		summary.Weather,
+	}
+}
+
+// xmlMETAR represents the XML representation of a METAR; its layout follows the one of the aviation weather data
+// server
+type xmlMETAR struct {
+	XMLName             xml.Name         `xml:"METAR"`
+	RawText             string           `xml:"raw_text"`
+	StationID           string           `xml:"station_id"`
+	ObservationTime     string           `xml:"observation_time"`
+	TempC               *int             `xml:"temp_c,omitempty"`
+	DewpointC           *int             `xml:"dewpoint_c,omitempty"`
+	WindDirDegrees      *int             `xml:"wind_dir_degrees,omitempty"`
+	WindSpeedKt         *float64         `xml:"wind_speed_kt,omitempty"`
+	WindGustKt          *float64         `xml:"wind_gust_kt,omitempty"`
+	VisibilityStatuteMi *float64         `xml:"visibility_statute_mi,omitempty"`
+	AltimInHg           *float64         `xml:"altim_in_hg,omitempty"`
+	QualityControlFlags *xmlQualityFlags `xml:"quality_control_flags,omitempty"`
+	WxString            string           `xml:"wx_string,omitempty"`
+	SkyConditions       []*xmlSky        `xml:"sky_condition"`
+	FlightCategory      string           `xml:"flight_category,omitempty"`
+	VertVisFt           *int             `xml:"vert_vis_ft,omitempty"`
+	METARType           string           `xml:"metar_type"`
+	ID                  string           `xml:"id"`
+	Sequence            int64            `xml:"sequence"`
+	SupersededBy        string           `xml:"superseded_by,omitempty"`
+	Extra               []any            `xml:",any"`
+}
+
+type xmlQualityFlags struct {
+	Corrected string `xml:"corrected,omitempty"`
+	Auto      string `xml:"auto,omitempty"`
+	NoSignal  string `xml:"no_signal,omitempty"`
+}
+
+type xmlSky struct {
+	SkyCover       string `xml:"sky_cover,attr"`
+	CloudBaseFtAGL *int   `xml:"cloud_base_ft_agl,attr,omitempty"`
+}
+
+// MarshalXML writes the XML representation of the METAR as a 'METAR' element
+func (obj *METAR) MarshalXML(encoder *xml.Encoder, _ xml.StartElement) error {
+	return obj.EncodeXML(encoder)
+}
+
+// EncodeXML writes the XML representation of the METAR as a 'METAR' element.
+// The given values are appended to it as additional child elements (e.g. to describe the METAR's station).
+func (obj *METAR) EncodeXML(encoder *xml.Encoder, extra ...any) error {
+	summary := obj.Summarize()
+	element := &xmlMETAR{
+		RawText:         obj.Raw,
+		StationID:       obj.StationID,
+		ObservationTime: time.Unix(obj.IssuedAt, 0).UTC().Format(time.RFC3339),
+		TempC:           summary.Temperature,
+		DewpointC:       summary.DewPoint,
+		WindDirDegrees:  summary.WindDirection,
+		WindSpeedKt:     summary.WindSpeed,
+		WindGustKt:      summary.WindGust,
+		WxString:        summary.Weather,
+		SkyConditions:   []*xmlSky{},
+		FlightCategory:  string(obj.FlightCategory),
+		METARType:       string(obj.Type),
+		ID:              obj.ID.String(),
+		Sequence:        obj.Sequence,
+		Extra:           extra,
+	}
+	if summary.Visibility != nil {
+		miles := round(*summary.Visibility/1609.344, 2)
+		element.VisibilityStatuteMi = &miles
+	}
+	if summary.Altimeter != nil {
+		inches := round(*summary.Altimeter/33.8639, 2)
+		element.AltimInHg = &inches
+	}
+	if obj.Corrected || obj.Automated || obj.Nil {
+		element.QualityControlFlags = &xmlQualityFlags{}
+		if obj.Corrected {
+			element.QualityControlFlags.Corrected = "TRUE"
+		}
+		if obj.Automated {
+			element.QualityControlFlags.Auto = "TRUE"
+		}
+		if obj.Nil {
+			element.QualityControlFlags.NoSignal = "TRUE"
+		}
+	}
+	if report := summary.report; report != nil {
+		switch {
+		case report.CAVOK:
+			element.SkyConditions = append(element.SkyConditions, &xmlSky{SkyCover: "CAVOK"})
+		case report.SkyCondition != "":
+			element.SkyConditions = append(element.SkyConditions, &xmlSky{SkyCover: report.SkyCondition})
+		}
+		for _, layer := range report.Clouds {
+			element.SkyConditions = append(element.SkyConditions, &xmlSky{
+				SkyCover:       layer.Cover,
+				CloudBaseFtAGL: layer.Height,
+			})
+		}
+		element.VertVisFt = report.VerticalVisibility
+	}
+	if obj.SupersededBy != nil {
+		element.SupersededBy = obj.SupersededBy.String()
+	}
+	return encoder.Encode(element)
+}
+
+// Summary contains the most important decoded values of a METAR in normalized units (knots, meters, feet, degrees
+// Celsius and hectopascals). Values that are not present in the METAR are nil.
+type Summary struct {
+	WindDirection *int     `json:"wind_direction"`
+	WindSpeed     *float64 `json:"wind_speed_kt"`
+	WindGust      *float64 `json:"wind_gust_kt"`
+	Visibility    *float64 `json:"visibility_m"`
+	Ceiling       *int     `json:"ceiling_ft"`
+	Temperature   *int     `json:"temperature_c"`
+	DewPoint      *int     `json:"dew_point_c"`
+	Altimeter     *float64 `json:"altimeter_hpa"`
+
+	// Weather contains the raw present weather groups, separated by spaces
+	Weather string `json:"weather"`
+
+	report *Report
+}
+
+// Summarize decodes the METAR and extracts its summary; the summary is empty if the METAR cannot be decoded
+func (obj *METAR) Summarize() *Summary {
+	summary := new(Summary)
+	report, err := obj.Decode()
+	if err != nil {
+		return summary
+	}
+	summary.report = report
+
+	if wind := report.Wind; wind != nil {
+		summary.WindDirection = wind.Direction
+		if wind.Speed != nil {
+			speed := round(wind.Unit.Knots(float64(*wind.Speed)), 1)
+			summary.WindSpeed = &speed
+		}
+		if wind.Gust != nil {
+			gust := round(wind.Unit.Knots(float64(*wind.Gust)), 1)
+			summary.WindGust = &gust
+		}
+	}
+	if report.CAVOK {
+		visibility := 10000.0
+		summary.Visibility = &visibility
+	} else if report.Visibility != nil {
+		visibility := round(report.Visibility.Meters(), 0)
+		summary.Visibility = &visibility
+	}
+	summary.Ceiling, _ = report.Ceiling()
+	summary.Temperature = report.Temperature
+	summary.DewPoint = report.DewPoint
+	if report.Altimeter != nil {
+		altimeter := round(report.Altimeter.Hectopascals(), 1)
+		summary.Altimeter = &altimeter
+	}
+	weather := make([]string, 0, len(report.Weather))
+	for _, group := range report.Weather {
+		weather = append(weather, group.Raw)
+	}
+	summary.Weather = strings.Join(weather, " ")
+	return summary
+}
+
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+func round(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
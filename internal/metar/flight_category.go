@@ -0,0 +1,102 @@
+package metar
+
+// FlightCategory represents the flight category derived from the ceiling and visibility of a METAR
+type FlightCategory string
+
+const (
+	// FlightCategoryVFR represents visual flight rules (ceiling above 3000 ft and visibility above 5 SM)
+	FlightCategoryVFR FlightCategory = "VFR"
+
+	// FlightCategoryMVFR represents marginal visual flight rules (ceiling 1000 to 3000 ft and/or visibility 3 to 5 SM)
+	FlightCategoryMVFR FlightCategory = "MVFR"
+
+	// FlightCategoryIFR represents instrument flight rules (ceiling 500 to below 1000 ft and/or visibility 1 to below
+	// 3 SM)
+	FlightCategoryIFR FlightCategory = "IFR"
+
+	// FlightCategoryLIFR represents low instrument flight rules (ceiling below 500 ft and/or visibility below 1 SM)
+	FlightCategoryLIFR FlightCategory = "LIFR"
+
+	// FlightCategoryUnknown is used if the ceiling and visibility of a METAR are not sufficient to derive a category
+	FlightCategoryUnknown FlightCategory = ""
+)
+
+// FlightCategories contains all known flight categories ordered from the best to the worst one
+var FlightCategories = []FlightCategory{FlightCategoryVFR, FlightCategoryMVFR, FlightCategoryIFR, FlightCategoryLIFR}
+
+// Severity returns the position of the flight category inside FlightCategories, so that worse categories have a higher
+// severity (-1 for FlightCategoryUnknown and invalid categories)
+func (category FlightCategory) Severity() int {
+	for i, candidate := range FlightCategories {
+		if candidate == category {
+			return i
+		}
+	}
+	return -1
+}
+
+// Ceiling returns the height of the lowest broken or overcast cloud layer (or the vertical visibility) in feet.
+// ok is false if the report does not contain any information about the sky; height is nil if it does, but there is
+// no ceiling.
+func (report *Report) Ceiling() (height *int, ok bool) {
+	if report.VerticalVisibility != nil {
+		height = report.VerticalVisibility
+	}
+	for _, layer := range report.Clouds {
+		if (layer.Cover != "BKN" && layer.Cover != "OVC") || layer.Height == nil {
+			continue
+		}
+		if height == nil || *layer.Height < *height {
+			height = layer.Height
+		}
+	}
+	ok = height != nil || report.CAVOK || report.SkyCondition != "" || len(report.Clouds) > 0
+	return height, ok
+}
+
+// FlightCategory derives the flight category of the report from its ceiling and prevailing visibility.
+// If only one of both is known, the category is only derived if that one alone results in a category worse than VFR.
+func (report *Report) FlightCategory() FlightCategory {
+	if report.CAVOK {
+		return FlightCategoryVFR
+	}
+
+	ceilingCategory := FlightCategoryUnknown
+	if ceiling, ok := report.Ceiling(); ok {
+		switch {
+		case ceiling == nil || *ceiling > 3000:
+			ceilingCategory = FlightCategoryVFR
+		case *ceiling >= 1000:
+			ceilingCategory = FlightCategoryMVFR
+		case *ceiling >= 500:
+			ceilingCategory = FlightCategoryIFR
+		default:
+			ceilingCategory = FlightCategoryLIFR
+		}
+	}
+
+	visibilityCategory := FlightCategoryUnknown
+	if report.Visibility != nil {
+		miles := report.Visibility.Meters() / 1609.344
+		switch {
+		case miles > 5 || (report.Visibility.MoreThan && miles >= 5):
+			visibilityCategory = FlightCategoryVFR
+		case miles >= 3:
+			visibilityCategory = FlightCategoryMVFR
+		case miles >= 1:
+			visibilityCategory = FlightCategoryIFR
+		default:
+			visibilityCategory = FlightCategoryLIFR
+		}
+	}
+
+	// The worse component determines the category; an unknown component could still be worse than a VFR one
+	worst := ceilingCategory
+	if visibilityCategory.Severity() > worst.Severity() {
+		worst = visibilityCategory
+	}
+	if worst == FlightCategoryVFR && (ceilingCategory == FlightCategoryUnknown || visibilityCategory == FlightCategoryUnknown) {
+		return FlightCategoryUnknown
+	}
+	return worst
+}
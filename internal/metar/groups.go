@@ -0,0 +1,407 @@
+package metar
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// groupDecoder tries to decode the group starting at tokens[i] into the given report.
+// It returns the amount of tokens it consumed (0 if the group is not handled by this decoder) and an optional error
+// if the group was recognized but its content is invalid.
+type groupDecoder func(tokens []Token, i int, report *Report) (int, error)
+
+// groupDecoders contains all decoders for METAR body groups in the order they usually appear in
+var groupDecoders = []groupDecoder{
+	decodeWind,
+	decodeWindVariation,
+	decodeCAVOK,
+	decodeVisibility,
+	decodeStatuteMilesVisibility,
+	decodeRunwayVisualRange,
+	decodeRunwayState,
+	decodePresentWeather,
+	decodeClouds,
+	decodeTemperature,
+	decodeAltimeter,
+	decodeRecentWeather,
+	decodeWindShear,
+}
+
+var (
+	windPattern              = regexp.MustCompile(`^(\d{3}|VRB|///)(\d{2,3}|//)(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	windVariationPattern     = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	visibilityPattern        = regexp.MustCompile(`^(\d{4})(NDV|N|NE|E|SE|S|SW|W|NW)?$`)
+	smVisibilityPattern      = regexp.MustCompile(`^([PM])?(?:(\d{1,2})|(\d{1,2})/(\d{1,2}))SM$`)
+	smVisibilityWholePattern = regexp.MustCompile(`^\d$`)
+	rvrPattern               = regexp.MustCompile(`^R(\d{2}[LCR]?)/([PM])?(\d{4}|////)(?:V([PM])?(\d{4}))?(FT)?/?([UDN])?$`)
+	runwayStatePattern       = regexp.MustCompile(`^(?:R\d{2}[LCR]?/(?:[0-9/]{6}|CLRD[0-9/]{2})|R/SNOCLO|\d{8})$`)
+	weatherPattern           = regexp.MustCompile(`^(-|\+|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?((?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*)$`)
+	cloudPattern             = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|///)(\d{3}|///)(CB|TCU|///)?$`)
+	verticalVisPattern       = regexp.MustCompile(`^VV(\d{3}|///)$`)
+	temperaturePattern       = regexp.MustCompile(`^(M?\d{2}|//)/(M?\d{2}|//)?$`)
+	altimeterPattern         = regexp.MustCompile(`^([QA])(\d{4}|////)$`)
+	windShearRunwayPattern   = regexp.MustCompile(`^(?:R|RWY)(\d{2}[LCR]?)$`)
+)
+
+func decodeWind(tokens []Token, i int, report *Report) (int, error) {
+	match := windPattern.FindStringSubmatch(tokens[i].Value)
+	if match == nil {
+		return 0, nil
+	}
+	if report.Wind != nil {
+		return 1, errors.New("duplicate wind group")
+	}
+
+	wind := &Wind{
+		Unit: SpeedUnit(match[4]),
+	}
+	switch match[1] {
+	case "VRB":
+		wind.Variable = true
+	case "///":
+	default:
+		direction, _ := strconv.Atoi(match[1])
+		if direction > 360 {
+			return 1, errors.New("wind direction out of range")
+		}
+		wind.Direction = &direction
+	}
+	if match[2] != "//" {
+		speed, _ := strconv.Atoi(match[2])
+		wind.Speed = &speed
+	}
+	if match[3] != "" {
+		gust, _ := strconv.Atoi(match[3])
+		wind.Gust = &gust
+	}
+
+	report.Wind = wind
+	return 1, nil
+}
+
+func decodeWindVariation(tokens []Token, i int, report *Report) (int, error) {
+	match := windVariationPattern.FindStringSubmatch(tokens[i].Value)
+	if match == nil {
+		return 0, nil
+	}
+	if report.Wind == nil {
+		return 1, errors.New("variable wind direction sector without preceding wind group")
+	}
+	from, _ := strconv.Atoi(match[1])
+	to, _ := strconv.Atoi(match[2])
+	if from > 360 || to > 360 {
+		return 1, errors.New("wind direction out of range")
+	}
+	report.Wind.VariableFrom = &from
+	report.Wind.VariableTo = &to
+	return 1, nil
+}
+
+func decodeCAVOK(tokens []Token, i int, report *Report) (int, error) {
+	if tokens[i].Value != "CAVOK" {
+		return 0, nil
+	}
+	report.CAVOK = true
+	return 1, nil
+}
+
+func decodeVisibility(tokens []Token, i int, report *Report) (int, error) {
+	if tokens[i].Value == "////" {
+		return 1, nil
+	}
+	match := visibilityPattern.FindStringSubmatch(tokens[i].Value)
+	if match == nil {
+		return 0, nil
+	}
+	value, _ := strconv.Atoi(match[1])
+
+	// The first visibility group is the prevailing visibility, a second one with a direction the minimum visibility
+	if report.Visibility != nil {
+		if match[2] == "" || match[2] == "NDV" {
+			return 1, errors.New("duplicate visibility group")
+		}
+		if report.MinimumVisibility != nil {
+			return 1, errors.New("duplicate minimum visibility group")
+		}
+		report.MinimumVisibility = &DirectionalVisibility{
+			Value:     value,
+			Direction: match[2],
+		}
+		return 1, nil
+	}
+
+	vis := &Visibility{
+		Value:                  float64(value),
+		Unit:                   DistanceUnitMeters,
+		NoDirectionalVariation: match[2] == "NDV",
+	}
+	if value == 9999 {
+		vis.Value = 10000
+		vis.MoreThan = true
+	}
+	report.Visibility = vis
+	return 1, nil
+}
+
+func decodeStatuteMilesVisibility(tokens []Token, i int, report *Report) (int, error) {
+	// Visibilities like '1 1/2SM' are split into two tokens
+	whole := 0
+	consumed := 1
+	if smVisibilityWholePattern.MatchString(tokens[i].Value) {
+		if i+1 >= len(tokens) || !smVisibilityPattern.MatchString(tokens[i+1].Value) {
+			return 0, nil
+		}
+		whole, _ = strconv.Atoi(tokens[i].Value)
+		consumed = 2
+		i++
+	}
+
+	match := smVisibilityPattern.FindStringSubmatch(tokens[i].Value)
+	if match == nil {
+		return 0, nil
+	}
+	if report.Visibility != nil {
+		return consumed, errors.New("duplicate visibility group")
+	}
+
+	value := float64(whole)
+	if match[2] != "" {
+		if consumed == 2 {
+			return consumed, errors.New("whole number followed by another whole number")
+		}
+		parsed, _ := strconv.Atoi(match[2])
+		value = float64(parsed)
+	} else {
+		numerator, _ := strconv.Atoi(match[3])
+		denominator, _ := strconv.Atoi(match[4])
+		if denominator == 0 {
+			return consumed, errors.New("division by zero")
+		}
+		value += float64(numerator) / float64(denominator)
+	}
+
+	report.Visibility = &Visibility{
+		Value:    value,
+		Unit:     DistanceUnitStatuteMiles,
+		LessThan: match[1] == "M",
+		MoreThan: match[1] == "P",
+	}
+	return consumed, nil
+}
+
+func decodeRunwayVisualRange(tokens []Token, i int, report *Report) (int, error) {
+	match := rvrPattern.FindStringSubmatch(tokens[i].Value)
+	if match == nil {
+		return 0, nil
+	}
+
+	// A missing RVR value ('R25/////') is still a valid group which does not contain any information
+	if match[3] == "////" {
+		return 1, nil
+	}
+
+	rvr := &RunwayVisualRange{
+		Runway:   match[1],
+		Value:    parseRunwayVisualValue(match[2], match[3]),
+		Unit:     DistanceUnitMeters,
+		Tendency: match[7],
+	}
+	if match[5] != "" {
+		rvr.Maximum = parseRunwayVisualValue(match[4], match[5])
+	}
+	if match[6] == "FT" {
+		rvr.Unit = DistanceUnitFeet
+	}
+
+	report.RunwayVisualRanges = append(report.RunwayVisualRanges, rvr)
+	return 1, nil
+}
+
+func parseRunwayVisualValue(modifier, raw string) *RunwayVisualValue {
+	value, _ := strconv.Atoi(raw)
+	return &RunwayVisualValue{
+		Value:    value,
+		LessThan: modifier == "M",
+		MoreThan: modifier == "P",
+	}
+}
+
+func decodeRunwayState(tokens []Token, i int, report *Report) (int, error) {
+	if !runwayStatePattern.MatchString(tokens[i].Value) {
+		return 0, nil
+	}
+	report.RunwayStates = append(report.RunwayStates, tokens[i].Value)
+	return 1, nil
+}
+
+// parseWeather parses a single weather group (without the 'RE' prefix of recent weather groups)
+func parseWeather(raw string) *Weather {
+	match := weatherPattern.FindStringSubmatch(raw)
+	if match == nil || (match[2] == "" && match[3] == "") {
+		return nil
+	}
+
+	// Only showers, thunderstorms and freezing may appear without a precipitation
+	if match[3] == "" && match[2] != "SH" && match[2] != "TS" {
+		return nil
+	}
+
+	phenomena := make([]string, 0, len(match[3])/2)
+	for j := 0; j+2 <= len(match[3]); j += 2 {
+		phenomena = append(phenomena, match[3][j:j+2])
+	}
+	return &Weather{
+		Intensity:  match[1],
+		Descriptor: match[2],
+		Phenomena:  phenomena,
+		Raw:        raw,
+	}
+}
+
+func decodePresentWeather(tokens []Token, i int, report *Report) (int, error) {
+	// '//' indicates that the present weather could not be observed by an automated station
+	if tokens[i].Value == "//" {
+		return 1, nil
+	}
+	if tokens[i].Value == "NSW" {
+		report.NoSignificantWeather = true
+		return 1, nil
+	}
+	weather := parseWeather(tokens[i].Value)
+	if weather == nil {
+		return 0, nil
+	}
+	report.Weather = append(report.Weather, weather)
+	return 1, nil
+}
+
+func decodeRecentWeather(tokens []Token, i int, report *Report) (int, error) {
+	if !strings.HasPrefix(tokens[i].Value, "RE") || len(tokens[i].Value) == 2 {
+		return 0, nil
+	}
+	weather := parseWeather(strings.TrimPrefix(tokens[i].Value, "RE"))
+	if weather == nil {
+		return 1, errors.New("invalid recent weather phenomenon")
+	}
+	report.RecentWeather = append(report.RecentWeather, weather)
+	return 1, nil
+}
+
+func decodeClouds(tokens []Token, i int, report *Report) (int, error) {
+	switch tokens[i].Value {
+	case "SKC", "CLR", "NSC", "NCD":
+		report.SkyCondition = tokens[i].Value
+		return 1, nil
+	}
+
+	if match := verticalVisPattern.FindStringSubmatch(tokens[i].Value); match != nil {
+		if match[1] != "///" {
+			height, _ := strconv.Atoi(match[1])
+			height *= 100
+			report.VerticalVisibility = &height
+		}
+		return 1, nil
+	}
+
+	match := cloudPattern.FindStringSubmatch(tokens[i].Value)
+	if match == nil {
+		return 0, nil
+	}
+	layer := &CloudLayer{
+		Cover: match[1],
+	}
+	if match[2] != "///" {
+		height, _ := strconv.Atoi(match[2])
+		height *= 100
+		layer.Height = &height
+	}
+	if match[3] != "///" {
+		layer.Type = match[3]
+	}
+	report.Clouds = append(report.Clouds, layer)
+	return 1, nil
+}
+
+func decodeTemperature(tokens []Token, i int, report *Report) (int, error) {
+	match := temperaturePattern.FindStringSubmatch(tokens[i].Value)
+	if match == nil {
+		return 0, nil
+	}
+	if report.Temperature != nil || report.DewPoint != nil {
+		return 1, errors.New("duplicate temperature group")
+	}
+	report.Temperature = parseTemperature(match[1])
+	report.DewPoint = parseTemperature(match[2])
+	return 1, nil
+}
+
+func parseTemperature(raw string) *int {
+	if raw == "" || raw == "//" {
+		return nil
+	}
+	negative := strings.HasPrefix(raw, "M")
+	value, _ := strconv.Atoi(strings.TrimPrefix(raw, "M"))
+	if negative {
+		value = -value
+	}
+	return &value
+}
+
+func decodeAltimeter(tokens []Token, i int, report *Report) (int, error) {
+	match := altimeterPattern.FindStringSubmatch(tokens[i].Value)
+	if match == nil {
+		return 0, nil
+	}
+	if match[2] == "////" {
+		return 1, nil
+	}
+	if report.Altimeter != nil {
+		return 1, errors.New("duplicate altimeter group")
+	}
+
+	value, _ := strconv.Atoi(match[2])
+	if match[1] == "A" {
+		report.Altimeter = &Altimeter{
+			Value: float64(value) / 100,
+			Unit:  PressureUnitInchesOfMercury,
+		}
+	} else {
+		report.Altimeter = &Altimeter{
+			Value: float64(value),
+			Unit:  PressureUnitHectopascals,
+		}
+	}
+	return 1, nil
+}
+
+func decodeWindShear(tokens []Token, i int, report *Report) (int, error) {
+	if tokens[i].Value != "WS" {
+		return 0, nil
+	}
+	if i+1 >= len(tokens) {
+		return 1, errors.New("wind shear group without runway")
+	}
+
+	// 'WS ALL RWY'
+	if tokens[i+1].Value == "ALL" {
+		if i+2 >= len(tokens) || tokens[i+2].Value != "RWY" {
+			return 2, errors.New("expected 'RWY' after 'WS ALL'")
+		}
+		report.WindShear = append(report.WindShear, &WindShear{
+			AllRunways: true,
+		})
+		return 3, nil
+	}
+
+	// 'WS R25L' or 'WS RWY25L'
+	match := windShearRunwayPattern.FindStringSubmatch(tokens[i+1].Value)
+	if match == nil {
+		return 1, errors.New("wind shear group without runway")
+	}
+	report.WindShear = append(report.WindShear, &WindShear{
+		Runway: match[1],
+	})
+	return 2, nil
+}
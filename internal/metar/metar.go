@@ -2,24 +2,66 @@ package metar
 
 import (
 	"github.com/google/uuid"
-	"strconv"
 	"strings"
-	"time"
 	"unicode"
 )
 
+// ReportType represents the type of a METAR report
+type ReportType string
+
+const (
+	// ReportTypeMETAR represents a routine weather report
+	ReportTypeMETAR ReportType = "METAR"
+
+	// ReportTypeSPECI represents a special (non-routine) weather report
+	ReportTypeSPECI ReportType = "SPECI"
+)
+
 // METAR represents a stored METAR data point
 type METAR struct {
-	ID        uuid.UUID `json:"id"`
-	StationID string    `json:"station_id"`
-	IssuedAt  int64     `json:"issued_at"`
-	Raw       string    `json:"raw"`
+	ID        uuid.UUID  `json:"id"`
+	StationID string     `json:"station_id"`
+	IssuedAt  int64      `json:"issued_at"`
+	Raw       string     `json:"raw"`
+	Type      ReportType `json:"type"`
+	Corrected bool       `json:"corrected"`
+	Automated bool       `json:"automated"`
+	Nil       bool       `json:"nil"`
+
+	// FlightCategory is derived from the ceiling and visibility of the METAR (see Report.FlightCategory)
+	FlightCategory FlightCategory `json:"flight_category"`
+
+	// Sequence reflects the order METARs were stored in; it is assigned by the repository.
+	// METARs become visible in ascending order of their sequence numbers, so it can be used to resume event streams.
+	Sequence int64 `json:"sequence"`
+
+	// SupersededBy is the ID of the latest correction (COR) of the same station and issuing time; it is nil for that
+	// correction itself and for METARs without any correction
+	SupersededBy *uuid.UUID `json:"superseded_by"`
+
+	// Supersedes contains the IDs of the METARs that were superseded by this correction when it was created.
+	// It is only populated by Repository.Create.
+	Supersedes []uuid.UUID `json:"supersedes,omitempty"`
+
+	// FedBy is the ID of the API key that first fed the METAR; it is nil if the METAR was not fed through the data API.
+	// ReceivedAt is the time the METAR was stored at; it is nil if the METAR was stored before it was tracked.
+	// The provenance is only exposed to admins and thus not part of the JSON representation.
+	FedBy      *uuid.UUID `json:"-"`
+	ReceivedAt *int64     `json:"-"`
+
+	// FeedCount is the amount of distinct API keys that fed the METAR
+	FeedCount int `json:"-"`
 }
 
 // OfString tries to decode a raw METAR string into a METAR object.
-// This method is no replacement to a fully-featured METAR decoder & validator as it only reads and validates the METAR
-// until the timestamp was decoded successfully.
-func OfString(raw string) (*METAR, error) {
+// This method is no replacement to a fully-featured METAR decoder & validator as it only validates the METAR until the
+// timestamp and report modifiers were decoded successfully; the remaining groups are only decoded to derive the flight
+// category. Use Decode to access the whole decoded report.
+// The month and year of the issuing time are resolved relative to the current time by default (see ResolveIssuingTime);
+// use WithReferenceTime and WithMaxFutureSkew to change this behaviour.
+func OfString(raw string, opts ...ParseOption) (*METAR, error) {
+	options := buildParseOptions(opts)
+
 	// METARs only consist of ASCII characters. We don't want to fuck around with other stuff.
 	for i := 0; i < len(raw); i++ {
 		if raw[i] > unicode.MaxASCII {
@@ -29,53 +71,53 @@ func OfString(raw string) (*METAR, error) {
 
 	raw = strings.TrimSpace(raw)
 
-	// The report type (METAR or SPECI) is not included in the METAR object as no currently known data source provides them.
-	// We still trim them as they are technically correct in a raw METAR and thus MAY be provided by a feeder.
-	if strings.HasPrefix(raw, "METAR") {
-		raw = strings.TrimSpace(strings.TrimPrefix(raw, "METAR"))
-	} else if strings.HasPrefix(raw, "SPECI") {
-		raw = strings.TrimSpace(strings.TrimPrefix(raw, "SPECI"))
+	// The report type (METAR or SPECI) is optional as most data sources do not provide it; we assume a routine METAR if
+	// it is missing. It is stored separately and trimmed from the raw representation so that the same report is
+	// recognized as a duplicate regardless of whether a feeder provides its type or not.
+	reportType := ReportTypeMETAR
+	if strings.HasPrefix(raw, string(ReportTypeMETAR)) {
+		raw = strings.TrimSpace(strings.TrimPrefix(raw, string(ReportTypeMETAR)))
+	} else if strings.HasPrefix(raw, string(ReportTypeSPECI)) {
+		reportType = ReportTypeSPECI
+		raw = strings.TrimSpace(strings.TrimPrefix(raw, string(ReportTypeSPECI)))
 	}
 
-	// To prevent data inconsistency, we will not include the report type either way
-	initial := raw
-
-	// The next 4 characters represent the station's ICAO code
-	if len(raw) < 4 {
-		return nil, ErrMETARIncomplete
-	}
-	stationID := raw[:4]
-	raw = strings.TrimSpace(raw[4:])
-
-	// The next 7 characters have to consist of 6 digits + a literal 'Z' as they represent the time the METAR was issued
-	if len(raw) < 7 {
-		return nil, ErrMETARIncomplete
+	// Decode the report, whose header consists of the station's ICAO code, the time the METAR was issued in the format
+	// 'ddhhmmZ' and the optional modifiers ('COR', 'AUTO' and 'NIL').
+	//
+	// Example: 'EDDF 161350Z AUTO' -> automated report of EDDF, issued on the 16th day of the current month at 13:50
+	// Zulu (UTC)
+	report, err := Decode(raw)
+	if err != nil {
+		return nil, err
 	}
-	timeSection := raw[:7]
-	if timeSection[6] != 'Z' {
+	issuedAt, ok := ResolveIssuingTime(report.Day, report.Hour, report.Minute, options.referenceTime, options.maxFutureSkew)
+	if !ok {
 		return nil, ErrInvalidMETARTime
 	}
-	for i := 0; i < 6; i++ {
-		if !unicode.IsDigit(rune(timeSection[i])) {
-			return nil, ErrInvalidMETARTime
-		}
-	}
-
-	// Now we need to split the digits into 3 parts of 2 digits each (dddddd -> dd dd dd).
-	// The first pair represents the day of the current month, the second one the hour and the third one the minutes
-	// the METAR was issued.
-	//
-	// Example: '161350' -> 16th day of the current month at 13:50 Zulu (UTC)
-	day, _ := strconv.Atoi(timeSection[:2])
-	hour, _ := strconv.Atoi(timeSection[2:4])
-	minutes, _ := strconv.Atoi(timeSection[4:6])
-	now := time.Now()
-	issuedAt := time.Date(now.Year(), now.Month(), day, hour, minutes, 0, 0, time.UTC)
 
 	return &METAR{
-		ID:        uuid.New(),
-		StationID: stationID,
-		IssuedAt:  issuedAt.Unix(),
-		Raw:       initial,
+		ID:             uuid.New(),
+		StationID:      report.StationID,
+		IssuedAt:       issuedAt.Unix(),
+		Raw:            raw,
+		Type:           reportType,
+		Corrected:      report.Corrected,
+		Automated:      report.Automated,
+		Nil:            report.Nil,
+		FlightCategory: report.FlightCategory(),
 	}, nil
 }
+
+// Decode fully decodes the raw representation of the METAR (see Decode).
+// As the raw representation does not contain the report type, it is taken from the METAR object itself.
+func (obj *METAR) Decode() (*Report, error) {
+	report, err := Decode(obj.Raw)
+	if err != nil {
+		return nil, err
+	}
+	if report.Type == "" {
+		report.Type = obj.Type
+	}
+	return report, nil
+}
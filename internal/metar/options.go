@@ -0,0 +1,74 @@
+package metar
+
+import "time"
+
+// DefaultMaxFutureSkew defines how far in the future (relative to the reference time) an issuing time may lie by default
+// before it is assumed to belong to the previous month
+var DefaultMaxFutureSkew = time.Hour
+
+// ParseOption represents an option that changes the behaviour of OfString
+type ParseOption func(options *parseOptions)
+
+type parseOptions struct {
+	referenceTime time.Time
+	maxFutureSkew time.Duration
+}
+
+func buildParseOptions(opts []ParseOption) *parseOptions {
+	options := &parseOptions{
+		referenceTime: time.Now(),
+		maxFutureSkew: DefaultMaxFutureSkew,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithReferenceTime makes OfString resolve the month and year of the issuing time relative to the given reference time
+// instead of the current wall-clock time. This is useful for backfilling historical METARs.
+func WithReferenceTime(reference time.Time) ParseOption {
+	return func(options *parseOptions) {
+		options.referenceTime = reference
+	}
+}
+
+// WithMaxFutureSkew sets how far in the future (relative to the reference time) an issuing time may lie before it is
+// assumed to belong to the previous month
+func WithMaxFutureSkew(skew time.Duration) ParseOption {
+	return func(options *parseOptions) {
+		if skew < 0 {
+			skew = 0
+		}
+		options.maxFutureSkew = skew
+	}
+}
+
+// ResolveIssuingTime resolves the full issuing time of a report that only specifies its day of month, hour and minute.
+// The month and year are chosen so that the resulting time is the latest possible one that does not lie more than
+// maxFutureSkew after the reference time. This way a report issued on the 31st that is fed on the 1st of the next month
+// is correctly assigned to the previous month (and year, if applicable).
+// ok is false if no month around the reference time results in a valid time.
+func ResolveIssuingTime(day, hour, minute int, reference time.Time, maxFutureSkew time.Duration) (time.Time, bool) {
+	if day < 1 || day > 31 || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return time.Time{}, false
+	}
+
+	reference = reference.UTC()
+	latest := reference.Add(maxFutureSkew)
+
+	// Check the next month (in case of a reference time slightly before the turn of the month), the current one and
+	// the two previous ones (a day may not exist in the previous month, i.e. the 31st)
+	for offset := 1; offset >= -2; offset-- {
+		candidate := time.Date(reference.Year(), reference.Month()+time.Month(offset), day, hour, minute, 0, 0, time.UTC)
+
+		// time.Date normalizes overflowing days into the next month, in which case the day does not exist in this month
+		if candidate.Day() != day {
+			continue
+		}
+		if !candidate.After(latest) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
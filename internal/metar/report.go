@@ -0,0 +1,380 @@
+package metar
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SpeedUnit represents the unit a wind speed is given in
+type SpeedUnit string
+
+const (
+	SpeedUnitKnots             SpeedUnit = "KT"
+	SpeedUnitMetersPerSecond   SpeedUnit = "MPS"
+	SpeedUnitKilometersPerHour SpeedUnit = "KMH"
+)
+
+// Knots converts a speed given in the unit to knots
+func (unit SpeedUnit) Knots(speed float64) float64 {
+	switch unit {
+	case SpeedUnitMetersPerSecond:
+		return speed * 1.943844
+	case SpeedUnitKilometersPerHour:
+		return speed * 0.539957
+	}
+	return speed
+}
+
+// DistanceUnit represents the unit a visibility is given in
+type DistanceUnit string
+
+const (
+	DistanceUnitMeters       DistanceUnit = "M"
+	DistanceUnitFeet         DistanceUnit = "FT"
+	DistanceUnitStatuteMiles DistanceUnit = "SM"
+)
+
+// PressureUnit represents the unit an altimeter setting is given in
+type PressureUnit string
+
+const (
+	PressureUnitHectopascals    PressureUnit = "hPa"
+	PressureUnitInchesOfMercury PressureUnit = "inHg"
+)
+
+// Report represents a fully decoded METAR.
+// Every group that is not present in the raw METAR (or could not be decoded) is left at its zero value.
+type Report struct {
+	// Type is empty if the raw METAR does not start with its report type
+	Type      ReportType `json:"type,omitempty"`
+	StationID string     `json:"station_id"`
+	Day       int        `json:"day"`
+	Hour      int        `json:"hour"`
+	Minute    int        `json:"minute"`
+	Automated bool       `json:"automated"`
+	Corrected bool       `json:"corrected"`
+	Nil       bool       `json:"nil"`
+
+	Wind                 *Wind                  `json:"wind,omitempty"`
+	CAVOK                bool                   `json:"cavok"`
+	Visibility           *Visibility            `json:"visibility,omitempty"`
+	MinimumVisibility    *DirectionalVisibility `json:"minimum_visibility,omitempty"`
+	RunwayVisualRanges   []*RunwayVisualRange   `json:"runway_visual_ranges"`
+	Weather              []*Weather             `json:"weather"`
+	NoSignificantWeather bool                   `json:"no_significant_weather"`
+	SkyCondition         string                 `json:"sky_condition,omitempty"`
+	VerticalVisibility   *int                   `json:"vertical_visibility,omitempty"`
+	Clouds               []*CloudLayer          `json:"clouds"`
+	Temperature          *int                   `json:"temperature,omitempty"`
+	DewPoint             *int                   `json:"dew_point,omitempty"`
+	Altimeter            *Altimeter             `json:"altimeter,omitempty"`
+	RecentWeather        []*Weather             `json:"recent_weather"`
+	WindShear            []*WindShear           `json:"wind_shear"`
+	RunwayStates         []string               `json:"runway_states"`
+	Trend                string                 `json:"trend,omitempty"`
+	Remarks              string                 `json:"remarks,omitempty"`
+
+	// Errors contains an error for every group that could not be decoded.
+	// The index of every error points to the byte offset of the group inside the decoded string.
+	Errors []*FormatError `json:"errors"`
+}
+
+// Wind represents the wind group of a METAR
+type Wind struct {
+	// Direction is nil if the wind direction is variable or was not observed
+	Direction *int `json:"direction"`
+	Variable  bool `json:"variable"`
+
+	// Speed is nil if the wind speed was not observed
+	Speed *int      `json:"speed"`
+	Gust  *int      `json:"gust,omitempty"`
+	Unit  SpeedUnit `json:"unit"`
+
+	// VariableFrom and VariableTo represent the optional variable wind direction sector
+	VariableFrom *int `json:"variable_from,omitempty"`
+	VariableTo   *int `json:"variable_to,omitempty"`
+}
+
+// Calm returns whether the wind group reports calm wind
+func (wind *Wind) Calm() bool {
+	return wind.Speed != nil && *wind.Speed == 0 && wind.Gust == nil
+}
+
+// Visibility represents the prevailing visibility group of a METAR
+type Visibility struct {
+	Value float64      `json:"value"`
+	Unit  DistanceUnit `json:"unit"`
+
+	// LessThan and MoreThan indicate that the actual visibility is below or above the given value.
+	// '9999' is decoded as 10000 meters with MoreThan set.
+	LessThan bool `json:"less_than,omitempty"`
+	MoreThan bool `json:"more_than,omitempty"`
+
+	NoDirectionalVariation bool `json:"no_directional_variation,omitempty"`
+}
+
+// Meters returns the visibility value converted to meters
+func (vis *Visibility) Meters() float64 {
+	if vis.Unit == DistanceUnitStatuteMiles {
+		return vis.Value * 1609.344
+	}
+	return vis.Value
+}
+
+// DirectionalVisibility represents the minimum visibility group of a METAR
+type DirectionalVisibility struct {
+	Value     int    `json:"value"`
+	Direction string `json:"direction"`
+}
+
+// RunwayVisualRange represents a single RVR group of a METAR
+type RunwayVisualRange struct {
+	Runway  string             `json:"runway"`
+	Value   *RunwayVisualValue `json:"value"`
+	Maximum *RunwayVisualValue `json:"maximum,omitempty"`
+	Unit    DistanceUnit       `json:"unit"`
+
+	// Tendency is one of 'U' (upward), 'D' (downward), 'N' (no change) or empty
+	Tendency string `json:"tendency,omitempty"`
+}
+
+// RunwayVisualValue represents a single visual range value inside an RVR group
+type RunwayVisualValue struct {
+	Value    int  `json:"value"`
+	LessThan bool `json:"less_than,omitempty"`
+	MoreThan bool `json:"more_than,omitempty"`
+}
+
+// Weather represents a present or recent weather group of a METAR
+type Weather struct {
+	// Intensity is one of '-' (light), '+' (heavy), 'VC' (in the vicinity) or empty (moderate)
+	Intensity  string   `json:"intensity,omitempty"`
+	Descriptor string   `json:"descriptor,omitempty"`
+	Phenomena  []string `json:"phenomena"`
+	Raw        string   `json:"raw"`
+}
+
+// CloudLayer represents a single cloud layer group of a METAR
+type CloudLayer struct {
+	Cover string `json:"cover"`
+
+	// Height is the height of the cloud base in feet; nil if it was not observed
+	Height *int `json:"height"`
+
+	// Type is one of 'CB' (cumulonimbus), 'TCU' (towering cumulus) or empty
+	Type string `json:"type,omitempty"`
+}
+
+// Altimeter represents the altimeter setting group of a METAR
+type Altimeter struct {
+	Value float64      `json:"value"`
+	Unit  PressureUnit `json:"unit"`
+}
+
+// Hectopascals returns the altimeter setting converted to hectopascals
+func (alt *Altimeter) Hectopascals() float64 {
+	if alt.Unit == PressureUnitInchesOfMercury {
+		return alt.Value * 33.8639
+	}
+	return alt.Value
+}
+
+// WindShear represents a wind shear group of a METAR
+type WindShear struct {
+	Runway     string `json:"runway,omitempty"`
+	AllRunways bool   `json:"all_runways"`
+}
+
+// Token represents a single whitespace-separated group of a raw report together with its byte offset
+type Token struct {
+	Value string
+	Index int
+}
+
+// Tokenize splits a raw report (METAR or TAF) into its whitespace-separated groups.
+// A trailing '=' terminator (as used in bulletins) is removed.
+func Tokenize(raw string) []Token {
+	var tokens []Token
+	start := -1
+	for i := 0; i <= len(raw); i++ {
+		if i == len(raw) || unicode.IsSpace(rune(raw[i])) {
+			if start >= 0 {
+				tokens = append(tokens, Token{Value: raw[start:i], Index: start})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+
+	// Reports taken out of bulletins may still carry their '=' terminator
+	if n := len(tokens); n > 0 {
+		tokens[n-1].Value = strings.TrimSuffix(tokens[n-1].Value, "=")
+		if tokens[n-1].Value == "" {
+			tokens = tokens[:n-1]
+		}
+	}
+	return tokens
+}
+
+// Decode fully decodes a raw METAR string.
+// Only errors in the header (report type, station ID and issuing time) cause this function to fail. Every other
+// group that could not be decoded is skipped and recorded in Report.Errors so that the remaining groups are still
+// available.
+func Decode(raw string) (*Report, error) {
+	for i := 0; i < len(raw); i++ {
+		if raw[i] > unicode.MaxASCII {
+			return nil, ErrNoASCIIString
+		}
+	}
+
+	report := newReport()
+	tokens := Tokenize(raw)
+	i, err := decodeHeader(tokens, report)
+	if err != nil {
+		return nil, err
+	}
+
+	// A NIL report does not contain any more information
+	if report.Nil {
+		return report, nil
+	}
+
+	decodeBody(raw, tokens[i:], report)
+	return report, nil
+}
+
+// DecodeGroups decodes a sequence of body groups (i.e. the forecast groups of a TAF period) without a header.
+// Just like with Decode, every group that could not be decoded is recorded in Report.Errors; the header fields of the
+// returned report are left empty.
+func DecodeGroups(raw string) *Report {
+	report := newReport()
+	decodeBody(raw, Tokenize(raw), report)
+	return report
+}
+
+func newReport() *Report {
+	return &Report{
+		RunwayVisualRanges: []*RunwayVisualRange{},
+		Weather:            []*Weather{},
+		Clouds:             []*CloudLayer{},
+		RecentWeather:      []*Weather{},
+		WindShear:          []*WindShear{},
+		RunwayStates:       []string{},
+		Errors:             []*FormatError{},
+	}
+}
+
+// decodeBody decodes the given body tokens of the raw string into the given report
+func decodeBody(raw string, tokens []Token, report *Report) {
+	for i := 0; i < len(tokens); {
+		tok := tokens[i]
+
+		// Everything after 'RMK' is kept as the unparsed remarks section
+		if tok.Value == "RMK" {
+			if i+1 < len(tokens) {
+				report.Remarks = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(raw[tokens[i+1].Index:]), "="))
+			}
+			break
+		}
+
+		// Everything between a trend indicator and the remarks section is kept as the unparsed trend section
+		if tok.Value == "NOSIG" || tok.Value == "BECMG" || tok.Value == "TEMPO" {
+			end := len(tokens)
+			for j := i + 1; j < len(tokens); j++ {
+				if tokens[j].Value == "RMK" {
+					end = j
+					break
+				}
+			}
+			last := tokens[end-1]
+			report.Trend = raw[tok.Index : last.Index+len(last.Value)]
+			i = end
+			continue
+		}
+
+		consumed := 0
+		for _, decoder := range groupDecoders {
+			n, err := decoder(tokens, i, report)
+			if n == 0 {
+				continue
+			}
+			if err != nil {
+				report.Errors = append(report.Errors, errInvalidGroup(tok.Value, err.Error(), tok.Index))
+			}
+			consumed = n
+			break
+		}
+		if consumed == 0 {
+			report.Errors = append(report.Errors, errUnrecognizedGroup(tok.Value, tok.Index))
+			consumed = 1
+		}
+		i += consumed
+	}
+}
+
+// decodeHeader decodes the report type, modifiers, station ID and issuing time of a METAR and returns the index of
+// the first token of the report body
+func decodeHeader(tokens []Token, report *Report) (int, error) {
+	i := 0
+	if i < len(tokens) && (tokens[i].Value == string(ReportTypeMETAR) || tokens[i].Value == string(ReportTypeSPECI)) {
+		report.Type = ReportType(tokens[i].Value)
+		i++
+	}
+	if i < len(tokens) && tokens[i].Value == "COR" {
+		report.Corrected = true
+		i++
+	}
+
+	if i >= len(tokens) {
+		return 0, ErrMETARIncomplete
+	}
+	stationID := tokens[i].Value
+	if len(stationID) != 4 {
+		return 0, ErrInvalidStationID
+	}
+	for _, char := range stationID {
+		if !unicode.IsUpper(char) && !unicode.IsDigit(char) {
+			return 0, ErrInvalidStationID
+		}
+	}
+	report.StationID = stationID
+	i++
+
+	if i >= len(tokens) {
+		return 0, ErrMETARIncomplete
+	}
+	timeSection := tokens[i].Value
+	if len(timeSection) != 7 || timeSection[6] != 'Z' {
+		return 0, ErrInvalidMETARTime
+	}
+	for j := 0; j < 6; j++ {
+		if !unicode.IsDigit(rune(timeSection[j])) {
+			return 0, ErrInvalidMETARTime
+		}
+	}
+	report.Day, _ = strconv.Atoi(timeSection[:2])
+	report.Hour, _ = strconv.Atoi(timeSection[2:4])
+	report.Minute, _ = strconv.Atoi(timeSection[4:6])
+	if report.Day < 1 || report.Day > 31 || report.Hour > 23 || report.Minute > 59 {
+		return 0, ErrInvalidMETARTime
+	}
+	i++
+
+	for ; i < len(tokens); i++ {
+		switch tokens[i].Value {
+		case "NIL":
+			report.Nil = true
+		case "AUTO":
+			report.Automated = true
+		case "COR":
+			report.Corrected = true
+		default:
+			return i, nil
+		}
+	}
+	return i, nil
+}
@@ -3,29 +3,136 @@ package metar
 import (
 	"context"
 	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/audit"
 )
 
 // Repository defines the METAR repository API
 type Repository interface {
-	// GetByFilter retrieves multiple METARs following a filter, ordered by their issuing date (descending).
+	// GetByFilter retrieves multiple METARs following a filter, ordered by their issuing date and ID (descending).
+	// METARs superseded by a correction are only included if Filter.IncludeSuperseded is set.
+	// The returned count is the total amount of METARs following the filter (ignoring Filter.Cursor); it is 0 if
+	// Filter.SkipCount is set.
 	// If limit <= 0, a default limit value of 10 is used.
 	GetByFilter(ctx context.Context, filter *Filter, limit uint64) ([]*METAR, uint64, error)
 
+	// GetLatestByFilter retrieves the latest METAR of every station that has at least one METAR following a filter.
+	// METARs superseded by a correction are only considered if Filter.IncludeSuperseded is set.
+	GetLatestByFilter(ctx context.Context, filter *Filter) ([]*METAR, error)
+
+	// GetLatestByStations retrieves the latest METAR that is not superseded by a correction of every given station.
+	// Stations without any METAR are not contained in the resulting map.
+	GetLatestByStations(ctx context.Context, stationIDs []string) (map[string]*METAR, error)
+
+	// GetSinceSequence retrieves up to limit METARs whose sequence number is greater than the given one, ordered by their
+	// sequence number (ascending). If stationIDs is not nil, only METARs of the given stations are included.
+	// Implementations guarantee that no METAR with a lower sequence number than a returned one becomes visible later on,
+	// so that the highest returned sequence number can be used to resume.
+	GetSinceSequence(ctx context.Context, sequence int64, stationIDs []string, limit uint64) ([]*METAR, error)
+
 	// GetByID retrieves a METAR by its ID
 	GetByID(ctx context.Context, id uuid.UUID) (*METAR, error)
 
 	// Create creates new METARs based on their raw text representation.
 	// All raw strings are sanitized (leading and trailing spaces are trimmed).
-	// This method also returns the indexes of the METARs that already exist in the database and thus were not inserted.
-	Create(ctx context.Context, raw []string) ([]*METAR, []uint, error)
+	// The outcome of every raw string is returned (in the order of the raw strings); a single malformed one rejects all
+	// of them with a *FormatError. METARs that already exist in the database are not inserted; the API key feeding
+	// them is added to their feeders instead.
+	// fedBy is the ID of the API key feeding the METARs; it is nil if they were not fed through the data API.
+	// The given parse options are passed to OfString for every raw string.
+	// Corrections (COR) supersede the other METARs of the same station and issuing time.
+	Create(ctx context.Context, raw []string, fedBy *uuid.UUID, opts ...ParseOption) ([]*CreateResult, error)
+
+	// CreatePartially creates new METARs based on their raw text representation just like Create, but malformed raw
+	// strings do not prevent the other METARs from being stored. Instead, the outcome of every raw string is returned
+	// (in the order of the raw strings).
+	CreatePartially(ctx context.Context, raw []string, fedBy *uuid.UUID, opts ...ParseOption) ([]*CreateResult, error)
 
-	// Delete deletes a METAR by its ID
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Delete deletes a METAR by its ID and issuing time (which determines the partition it is stored in).
+	// If entry is not nil, it is appended to the audit trail in the same transaction.
+	Delete(ctx context.Context, id uuid.UUID, issuedAt int64, entry *audit.Create) error
+
+	// DeleteByFilter deletes all METARs following a filter (ignoring Filter.Cursor) and returns the amount of deleted
+	// METARs. METARs superseded by a correction are only included if Filter.IncludeSuperseded is set.
+	// The supersession links of the remaining METARs of the affected stations and issuing times are restored.
+	// If entry is not nil, its affected count is set and it is appended to the audit trail in the same transaction.
+	DeleteByFilter(ctx context.Context, filter *Filter, entry *audit.Create) (int64, error)
+
+	// Reparse re-derives the report modifiers and the flight category of all METARs following a filter (ignoring
+	// Filter.Cursor) from their raw text representation, e.g. after the decoder was improved.
+	// The station, issuing time and report type of the METARs are left untouched.
+	Reparse(ctx context.Context, filter *Filter) (*ReparseResult, error)
 }
 
 // Filter is used to query METARs based on a filter
 type Filter struct {
-	StationID    *string
+	StationID *string
+
+	// StationIDs restricts the query to the given stations if it is not nil
+	StationIDs []string
+
 	IssuedBefore *int64
 	IssuedAfter  *int64
+	Type         *ReportType
+	Corrected    *bool
+	Automated    *bool
+	Nil          *bool
+
+	FlightCategory *FlightCategory
+
+	// FedBy restricts the query to the METARs first fed by the given API key
+	FedBy          *uuid.UUID
+	ReceivedBefore *int64
+	ReceivedAfter  *int64
+
+	// IncludeSuperseded defines whether METARs superseded by a correction should be included
+	IncludeSuperseded bool
+
+	// Cursor restricts the query to the METARs that come after the given cursor (see Repository.GetByFilter)
+	Cursor *Cursor
+
+	// SkipCount defines whether counting the total amount of matching METARs should be skipped
+	SkipCount bool
+}
+
+// IsEmpty returns whether the filter does not restrict the METARs by any of their properties.
+// Filter.IncludeSuperseded, Filter.Cursor and Filter.SkipCount are not considered.
+func (filter *Filter) IsEmpty() bool {
+	return filter.StationID == nil && filter.StationIDs == nil && filter.IssuedBefore == nil && filter.IssuedAfter == nil &&
+		filter.Type == nil && filter.Corrected == nil && filter.Automated == nil && filter.Nil == nil &&
+		filter.FlightCategory == nil && filter.FedBy == nil && filter.ReceivedBefore == nil && filter.ReceivedAfter == nil
+}
+
+// CreateStatus represents the outcome of storing a single raw METAR
+type CreateStatus string
+
+const (
+	CreateStatusCreated   CreateStatus = "created"
+	CreateStatusDuplicate CreateStatus = "duplicate"
+	CreateStatusInvalid   CreateStatus = "invalid"
+)
+
+// CreateResult represents the outcome of storing a single raw METAR (see Repository.CreatePartially)
+type CreateResult struct {
+	Status CreateStatus
+
+	// METAR is the stored METAR if it was created
+	METAR *METAR
+
+	// ExistingID is the ID of the already stored METAR if the METAR is a duplicate
+	ExistingID *uuid.UUID
+
+	// Error describes why the raw string could not be parsed if it is invalid
+	Error error
+}
+
+// ReparseResult represents the outcome of re-parsing stored METARs
+type ReparseResult struct {
+	// Processed is the amount of METARs that were re-parsed
+	Processed int64 `json:"processed"`
+
+	// Changed is the amount of METARs whose derived values changed
+	Changed int64 `json:"changed"`
+
+	// Failed is the amount of METARs that could not be decoded anymore; they are left unchanged
+	Failed int64 `json:"failed"`
 }
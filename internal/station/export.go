@@ -0,0 +1,63 @@
+package station
+
+import (
+	"encoding/xml"
+	"strconv"
+)
+
+// CSVHeader returns the columns of the CSV representation of a station
+func (station *Station) CSVHeader() []string {
+	return []string{"icao", "iata", "name", "type", "latitude", "longitude", "elevation", "country", "region", "municipality"}
+}
+
+// CSVRecord returns the CSV representation of the station (see CSVHeader)
+func (station *Station) CSVRecord() []string {
+	elevation := ""
+	if station.Elevation != nil {
+		elevation = strconv.Itoa(*station.Elevation)
+	}
+	return []string{
+		station.ICAO,
+		station.IATA,
+		station.Name,
+		station.Type,
+		strconv.FormatFloat(station.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(station.Longitude, 'f', -1, 64),
+		elevation,
+		station.Country,
+		station.Region,
+		station.Municipality,
+	}
+}
+
+// xmlStation represents the XML representation of a station; its layout follows the one of the aviation weather data
+// server
+type xmlStation struct {
+	XMLName      xml.Name `xml:"Station"`
+	StationID    string   `xml:"station_id"`
+	IATAID       string   `xml:"iata_id,omitempty"`
+	Site         string   `xml:"site"`
+	SiteType     string   `xml:"site_type"`
+	Latitude     float64  `xml:"latitude"`
+	Longitude    float64  `xml:"longitude"`
+	ElevationFt  *int     `xml:"elevation_ft,omitempty"`
+	Country      string   `xml:"country"`
+	Region       string   `xml:"region,omitempty"`
+	Municipality string   `xml:"municipality,omitempty"`
+}
+
+// MarshalXML writes the XML representation of the station as a 'Station' element
+func (station *Station) MarshalXML(encoder *xml.Encoder, _ xml.StartElement) error {
+	return encoder.Encode(&xmlStation{
+		StationID:    station.ICAO,
+		IATAID:       station.IATA,
+		Site:         station.Name,
+		SiteType:     station.Type,
+		Latitude:     station.Latitude,
+		Longitude:    station.Longitude,
+		ElevationFt:  station.Elevation,
+		Country:      station.Country,
+		Region:       station.Region,
+		Municipality: station.Municipality,
+	})
+}
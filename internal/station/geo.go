@@ -0,0 +1,94 @@
+package station
+
+import "math"
+
+// earthRadius is the mean radius of the earth in kilometers
+const earthRadius = 6371.0088
+
+// Bounds represents a geographic bounding box.
+// If MinLongitude is greater than MaxLongitude, the box crosses the antimeridian.
+type Bounds struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// BoundsAround returns the smallest bounding box containing every point within the given radius (in kilometers)
+// around the given coordinates
+func BoundsAround(latitude, longitude, radius float64) *Bounds {
+	angularRadius := radius / earthRadius
+	latitudeDelta := angularRadius * 180 / math.Pi
+	bounds := &Bounds{
+		MinLatitude:  latitude - latitudeDelta,
+		MinLongitude: -180,
+		MaxLatitude:  latitude + latitudeDelta,
+		MaxLongitude: 180,
+	}
+
+	// The longitude range covers the whole earth if the circle contains one of the poles
+	if bounds.MinLatitude <= -90 || bounds.MaxLatitude >= 90 {
+		bounds.MinLatitude = math.Max(bounds.MinLatitude, -90)
+		bounds.MaxLatitude = math.Min(bounds.MaxLatitude, 90)
+		return bounds
+	}
+
+	longitudeDelta := math.Asin(math.Sin(angularRadius)/math.Cos(latitude*math.Pi/180)) * 180 / math.Pi
+	bounds.MinLongitude = normalizeLongitude(longitude - longitudeDelta)
+	bounds.MaxLongitude = normalizeLongitude(longitude + longitudeDelta)
+	return bounds
+}
+
+// Contains returns whether the given coordinates lie inside the bounding box
+func (bounds *Bounds) Contains(latitude, longitude float64) bool {
+	if latitude < bounds.MinLatitude || latitude > bounds.MaxLatitude {
+		return false
+	}
+	if bounds.MinLongitude > bounds.MaxLongitude {
+		return longitude >= bounds.MinLongitude || longitude <= bounds.MaxLongitude
+	}
+	return longitude >= bounds.MinLongitude && longitude <= bounds.MaxLongitude
+}
+
+// LongitudeSpan returns the amount of degrees of longitude the bounding box spans
+func (bounds *Bounds) LongitudeSpan() float64 {
+	if bounds.MinLongitude > bounds.MaxLongitude {
+		return bounds.MaxLongitude - bounds.MinLongitude + 360
+	}
+	return bounds.MaxLongitude - bounds.MinLongitude
+}
+
+// Center returns the coordinates of the center of the bounding box
+func (bounds *Bounds) Center() (float64, float64) {
+	latitude := (bounds.MinLatitude + bounds.MaxLatitude) / 2
+	if bounds.MinLongitude > bounds.MaxLongitude {
+		return latitude, normalizeLongitude((bounds.MinLongitude + bounds.MaxLongitude + 360) / 2)
+	}
+	return latitude, (bounds.MinLongitude + bounds.MaxLongitude) / 2
+}
+
+// Distance calculates the great-circle distance in kilometers between two coordinates using the haversine formula
+func Distance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	phi1 := latitude1 * math.Pi / 180
+	phi2 := latitude2 * math.Pi / 180
+	deltaPhi := (latitude2 - latitude1) * math.Pi / 180
+	deltaLambda := (longitude2 - longitude1) * math.Pi / 180
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// DistanceTo calculates the great-circle distance in kilometers between the station and the given coordinates
+func (station *Station) DistanceTo(latitude, longitude float64) float64 {
+	return Distance(station.Latitude, station.Longitude, latitude, longitude)
+}
+
+func normalizeLongitude(longitude float64) float64 {
+	for longitude > 180 {
+		longitude -= 360
+	}
+	for longitude < -180 {
+		longitude += 360
+	}
+	return longitude
+}
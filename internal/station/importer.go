@@ -0,0 +1,177 @@
+package station
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ourAirportsRequiredColumns contains the columns every OurAirports-style CSV file has to provide
+var ourAirportsRequiredColumns = []string{"ident", "type", "name", "latitude_deg", "longitude_deg", "iso_country"}
+
+// ErrMissingColumn is returned by ParseOurAirportsCSV if the header of the CSV file lacks a required column
+var ErrMissingColumn = errors.New("missing required column")
+
+// RowError describes a malformed row of a CSV file that was skipped
+type RowError struct {
+	Line int
+	Err  error
+}
+
+// Error returns the error message including the line of the row
+func (err *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", err.Line, err.Err.Error())
+}
+
+// Unwrap returns the wrapped error
+func (err *RowError) Unwrap() error {
+	return err.Err
+}
+
+// ImportResult represents the outcome of importing a station file
+type ImportResult struct {
+	// Affected is the amount of created or updated stations
+	Affected int
+
+	// Skipped contains an error for every malformed row that was skipped
+	Skipped []*RowError
+}
+
+// ImportFile parses the OurAirports-style CSV file at the given path (see ParseOurAirportsCSV) and upserts all of its
+// valid stations using the given repository. Malformed rows do not abort the import; they are reported in the result
+// instead.
+func ImportFile(ctx context.Context, repo Repository, path string) (*ImportResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stations, skipped, err := ParseOurAirportsCSV(file)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := repo.Upsert(ctx, stations)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportResult{
+		Affected: affected,
+		Skipped:  skipped,
+	}, nil
+}
+
+// ParseOurAirportsCSV parses a CSV file in the format of the OurAirports 'airports.csv' data set.
+// The columns are looked up by the names given in the header row, so additional columns and a different column order
+// are supported.
+// Only stations with a valid ICAO code are returned; the code is taken from the 'icao_code', 'gps_code' or 'ident'
+// column (in that order). Closed stations are skipped.
+// Malformed rows are skipped as well and returned as row errors; only an unreadable file or header causes an error.
+func ParseOurAirportsCSV(reader io.Reader) ([]*Station, []*RowError, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.ReuseRecord = true
+
+	// Rows with missing trailing columns are handled like rows with empty ones
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range ourAirportsRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("%w '%s'", ErrMissingColumn, name)
+		}
+	}
+	column := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	stations := []*Station{}
+	skipped := []*RowError{}
+	seen := make(map[string]struct{})
+	for {
+		record, err := csvReader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				skipped = append(skipped, &RowError{Line: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return nil, nil, err
+		}
+		line, _ := csvReader.FieldPos(0)
+
+		if column(record, "type") == "closed" {
+			continue
+		}
+
+		icao := ""
+		for _, candidate := range []string{column(record, "icao_code"), column(record, "gps_code"), column(record, "ident")} {
+			if IsValidICAO(candidate) {
+				icao = candidate
+				break
+			}
+		}
+		if icao == "" {
+			continue
+		}
+
+		// The data set may contain multiple entries referring to the same ICAO code; the first one wins
+		if _, ok := seen[icao]; ok {
+			continue
+		}
+
+		latitude, err := strconv.ParseFloat(column(record, "latitude_deg"), 64)
+		if err != nil || math.Abs(latitude) > 90 {
+			skipped = append(skipped, &RowError{Line: line, Err: fmt.Errorf("invalid latitude of station '%s'", icao)})
+			continue
+		}
+		longitude, err := strconv.ParseFloat(column(record, "longitude_deg"), 64)
+		if err != nil || math.Abs(longitude) > 180 {
+			skipped = append(skipped, &RowError{Line: line, Err: fmt.Errorf("invalid longitude of station '%s'", icao)})
+			continue
+		}
+
+		station := &Station{
+			ICAO:         icao,
+			IATA:         column(record, "iata_code"),
+			Name:         column(record, "name"),
+			Type:         column(record, "type"),
+			Latitude:     latitude,
+			Longitude:    longitude,
+			Country:      column(record, "iso_country"),
+			Region:       column(record, "iso_region"),
+			Municipality: column(record, "municipality"),
+		}
+		if raw := column(record, "elevation_ft"); raw != "" {
+			elevation, err := strconv.Atoi(raw)
+			if err != nil {
+				skipped = append(skipped, &RowError{Line: line, Err: fmt.Errorf("invalid elevation of station '%s'", icao)})
+				continue
+			}
+			station.Elevation = &elevation
+		}
+
+		seen[icao] = struct{}{}
+		stations = append(stations, station)
+	}
+	return stations, skipped, nil
+}
@@ -0,0 +1,35 @@
+package station
+
+import "context"
+
+// Repository defines the station repository API
+type Repository interface {
+	// GetByFilter retrieves multiple stations following a filter, ordered by their ICAO code
+	GetByFilter(ctx context.Context, filter *Filter, offset, limit uint64) ([]*Station, uint64, error)
+
+	// GetByICAO retrieves a station by its ICAO code
+	GetByICAO(ctx context.Context, icao string) (*Station, error)
+
+	// GetByICAOs retrieves multiple stations by their ICAO codes.
+	// Unknown stations are not contained in the resulting map.
+	GetByICAOs(ctx context.Context, icaos []string) (map[string]*Station, error)
+
+	// GetWithinBounds retrieves the stations located inside the given bounding box, at most limit ones
+	GetWithinBounds(ctx context.Context, bounds *Bounds, limit uint64) ([]*Station, error)
+
+	// Upsert creates the given stations or updates them if a station with the same ICAO code already exists.
+	// It returns the amount of affected stations.
+	Upsert(ctx context.Context, stations []*Station) (int, error)
+
+	// Delete deletes a station by its ICAO code
+	Delete(ctx context.Context, icao string) error
+}
+
+// Filter is used to query stations based on a filter
+type Filter struct {
+	Country *string
+	Type    *string
+
+	// Search matches all stations whose ICAO code, IATA code or name contains the given string (case-insensitive)
+	Search *string
+}
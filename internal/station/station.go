@@ -0,0 +1,39 @@
+package station
+
+import "unicode"
+
+// Station represents a single weather station (usually an aerodrome) identified by its ICAO code
+type Station struct {
+	ICAO string `json:"icao"`
+	IATA string `json:"iata,omitempty"`
+	Name string `json:"name"`
+
+	// Type is the type of the station as given by the imported data set (i.e. 'large_airport' or 'heliport')
+	Type string `json:"type"`
+
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+
+	// Elevation is the elevation of the station in feet; nil if it is unknown
+	Elevation *int `json:"elevation"`
+
+	// Country is the ISO 3166-1 alpha-2 code of the country the station is located in
+	Country string `json:"country"`
+
+	// Region is the ISO 3166-2 code of the region the station is located in
+	Region       string `json:"region,omitempty"`
+	Municipality string `json:"municipality,omitempty"`
+}
+
+// IsValidICAO returns whether the given string is a syntactically valid ICAO location indicator
+func IsValidICAO(icao string) bool {
+	if len(icao) != 4 {
+		return false
+	}
+	for _, char := range icao {
+		if !unicode.IsUpper(char) && !unicode.IsDigit(char) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/alert"
+	"github.com/skybi/pluteo/internal/hashmap"
+)
+
+// AlertRuleRepository implements the alert.Repository interface in order to implement caching
+type AlertRuleRepository struct {
+	repo  alert.Repository
+	cache *hashmap.ExpiringMap[uuid.UUID, *alert.Rule]
+}
+
+var _ alert.Repository = (*AlertRuleRepository)(nil)
+
+// Get retrieves multiple alert rules
+func (repo *AlertRuleRepository) Get(ctx context.Context, offset, limit uint64) ([]*alert.Rule, uint64, error) {
+	rules, n, err := repo.repo.Get(ctx, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, rule := range rules {
+		repo.cache.Set(rule.ID, rule)
+	}
+	return rules, n, nil
+}
+
+// GetByUserID retrieves multiple alert rules of a specific user
+func (repo *AlertRuleRepository) GetByUserID(ctx context.Context, userID string, offset, limit uint64) ([]*alert.Rule, uint64, error) {
+	rules, n, err := repo.repo.GetByUserID(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, rule := range rules {
+		repo.cache.Set(rule.ID, rule)
+	}
+	return rules, n, nil
+}
+
+// GetByID retrieves an alert rule by its ID
+func (repo *AlertRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*alert.Rule, error) {
+	cached, ok := repo.cache.Lookup(id)
+	if ok {
+		return cached, nil
+	}
+	rule, err := repo.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil {
+		repo.cache.Set(rule.ID, rule)
+	}
+	return rule, nil
+}
+
+// GetEnabledByStation retrieves all enabled alert rules of a specific station
+func (repo *AlertRuleRepository) GetEnabledByStation(ctx context.Context, stationID string) ([]*alert.Rule, error) {
+	rules, err := repo.repo.GetEnabledByStation(ctx, stationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		repo.cache.Set(rule.ID, rule)
+	}
+	return rules, nil
+}
+
+// Create creates a new alert rule
+func (repo *AlertRuleRepository) Create(ctx context.Context, create *alert.Create) (*alert.Rule, error) {
+	rule, err := repo.repo.Create(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+	repo.cache.Set(rule.ID, rule)
+	return rule, nil
+}
+
+// Update updates an alert rule
+func (repo *AlertRuleRepository) Update(ctx context.Context, id uuid.UUID, update *alert.Update) (*alert.Rule, error) {
+	rule, err := repo.repo.Update(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		repo.cache.Unset(id)
+		return nil, nil
+	}
+	repo.cache.Set(rule.ID, rule)
+	return rule, nil
+}
+
+// Delete deletes an alert rule by its ID
+func (repo *AlertRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := repo.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	repo.cache.Unset(id)
+	return nil
+}
@@ -3,11 +3,17 @@ package cache
 import (
 	"context"
 	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/alert"
 	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/audit"
+	"github.com/skybi/pluteo/internal/coverage"
 	"github.com/skybi/pluteo/internal/hashmap"
 	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/station"
 	"github.com/skybi/pluteo/internal/storage"
+	"github.com/skybi/pluteo/internal/taf"
 	"github.com/skybi/pluteo/internal/user"
+	"github.com/skybi/pluteo/internal/webhook"
 	"time"
 )
 
@@ -17,6 +23,10 @@ type Driver struct {
 	users      *UserRepository
 	apiKeys    *APIKeyRepository
 	metars     *METARRepository
+	tafs       *TAFRepository
+	stations   *StationRepository
+	webhooks   *WebhookRepository
+	alerts     *AlertRuleRepository
 }
 
 var _ storage.Driver = (*Driver)(nil)
@@ -49,9 +59,40 @@ func (driver *Driver) Initialize(_ context.Context) error {
 
 	metarCache := hashmap.NewExpiring[uuid.UUID, *metar.METAR](5 * time.Minute)
 	metarCache.ScheduleCleanupTask(time.Minute)
+	metarLatestCache := hashmap.NewExpiring[string, *metar.METAR](5 * time.Minute)
+	metarLatestCache.ScheduleCleanupTask(time.Minute)
 	driver.metars = &METARRepository{
-		repo:  driver.underlying.METARs(),
-		cache: metarCache,
+		repo:        driver.underlying.METARs(),
+		cache:       metarCache,
+		latestCache: metarLatestCache,
+	}
+
+	tafCache := hashmap.NewExpiring[uuid.UUID, *taf.TAF](5 * time.Minute)
+	tafCache.ScheduleCleanupTask(time.Minute)
+	driver.tafs = &TAFRepository{
+		repo:  driver.underlying.TAFs(),
+		cache: tafCache,
+	}
+
+	stationCache := hashmap.NewExpiring[string, *station.Station](5 * time.Minute)
+	stationCache.ScheduleCleanupTask(time.Minute)
+	driver.stations = &StationRepository{
+		repo:  driver.underlying.Stations(),
+		cache: stationCache,
+	}
+
+	webhookCache := hashmap.NewExpiring[uuid.UUID, *webhook.Webhook](5 * time.Minute)
+	webhookCache.ScheduleCleanupTask(time.Minute)
+	driver.webhooks = &WebhookRepository{
+		repo:  driver.underlying.Webhooks(),
+		cache: webhookCache,
+	}
+
+	alertCache := hashmap.NewExpiring[uuid.UUID, *alert.Rule](5 * time.Minute)
+	alertCache.ScheduleCleanupTask(time.Minute)
+	driver.alerts = &AlertRuleRepository{
+		repo:  driver.underlying.AlertRules(),
+		cache: alertCache,
 	}
 
 	return nil
@@ -72,6 +113,45 @@ func (driver *Driver) METARs() metar.Repository {
 	return driver.metars
 }
 
+// TAFs provides the caching TAF repository implementation
+func (driver *Driver) TAFs() taf.Repository {
+	return driver.tafs
+}
+
+// Stations provides the caching station repository implementation
+func (driver *Driver) Stations() station.Repository {
+	return driver.stations
+}
+
+// Webhooks provides the caching webhook repository implementation
+func (driver *Driver) Webhooks() webhook.Repository {
+	return driver.webhooks
+}
+
+// AlertRules provides the caching alert rule repository implementation
+func (driver *Driver) AlertRules() alert.Repository {
+	return driver.alerts
+}
+
+// Coverage provides the coverage rollup repository implementation of the underlying driver.
+// The statistics are only refreshed periodically and thus not cached.
+func (driver *Driver) Coverage() coverage.Repository {
+	return driver.underlying.Coverage()
+}
+
+// AuditLog provides the audit trail repository implementation of the underlying driver.
+// The audit trail is rarely read and thus not cached.
+func (driver *Driver) AuditLog() audit.Repository {
+	return driver.underlying.AuditLog()
+}
+
+// InvalidateMETARs discards all cached METARs.
+// It has to be called after METARs were changed in the underlying storage without using this driver.
+func (driver *Driver) InvalidateMETARs() {
+	driver.metars.cache.Clear()
+	driver.metars.latestCache.Clear()
+}
+
 // Close closes the caching repositories and disposes their instances
 func (driver *Driver) Close() {
 	driver.users.cache.StopCleanupTask()
@@ -80,5 +160,14 @@ func (driver *Driver) Close() {
 	driver.apiKeys.hashCache.StopCleanupTask()
 	driver.apiKeys = nil
 	driver.metars.cache.StopCleanupTask()
+	driver.metars.latestCache.StopCleanupTask()
 	driver.metars = nil
+	driver.tafs.cache.StopCleanupTask()
+	driver.tafs = nil
+	driver.stations.cache.StopCleanupTask()
+	driver.stations = nil
+	driver.webhooks.cache.StopCleanupTask()
+	driver.webhooks = nil
+	driver.alerts.cache.StopCleanupTask()
+	driver.alerts = nil
 }
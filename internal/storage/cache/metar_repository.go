@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/audit"
 	"github.com/skybi/pluteo/internal/hashmap"
 	"github.com/skybi/pluteo/internal/metar"
 )
@@ -11,11 +12,16 @@ import (
 type METARRepository struct {
 	repo  metar.Repository
 	cache *hashmap.ExpiringMap[uuid.UUID, *metar.METAR]
+
+	// latestCache maps station IDs to their latest METAR; a nil value marks a station without any METAR
+	latestCache *hashmap.ExpiringMap[string, *metar.METAR]
 }
 
 var _ metar.Repository = (*METARRepository)(nil)
 
-// GetByFilter retrieves multiple METARs following a filter, ordered by their issuing date (descending).
+// GetByFilter retrieves multiple METARs following a filter, ordered by their issuing date and ID (descending).
+// The returned count is the total amount of METARs following the filter (ignoring metar.Filter.Cursor); it is 0 if
+// metar.Filter.SkipCount is set.
 // If limit <= 0, a default limit value of 10 is used.
 func (repo *METARRepository) GetByFilter(ctx context.Context, filter *metar.Filter, limit uint64) ([]*metar.METAR, uint64, error) {
 	metars, n, err := repo.repo.GetByFilter(ctx, filter, limit)
@@ -28,6 +34,66 @@ func (repo *METARRepository) GetByFilter(ctx context.Context, filter *metar.Filt
 	return metars, n, nil
 }
 
+// GetLatestByFilter retrieves the latest METAR of every station that has at least one METAR following a filter.
+// METARs superseded by a correction are only considered if Filter.IncludeSuperseded is set.
+func (repo *METARRepository) GetLatestByFilter(ctx context.Context, filter *metar.Filter) ([]*metar.METAR, error) {
+	metars, err := repo.repo.GetLatestByFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range metars {
+		repo.cache.Set(obj.ID, obj)
+	}
+	return metars, nil
+}
+
+// GetLatestByStations retrieves the latest METAR that is not superseded by a correction of every given station.
+// Stations without any METAR are not contained in the resulting map.
+func (repo *METARRepository) GetLatestByStations(ctx context.Context, stationIDs []string) (map[string]*metar.METAR, error) {
+	latest := make(map[string]*metar.METAR, len(stationIDs))
+	missing := []string{}
+	for _, stationID := range stationIDs {
+		cached, ok := repo.latestCache.Lookup(stationID)
+		if !ok {
+			missing = append(missing, stationID)
+			continue
+		}
+		if cached != nil {
+			latest[stationID] = cached
+		}
+	}
+	if len(missing) == 0 {
+		return latest, nil
+	}
+
+	fetched, err := repo.repo.GetLatestByStations(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, stationID := range missing {
+		obj := fetched[stationID]
+		repo.latestCache.Set(stationID, obj)
+		if obj != nil {
+			repo.cache.Set(obj.ID, obj)
+			latest[stationID] = obj
+		}
+	}
+	return latest, nil
+}
+
+// GetSinceSequence retrieves up to limit METARs whose sequence number is greater than the given one, ordered by their
+// sequence number (ascending). If stationIDs is not nil, only METARs of the given stations are included.
+func (repo *METARRepository) GetSinceSequence(ctx context.Context, sequence int64, stationIDs []string, limit uint64) ([]*metar.METAR, error) {
+	metars, err := repo.repo.GetSinceSequence(ctx, sequence, stationIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range metars {
+		repo.cache.Set(obj.ID, obj)
+	}
+	return metars, nil
+}
+
 // GetByID retrieves a METAR by its ID
 func (repo *METARRepository) GetByID(ctx context.Context, id uuid.UUID) (*metar.METAR, error) {
 	cached, ok := repo.cache.Lookup(id)
@@ -46,24 +112,115 @@ func (repo *METARRepository) GetByID(ctx context.Context, id uuid.UUID) (*metar.
 
 // Create creates new METARs based on their raw text representation.
 // All raw strings are sanitized (leading and trailing spaces are trimmed).
-// This method also returns the indexes of the METARs that already exist in the database and thus were not inserted.
-func (repo *METARRepository) Create(ctx context.Context, raw []string) ([]*metar.METAR, []uint, error) {
-	metars, duplicates, err := repo.repo.Create(ctx, raw)
+// The outcome of every raw string is returned (in the order of the raw strings); a single malformed one rejects all of
+// them with a *metar.FormatError. METARs that already exist in the database are not inserted; the API key feeding
+// them is added to their feeders instead.
+// fedBy is the ID of the API key feeding the METARs; it is nil if they were not fed through the data API.
+// The given parse options are passed to metar.OfString for every raw string.
+func (repo *METARRepository) Create(ctx context.Context, raw []string, fedBy *uuid.UUID, opts ...metar.ParseOption) ([]*metar.CreateResult, error) {
+	results, err := repo.repo.Create(ctx, raw, fedBy, opts...)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	for _, obj := range metars {
-		repo.cache.Set(obj.ID, obj)
+	repo.cacheResults(results, fedBy)
+	return results, nil
+}
+
+// CreatePartially creates new METARs based on their raw text representation just like Create, but malformed raw
+// strings do not prevent the other METARs from being stored. Instead, the outcome of every raw string is returned
+// (in the order of the raw strings).
+func (repo *METARRepository) CreatePartially(ctx context.Context, raw []string, fedBy *uuid.UUID, opts ...metar.ParseOption) ([]*metar.CreateResult, error) {
+	results, err := repo.repo.CreatePartially(ctx, raw, fedBy, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return metars, duplicates, nil
+	repo.cacheResults(results, fedBy)
+	return results, nil
 }
 
-// Delete deletes a METAR by its ID
-func (repo *METARRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	err := repo.repo.Delete(ctx, id)
+// DeleteByFilter deletes all METARs following a filter (ignoring metar.Filter.Cursor) and returns the amount of
+// deleted METARs.
+// The supersession links of the remaining METARs of the affected stations and issuing times are restored.
+// If entry is not nil, its affected count is set and it is appended to the audit trail in the same transaction.
+func (repo *METARRepository) DeleteByFilter(ctx context.Context, filter *metar.Filter, entry *audit.Create) (int64, error) {
+	n, err := repo.repo.DeleteByFilter(ctx, filter, entry)
+	if err != nil {
+		return 0, err
+	}
+
+	// The deleted METARs and the ones whose supersession changed are not known, so all cached METARs are invalidated
+	if n > 0 {
+		repo.cache.Clear()
+		repo.latestCache.Clear()
+	}
+	return n, nil
+}
+
+// Reparse re-derives the report modifiers and the flight category of all METARs following a filter (ignoring
+// metar.Filter.Cursor) from their raw text representation
+func (repo *METARRepository) Reparse(ctx context.Context, filter *metar.Filter) (*metar.ReparseResult, error) {
+	result, err := repo.repo.Reparse(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// The changed METARs are not known, so all cached METARs are invalidated
+	if result.Changed > 0 {
+		repo.cache.Clear()
+		repo.latestCache.Clear()
+	}
+	return result, nil
+}
+
+// cacheResults caches freshly created METARs and invalidates the cache entries they affect.
+// Duplicates fed through the data API may have gained a new feeder, so their cache entries are invalidated as well.
+func (repo *METARRepository) cacheResults(results []*metar.CreateResult, fedBy *uuid.UUID) {
+	for _, result := range results {
+		if result.Status == metar.CreateStatusDuplicate && result.ExistingID != nil && fedBy != nil {
+			repo.cache.Unset(*result.ExistingID)
+		}
+		if result.Status != metar.CreateStatusCreated {
+			continue
+		}
+		obj := result.METAR
+
+		// Supersedes is only populated on creation and thus must not be cached
+		cpy := *obj
+		cpy.Supersedes = nil
+		repo.cache.Set(obj.ID, &cpy)
+
+		// The superseded METARs changed in the underlying repository
+		for _, superseded := range obj.Supersedes {
+			repo.cache.Unset(superseded)
+		}
+
+		// The new METAR may have replaced the latest one of its station
+		repo.latestCache.Unset(obj.StationID)
+	}
+}
+
+// Delete deletes a METAR by its ID and issuing time (which determines the partition it is stored in).
+// If entry is not nil, it is appended to the audit trail in the same transaction.
+func (repo *METARRepository) Delete(ctx context.Context, id uuid.UUID, issuedAt int64, entry *audit.Create) error {
+	// The station of the METAR is required to invalidate its latest METAR
+	obj, err := repo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+
+	err = repo.repo.Delete(ctx, id, issuedAt, entry)
+	if err != nil {
+		return err
+	}
+
+	// Deleting a correction changes the supersession of the other METARs of its group which are not known, so all
+	// cached METARs are invalidated
+	if obj == nil || obj.Corrected {
+		repo.cache.Clear()
+		repo.latestCache.Clear()
+		return nil
+	}
 	repo.cache.Unset(id)
+	repo.latestCache.Unset(obj.StationID)
 	return nil
 }
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"github.com/skybi/pluteo/internal/hashmap"
+	"github.com/skybi/pluteo/internal/station"
+)
+
+// StationRepository implements the station.Repository interface in order to implement caching
+type StationRepository struct {
+	repo  station.Repository
+	cache *hashmap.ExpiringMap[string, *station.Station]
+}
+
+var _ station.Repository = (*StationRepository)(nil)
+
+// GetByFilter retrieves multiple stations following a filter, ordered by their ICAO code
+func (repo *StationRepository) GetByFilter(ctx context.Context, filter *station.Filter, offset, limit uint64) ([]*station.Station, uint64, error) {
+	stations, n, err := repo.repo.GetByFilter(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, obj := range stations {
+		repo.cache.Set(obj.ICAO, obj)
+	}
+	return stations, n, nil
+}
+
+// GetByICAO retrieves a station by its ICAO code
+func (repo *StationRepository) GetByICAO(ctx context.Context, icao string) (*station.Station, error) {
+	cached, ok := repo.cache.Lookup(icao)
+	if ok {
+		return cached, nil
+	}
+	obj, err := repo.repo.GetByICAO(ctx, icao)
+	if err != nil {
+		return nil, err
+	}
+	if obj != nil {
+		repo.cache.Set(obj.ICAO, obj)
+	}
+	return obj, nil
+}
+
+// GetByICAOs retrieves multiple stations by their ICAO codes.
+// Unknown stations are not contained in the resulting map.
+func (repo *StationRepository) GetByICAOs(ctx context.Context, icaos []string) (map[string]*station.Station, error) {
+	objs := make(map[string]*station.Station, len(icaos))
+	missing := []string{}
+	for _, icao := range icaos {
+		cached, ok := repo.cache.Lookup(icao)
+		if !ok {
+			missing = append(missing, icao)
+			continue
+		}
+		objs[icao] = cached
+	}
+	if len(missing) == 0 {
+		return objs, nil
+	}
+
+	fetched, err := repo.repo.GetByICAOs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for icao, obj := range fetched {
+		repo.cache.Set(icao, obj)
+		objs[icao] = obj
+	}
+	return objs, nil
+}
+
+// GetWithinBounds retrieves the stations located inside the given bounding box, at most limit ones
+func (repo *StationRepository) GetWithinBounds(ctx context.Context, bounds *station.Bounds, limit uint64) ([]*station.Station, error) {
+	stations, err := repo.repo.GetWithinBounds(ctx, bounds, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range stations {
+		repo.cache.Set(obj.ICAO, obj)
+	}
+	return stations, nil
+}
+
+// Upsert creates the given stations or updates them if a station with the same ICAO code already exists.
+// It returns the amount of affected stations.
+func (repo *StationRepository) Upsert(ctx context.Context, stations []*station.Station) (int, error) {
+	n, err := repo.repo.Upsert(ctx, stations)
+	if err != nil {
+		return 0, err
+	}
+	for _, obj := range stations {
+		repo.cache.Unset(obj.ICAO)
+	}
+	return n, nil
+}
+
+// Delete deletes a station by its ICAO code
+func (repo *StationRepository) Delete(ctx context.Context, icao string) error {
+	err := repo.repo.Delete(ctx, icao)
+	if err != nil {
+		return err
+	}
+	repo.cache.Unset(icao)
+	return nil
+}
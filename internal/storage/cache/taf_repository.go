@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/hashmap"
+	"github.com/skybi/pluteo/internal/taf"
+)
+
+// TAFRepository implements the taf.Repository interface in order to implement caching
+type TAFRepository struct {
+	repo  taf.Repository
+	cache *hashmap.ExpiringMap[uuid.UUID, *taf.TAF]
+}
+
+var _ taf.Repository = (*TAFRepository)(nil)
+
+// GetByFilter retrieves multiple TAFs following a filter, ordered by their issuing date (descending).
+// If limit <= 0, a default limit value of 10 is used.
+func (repo *TAFRepository) GetByFilter(ctx context.Context, filter *taf.Filter, limit uint64) ([]*taf.TAF, uint64, error) {
+	tafs, n, err := repo.repo.GetByFilter(ctx, filter, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, obj := range tafs {
+		repo.cache.Set(obj.ID, obj)
+	}
+	return tafs, n, nil
+}
+
+// GetByID retrieves a TAF by its ID
+func (repo *TAFRepository) GetByID(ctx context.Context, id uuid.UUID) (*taf.TAF, error) {
+	cached, ok := repo.cache.Lookup(id)
+	if ok {
+		return cached, nil
+	}
+	obj, err := repo.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if obj != nil {
+		repo.cache.Set(obj.ID, obj)
+	}
+	return obj, nil
+}
+
+// Create creates new TAFs based on their raw text representation.
+// All raw strings are sanitized (leading and trailing spaces are trimmed).
+// This method also returns the indexes of the TAFs that already exist in the database and thus were not inserted.
+// The given parse options are passed to taf.OfString for every raw string.
+func (repo *TAFRepository) Create(ctx context.Context, raw []string, opts ...taf.ParseOption) ([]*taf.TAF, []uint, error) {
+	tafs, duplicates, err := repo.repo.Create(ctx, raw, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, obj := range tafs {
+		repo.cache.Set(obj.ID, obj)
+	}
+	return tafs, duplicates, nil
+}
+
+// Delete deletes a TAF by its ID
+func (repo *TAFRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	err := repo.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	repo.cache.Unset(id)
+	return nil
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/hashmap"
+	"github.com/skybi/pluteo/internal/webhook"
+)
+
+// WebhookRepository implements the webhook.Repository interface in order to implement caching
+type WebhookRepository struct {
+	repo  webhook.Repository
+	cache *hashmap.ExpiringMap[uuid.UUID, *webhook.Webhook]
+}
+
+var _ webhook.Repository = (*WebhookRepository)(nil)
+
+// Get retrieves multiple webhooks
+func (repo *WebhookRepository) Get(ctx context.Context, offset, limit uint64) ([]*webhook.Webhook, uint64, error) {
+	hooks, n, err := repo.repo.Get(ctx, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, hook := range hooks {
+		repo.cache.Set(hook.ID, hook)
+	}
+	return hooks, n, nil
+}
+
+// GetByUserID retrieves multiple webhooks of a specific user
+func (repo *WebhookRepository) GetByUserID(ctx context.Context, userID string, offset, limit uint64) ([]*webhook.Webhook, uint64, error) {
+	hooks, n, err := repo.repo.GetByUserID(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, hook := range hooks {
+		repo.cache.Set(hook.ID, hook)
+	}
+	return hooks, n, nil
+}
+
+// GetByID retrieves a webhook by its ID
+func (repo *WebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*webhook.Webhook, error) {
+	cached, ok := repo.cache.Lookup(id)
+	if ok {
+		return cached, nil
+	}
+	hook, err := repo.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hook != nil {
+		repo.cache.Set(hook.ID, hook)
+	}
+	return hook, nil
+}
+
+// GetEnabled retrieves all enabled webhooks
+func (repo *WebhookRepository) GetEnabled(ctx context.Context) ([]*webhook.Webhook, error) {
+	hooks, err := repo.repo.GetEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range hooks {
+		repo.cache.Set(hook.ID, hook)
+	}
+	return hooks, nil
+}
+
+// Create creates a new webhook with a newly generated secret
+func (repo *WebhookRepository) Create(ctx context.Context, create *webhook.Create) (*webhook.Webhook, error) {
+	hook, err := repo.repo.Create(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+	repo.cache.Set(hook.ID, hook)
+	return hook, nil
+}
+
+// Update updates a webhook
+func (repo *WebhookRepository) Update(ctx context.Context, id uuid.UUID, update *webhook.Update) (*webhook.Webhook, error) {
+	hook, err := repo.repo.Update(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil {
+		repo.cache.Unset(id)
+		return nil, nil
+	}
+	repo.cache.Set(hook.ID, hook)
+	return hook, nil
+}
+
+// Delete deletes a webhook and its delivery attempts by its ID
+func (repo *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := repo.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	repo.cache.Unset(id)
+	return nil
+}
+
+// GetAttempts retrieves multiple delivery attempts of a specific webhook, ordered by their time (descending)
+func (repo *WebhookRepository) GetAttempts(ctx context.Context, webhookID uuid.UUID, offset, limit uint64) ([]*webhook.DeliveryAttempt, uint64, error) {
+	return repo.repo.GetAttempts(ctx, webhookID, offset, limit)
+}
+
+// RecordAttempt stores a delivery attempt
+func (repo *WebhookRepository) RecordAttempt(ctx context.Context, attempt *webhook.DeliveryAttempt) error {
+	return repo.repo.RecordAttempt(ctx, attempt)
+}
+
+// DeleteAttemptsBefore deletes all delivery attempts that took place before the given unix timestamp
+func (repo *WebhookRepository) DeleteAttemptsBefore(ctx context.Context, timestamp int64) (int64, error) {
+	return repo.repo.DeleteAttemptsBefore(ctx, timestamp)
+}
@@ -2,9 +2,15 @@ package storage
 
 import (
 	"context"
+	"github.com/skybi/pluteo/internal/alert"
 	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/audit"
+	"github.com/skybi/pluteo/internal/coverage"
 	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/station"
+	"github.com/skybi/pluteo/internal/taf"
 	"github.com/skybi/pluteo/internal/user"
+	"github.com/skybi/pluteo/internal/webhook"
 )
 
 // Driver represents a storage driver
@@ -18,9 +24,27 @@ type Driver interface {
 	// APIKeys provides an API key repository implementation
 	APIKeys() apikey.Repository
 
-	// METARs provides an API key repository implementation
+	// METARs provides a METAR repository implementation
 	METARs() metar.Repository
 
+	// TAFs provides a TAF repository implementation
+	TAFs() taf.Repository
+
+	// Stations provides a station repository implementation
+	Stations() station.Repository
+
+	// Webhooks provides a webhook repository implementation
+	Webhooks() webhook.Repository
+
+	// AlertRules provides an alert rule repository implementation
+	AlertRules() alert.Repository
+
+	// Coverage provides a coverage rollup repository implementation
+	Coverage() coverage.Repository
+
+	// AuditLog provides an audit trail repository implementation
+	AuditLog() audit.Repository
+
 	// Close closes the storage driver (i.e. closes a database connection)
 	Close()
 }
@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/skybi/pluteo/internal/alert"
+	"time"
+)
+
+// AlertRuleRepository implements the alert.Repository interface using PostgreSQL
+type AlertRuleRepository struct {
+	db *pgxpool.Pool
+}
+
+var _ alert.Repository = (*AlertRuleRepository)(nil)
+
+// Get retrieves multiple alert rules
+func (repo *AlertRuleRepository) Get(ctx context.Context, offset, limit uint64) ([]*alert.Rule, uint64, error) {
+	return repo.getByConditions(ctx, squirrel.And{}, offset, limit)
+}
+
+// GetByUserID retrieves multiple alert rules of a specific user
+func (repo *AlertRuleRepository) GetByUserID(ctx context.Context, userID string, offset, limit uint64) ([]*alert.Rule, uint64, error) {
+	return repo.getByConditions(ctx, squirrel.And{squirrel.Eq{"user_id": userID}}, offset, limit)
+}
+
+func (repo *AlertRuleRepository) getByConditions(ctx context.Context, conditions squirrel.And, offset, limit uint64) ([]*alert.Rule, uint64, error) {
+	if limit == 0 {
+		limit = 10
+	}
+
+	// Construct the SQL queries
+	countQuery := squirrel.Select("COUNT(*)").From("alert_rules").Where(conditions)
+	query := squirrel.Select("*").From("alert_rules").Where(conditions).OrderBy("created_at", "rule_id").Offset(offset).Limit(limit)
+	countSQL, countVals, err := countQuery.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	sql, vals, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Fetch the total amount of alert rules that matches the given conditions
+	var n uint64
+	if err := repo.db.QueryRow(ctx, countSQL, countVals...).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+	if n == 0 {
+		return []*alert.Rule{}, 0, nil
+	}
+
+	// Fetch the alert rule objects themselves
+	rows, err := repo.db.Query(ctx, sql, vals...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []*alert.Rule{}, n, nil
+		}
+		return nil, 0, err
+	}
+	defer rows.Close()
+	rules := []*alert.Rule{}
+	for rows.Next() {
+		rule, err := repo.rowToRule(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, n, nil
+}
+
+// GetByID retrieves an alert rule by its ID
+func (repo *AlertRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*alert.Rule, error) {
+	row := repo.db.QueryRow(ctx, "SELECT * FROM alert_rules WHERE rule_id = $1", id)
+	rule, err := repo.rowToRule(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetEnabledByStation retrieves all enabled alert rules of a specific station
+func (repo *AlertRuleRepository) GetEnabledByStation(ctx context.Context, stationID string) ([]*alert.Rule, error) {
+	rows, err := repo.db.Query(ctx, "SELECT * FROM alert_rules WHERE station_id = $1 AND enabled", stationID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []*alert.Rule{}, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+	rules := []*alert.Rule{}
+	for rows.Next() {
+		rule, err := repo.rowToRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Create creates a new alert rule
+func (repo *AlertRuleRepository) Create(ctx context.Context, create *alert.Create) (*alert.Rule, error) {
+	rule := &alert.Rule{
+		ID:              uuid.New(),
+		UserID:          create.UserID,
+		Name:            create.Name,
+		StationID:       create.StationID,
+		Conditions:      create.Conditions,
+		WebhookID:       create.WebhookID,
+		Enabled:         true,
+		Triggered:       false,
+		LastEvaluatedAt: 0,
+		LastTriggeredAt: nil,
+		CreatedAt:       time.Now().Unix(),
+	}
+
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = repo.db.Exec(
+		ctx,
+		"INSERT INTO alert_rules VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		rule.ID,
+		rule.UserID,
+		rule.Name,
+		rule.StationID,
+		conditions,
+		rule.WebhookID,
+		rule.Enabled,
+		rule.Triggered,
+		rule.LastEvaluatedAt,
+		rule.LastTriggeredAt,
+		rule.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Update updates an alert rule.
+// Changing the station or conditions resets the state of the rule.
+func (repo *AlertRuleRepository) Update(ctx context.Context, id uuid.UUID, update *alert.Update) (*alert.Rule, error) {
+	// Simply re-fetch the alert rule if nothing should be changed
+	if update.Name == nil && update.StationID == nil && update.Conditions == nil && update.WebhookID == nil && !update.ClearWebhook &&
+		update.Enabled == nil && update.Triggered == nil && update.LastEvaluatedAt == nil && update.LastTriggeredAt == nil {
+		return repo.GetByID(ctx, id)
+	}
+
+	// Build the SQL query
+	query := squirrel.Update("alert_rules").Where(squirrel.Eq{"rule_id": id})
+	if update.Name != nil {
+		query = query.Set("name", *update.Name)
+	}
+	if update.StationID != nil {
+		query = query.Set("station_id", *update.StationID)
+	}
+	if update.Conditions != nil {
+		conditions, err := json.Marshal(update.Conditions)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Set("conditions", conditions)
+	}
+	if update.ClearWebhook {
+		query = query.Set("webhook_id", nil)
+	} else if update.WebhookID != nil {
+		query = query.Set("webhook_id", *update.WebhookID)
+	}
+	if update.Enabled != nil {
+		query = query.Set("enabled", *update.Enabled)
+	}
+	if update.StationID != nil || update.Conditions != nil {
+		query = query.Set("triggered", false).Set("last_evaluated_at", 0)
+	} else {
+		if update.Triggered != nil {
+			query = query.Set("triggered", *update.Triggered)
+		}
+		if update.LastEvaluatedAt != nil {
+			query = query.Set("last_evaluated_at", *update.LastEvaluatedAt)
+		}
+	}
+	if update.LastTriggeredAt != nil {
+		query = query.Set("last_triggered_at", *update.LastTriggeredAt)
+	}
+	sql, values, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	// Perform the SQL query
+	if _, err := repo.db.Exec(ctx, sql, values...); err != nil {
+		return nil, err
+	}
+
+	// Re-fetch the alert rule
+	return repo.GetByID(ctx, id)
+}
+
+// Delete deletes an alert rule by its ID
+func (repo *AlertRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := repo.db.Exec(ctx, "DELETE FROM alert_rules WHERE rule_id = $1", id)
+	return err
+}
+
+func (repo *AlertRuleRepository) rowToRule(row pgx.Row) (*alert.Rule, error) {
+	obj := new(alert.Rule)
+	var conditions []byte
+	if err := row.Scan(&obj.ID, &obj.UserID, &obj.Name, &obj.StationID, &conditions, &obj.WebhookID, &obj.Enabled, &obj.Triggered, &obj.LastEvaluatedAt, &obj.LastTriggeredAt, &obj.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(conditions, &obj.Conditions); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/skybi/pluteo/internal/audit"
+	"time"
+)
+
+// AuditRepository implements the audit.Repository interface using PostgreSQL
+type AuditRepository struct {
+	db *pgxpool.Pool
+}
+
+var _ audit.Repository = (*AuditRepository)(nil)
+
+// Get retrieves multiple audit trail entries, ordered by their creation date (descending)
+func (repo *AuditRepository) Get(ctx context.Context, offset, limit uint64) ([]*audit.Entry, uint64, error) {
+	return repo.getByConditions(ctx, squirrel.And{}, offset, limit)
+}
+
+// GetByAction retrieves multiple audit trail entries of a specific action, ordered by their creation date (descending)
+func (repo *AuditRepository) GetByAction(ctx context.Context, action audit.Action, offset, limit uint64) ([]*audit.Entry, uint64, error) {
+	return repo.getByConditions(ctx, squirrel.And{squirrel.Eq{"action": action}}, offset, limit)
+}
+
+func (repo *AuditRepository) getByConditions(ctx context.Context, conditions squirrel.And, offset, limit uint64) ([]*audit.Entry, uint64, error) {
+	if limit == 0 {
+		limit = 10
+	}
+
+	// Construct the SQL queries
+	countQuery := squirrel.Select("COUNT(*)").From("audit_log").Where(conditions)
+	query := squirrel.Select("*").From("audit_log").Where(conditions).OrderBy("created_at DESC", "entry_id").Offset(offset).Limit(limit)
+	countSQL, countVals, err := countQuery.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	sql, vals, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Fetch the total amount of entries that matches the given conditions
+	var n uint64
+	if err := repo.db.QueryRow(ctx, countSQL, countVals...).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+	if n == 0 {
+		return []*audit.Entry{}, 0, nil
+	}
+
+	// Fetch the entries themselves
+	rows, err := repo.db.Query(ctx, sql, vals...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	entries := []*audit.Entry{}
+	for rows.Next() {
+		entry, err := repo.rowToEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, n, rows.Err()
+}
+
+// Create appends a new entry to the audit trail
+func (repo *AuditRepository) Create(ctx context.Context, create *audit.Create) (*audit.Entry, error) {
+	entry, parameters, err := newAuditEntry(create)
+	if err != nil {
+		return nil, err
+	}
+	_, err = repo.db.Exec(ctx, insertAuditEntrySQL, entry.ID, entry.UserID, entry.Action, entry.Target, parameters, entry.Affected, entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// insertAuditEntrySQL appends a single entry to the audit trail
+const insertAuditEntrySQL = "INSERT INTO audit_log VALUES ($1, $2, $3, $4, $5, $6, $7)"
+
+// insertAuditEntry appends a new entry to the audit trail inside the given transaction.
+// Other repositories use it to record an action in the same transaction it is performed in.
+func insertAuditEntry(ctx context.Context, txn pgx.Tx, create *audit.Create) (*audit.Entry, error) {
+	entry, parameters, err := newAuditEntry(create)
+	if err != nil {
+		return nil, err
+	}
+	_, err = txn.Exec(ctx, insertAuditEntrySQL, entry.ID, entry.UserID, entry.Action, entry.Target, parameters, entry.Affected, entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// newAuditEntry builds a new audit trail entry together with its JSON-encoded parameters
+func newAuditEntry(create *audit.Create) (*audit.Entry, []byte, error) {
+	entry := &audit.Entry{
+		ID:         uuid.New(),
+		UserID:     create.UserID,
+		Action:     create.Action,
+		Target:     create.Target,
+		Parameters: create.Parameters,
+		Affected:   create.Affected,
+		CreatedAt:  time.Now().Unix(),
+	}
+	if entry.Parameters == nil {
+		entry.Parameters = map[string]any{}
+	}
+
+	parameters, err := json.Marshal(entry.Parameters)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, parameters, nil
+}
+
+func (repo *AuditRepository) rowToEntry(row pgx.Row) (*audit.Entry, error) {
+	obj := new(audit.Entry)
+	var parameters []byte
+	if err := row.Scan(&obj.ID, &obj.UserID, &obj.Action, &obj.Target, &parameters, &obj.Affected, &obj.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(parameters, &obj.Parameters); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
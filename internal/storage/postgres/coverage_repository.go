@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/skybi/pluteo/internal/coverage"
+	"time"
+)
+
+// coverageGapLookback defines how many seconds before the first refreshed day METARs are considered when determining
+// the gap preceding the first METAR of a day
+const coverageGapLookback = 7 * 24 * 60 * 60
+
+// CoverageRepository implements the coverage.Repository interface using PostgreSQL
+type CoverageRepository struct {
+	db *pgxpool.Pool
+}
+
+var _ coverage.Repository = (*CoverageRepository)(nil)
+
+// GetByStation retrieves the statistics of a station for all days between from and to (both inclusive unix
+// timestamps), ordered by the day (ascending)
+func (repo *CoverageRepository) GetByStation(ctx context.Context, stationID string, from, to int64) ([]*coverage.Day, error) {
+	rows, err := repo.db.Query(
+		ctx,
+		"SELECT * FROM metar_coverage WHERE station_id = $1 AND day >= $2 AND day <= $3 ORDER BY day",
+		stationID,
+		coverage.StartOfDay(from),
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	days := []*coverage.Day{}
+	for rows.Next() {
+		day, err := repo.rowToDay(rows)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// GetSilent retrieves all stations whose latest METAR was issued after activeSince but not after silentSince,
+// ordered by the issuing time of their latest METAR (ascending)
+func (repo *CoverageRepository) GetSilent(ctx context.Context, silentSince, activeSince int64) ([]*coverage.SilentStation, error) {
+	rows, err := repo.db.Query(
+		ctx,
+		`SELECT station_id, MAX(last_issued_at) AS latest FROM metar_coverage WHERE day >= $1
+		GROUP BY station_id HAVING MAX(last_issued_at) > $2 AND MAX(last_issued_at) <= $3
+		ORDER BY latest, station_id`,
+		coverage.StartOfDay(activeSince),
+		activeSince,
+		silentSince,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stations := []*coverage.SilentStation{}
+	for rows.Next() {
+		station := new(coverage.SilentStation)
+		if err := rows.Scan(&station.StationID, &station.LastIssuedAt); err != nil {
+			return nil, err
+		}
+		stations = append(stations, station)
+	}
+	return stations, rows.Err()
+}
+
+// Refresh recomputes the statistics of all days starting with the day containing the given unix timestamp.
+// It returns the amount of refreshed station days.
+func (repo *CoverageRepository) Refresh(ctx context.Context, since int64) (int64, error) {
+	firstDay := coverage.StartOfDay(since)
+
+	txn, err := repo.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer txn.Rollback(ctx)
+
+	// Days whose METARs were deleted in the meantime must not remain
+	if _, err := txn.Exec(ctx, "DELETE FROM metar_coverage WHERE day >= $1", firstDay); err != nil {
+		return 0, err
+	}
+
+	// The gap preceding a METAR is attributed to the day the METAR was issued on
+	tag, err := txn.Exec(
+		ctx,
+		`INSERT INTO metar_coverage
+		SELECT
+			station_id,
+			day,
+			COUNT(*),
+			MIN(issued_at),
+			MAX(issued_at),
+			MAX(gap),
+			percentile_disc(0.5) WITHIN GROUP (ORDER BY latency),
+			percentile_disc(0.9) WITHIN GROUP (ORDER BY latency),
+			percentile_disc(0.99) WITHIN GROUP (ORDER BY latency),
+			$3::bigint
+		FROM (
+			SELECT
+				station_id,
+				issued_at,
+				issued_at - issued_at % 86400 AS day,
+				received_at - issued_at AS latency,
+				issued_at - LAG(issued_at) OVER (PARTITION BY station_id ORDER BY issued_at) AS gap
+			FROM metars
+			WHERE issued_at >= $2
+		) AS reports
+		WHERE day >= $1
+		GROUP BY station_id, day`,
+		firstDay,
+		firstDay-coverageGapLookback,
+		time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := txn.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (repo *CoverageRepository) rowToDay(row pgx.Row) (*coverage.Day, error) {
+	day := new(coverage.Day)
+	if err := row.Scan(
+		&day.StationID,
+		&day.Day,
+		&day.Reports,
+		&day.FirstIssuedAt,
+		&day.LastIssuedAt,
+		&day.LargestGap,
+		&day.LatencyP50,
+		&day.LatencyP90,
+		&day.LatencyP99,
+		&day.RefreshedAt,
+	); err != nil {
+		return nil, err
+	}
+	return day, nil
+}
@@ -8,10 +8,16 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/skybi/pluteo/internal/alert"
 	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/audit"
+	"github.com/skybi/pluteo/internal/coverage"
 	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/station"
 	"github.com/skybi/pluteo/internal/storage"
+	"github.com/skybi/pluteo/internal/taf"
 	"github.com/skybi/pluteo/internal/user"
+	"github.com/skybi/pluteo/internal/webhook"
 )
 
 //go:embed migrations/*.sql
@@ -19,11 +25,17 @@ var migrations embed.FS
 
 // Driver represents the PostgreSQL storage driver implementation
 type Driver struct {
-	dsn     string
-	db      *pgxpool.Pool
-	users   *UserRepository
-	apiKeys *APIKeyRepository
-	metars  *METARRepository
+	dsn      string
+	db       *pgxpool.Pool
+	users    *UserRepository
+	apiKeys  *APIKeyRepository
+	metars   *METARRepository
+	tafs     *TAFRepository
+	stations *StationRepository
+	webhooks *WebhookRepository
+	alerts   *AlertRuleRepository
+	coverage *CoverageRepository
+	auditLog *AuditRepository
 }
 
 var _ storage.Driver = (*Driver)(nil)
@@ -63,10 +75,23 @@ func (driver *Driver) Initialize(ctx context.Context) error {
 	driver.users = &UserRepository{db: pool}
 	driver.apiKeys = &APIKeyRepository{db: pool}
 	driver.metars = &METARRepository{db: pool}
+	driver.tafs = &TAFRepository{db: pool}
+	driver.stations = &StationRepository{db: pool}
+	driver.webhooks = &WebhookRepository{db: pool}
+	driver.alerts = &AlertRuleRepository{db: pool}
+	driver.coverage = &CoverageRepository{db: pool}
+	driver.auditLog = &AuditRepository{db: pool}
 
 	return nil
 }
 
+// BackfillFlightCategories derives the flight category of every METAR that was stored before flight categories were
+// introduced. As this may take a while for large databases, it is not part of Initialize and should be run in the
+// background.
+func (driver *Driver) BackfillFlightCategories(ctx context.Context) error {
+	return driver.metars.backfillFlightCategories(ctx)
+}
+
 // Users provides the PostgreSQL user repository implementation
 func (driver *Driver) Users() user.Repository {
 	return driver.users
@@ -82,11 +107,47 @@ func (driver *Driver) METARs() metar.Repository {
 	return driver.metars
 }
 
+// TAFs provides the PostgreSQL TAF repository implementation
+func (driver *Driver) TAFs() taf.Repository {
+	return driver.tafs
+}
+
+// Stations provides the PostgreSQL station repository implementation
+func (driver *Driver) Stations() station.Repository {
+	return driver.stations
+}
+
+// Webhooks provides the PostgreSQL webhook repository implementation
+func (driver *Driver) Webhooks() webhook.Repository {
+	return driver.webhooks
+}
+
+// AlertRules provides the PostgreSQL alert rule repository implementation
+func (driver *Driver) AlertRules() alert.Repository {
+	return driver.alerts
+}
+
+// Coverage provides the PostgreSQL coverage rollup repository implementation
+func (driver *Driver) Coverage() coverage.Repository {
+	return driver.coverage
+}
+
+// AuditLog provides the PostgreSQL audit trail repository implementation
+func (driver *Driver) AuditLog() audit.Repository {
+	return driver.auditLog
+}
+
 // Close discards the repository implementations and closes the database connection
 func (driver *Driver) Close() {
 	driver.users = nil
 	driver.apiKeys = nil
 	driver.metars = nil
+	driver.tafs = nil
+	driver.stations = nil
+	driver.webhooks = nil
+	driver.alerts = nil
+	driver.coverage = nil
+	driver.auditLog = nil
 
 	driver.db.Close()
 	driver.db = nil
@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"strings"
+	"time"
+)
+
+// metarPartitionPrefix is the prefix of the names of the monthly METAR partitions ('metars_pYYYYMM')
+const metarPartitionPrefix = "metars_p"
+
+// metarArchiveSchema is the schema archived METAR partitions are moved to
+const metarArchiveSchema = "metars_archive"
+
+// metarDefaultExpiredTable is the table expired METARs of the default partition are moved to if they are not dropped
+const metarDefaultExpiredTable = "metars_default_expired"
+
+// METARRetentionMode defines what happens to METAR partitions that exceed the retention period
+type METARRetentionMode string
+
+const (
+	// METARRetentionModeDrop drops expired partitions and deletes the expired METARs of the default partition
+	METARRetentionModeDrop METARRetentionMode = "drop"
+
+	// METARRetentionModeDetach detaches expired partitions, which remain as standalone tables; the expired METARs of the
+	// default partition are moved to the 'metars_default_expired' table
+	METARRetentionModeDetach METARRetentionMode = "detach"
+
+	// METARRetentionModeArchive detaches expired partitions and moves them to the 'metars_archive' schema; the expired
+	// METARs of the default partition are moved to the 'metars_default_expired' table of that schema
+	METARRetentionModeArchive METARRetentionMode = "archive"
+)
+
+// IsValid returns whether the retention mode is known
+func (mode METARRetentionMode) IsValid() bool {
+	switch mode {
+	case METARRetentionModeDrop, METARRetentionModeDetach, METARRetentionModeArchive:
+		return true
+	default:
+		return false
+	}
+}
+
+// METARPartitionPolicy defines how the monthly METAR partitions are maintained
+type METARPartitionPolicy struct {
+	// Ahead defines for how many months after the current one partitions are created in advance
+	Ahead int
+
+	// Retention defines how long METARs are kept after they were issued; 0 keeps them forever.
+	// Only whole months are retired, so METARs may be kept up to a month longer.
+	Retention time.Duration
+	Mode      METARRetentionMode
+}
+
+// METARPartitionMaintenance represents the outcome of maintaining the METAR partitions
+type METARPartitionMaintenance struct {
+	// Created and Retired contain the names of the created and retired partitions
+	Created []string
+	Retired []string
+
+	// RetiredDefault is the amount of expired METARs retired from the default partition
+	RetiredDefault int64
+}
+
+// MaintainMETARPartitions creates the monthly METAR partitions of the current and the upcoming months and retires the
+// partitions and the METARs of the default partition that exceed the retention period. METARs of a newly created
+// partition's month that were stored in the default partition are moved to it.
+// As this bypasses any caching layer, cached METARs have to be invalidated if METARs were retired.
+// The outcome is returned even if an error occurred.
+func (driver *Driver) MaintainMETARPartitions(ctx context.Context, policy *METARPartitionPolicy) (*METARPartitionMaintenance, error) {
+	result := new(METARPartitionMaintenance)
+
+	partitions, err := driver.metarPartitions(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	now := time.Now().UTC()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= policy.Ahead; i++ {
+		month := currentMonth.AddDate(0, i, 0)
+		if _, ok := partitions[metarPartitionName(month)]; ok {
+			continue
+		}
+		if err := driver.createMETARPartition(ctx, month); err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, metarPartitionName(month))
+	}
+
+	if policy.Retention <= 0 {
+		return result, nil
+	}
+
+	// Partitions are only retired once their whole month exceeds the retention period
+	threshold := now.Add(-policy.Retention)
+	cutoff := time.Date(threshold.Year(), threshold.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for name, month := range partitions {
+		if month.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+		if err := driver.retireMETARPartition(ctx, name, policy.Mode); err != nil {
+			return result, err
+		}
+		result.Retired = append(result.Retired, name)
+	}
+	result.RetiredDefault, err = driver.retireExpiredDefaultMETARs(ctx, cutoff.Unix(), policy.Mode)
+	if err != nil {
+		return result, err
+	}
+
+	// The feeders of the retired METARs are not needed anymore
+	if _, err := driver.db.Exec(ctx, "DELETE FROM metar_feeders WHERE issued_at < $1", cutoff.Unix()); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// metarPartitions retrieves the monthly METAR partitions, mapped to the first day of their month
+func (driver *Driver) metarPartitions(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := driver.db.Query(
+		ctx,
+		"SELECT child.relname FROM pg_inherits JOIN pg_class AS child ON pg_inherits.inhrelid = child.oid WHERE pg_inherits.inhparent = 'metars'::regclass",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	partitions := make(map[string]time.Time)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(name, metarPartitionPrefix) {
+			continue
+		}
+		month, err := time.Parse("200601", strings.TrimPrefix(name, metarPartitionPrefix))
+		if err != nil {
+			continue
+		}
+		partitions[name] = month
+	}
+	return partitions, rows.Err()
+}
+
+// createMETARPartition creates the partition of the given month.
+// The partition is created as a standalone table first so that the METARs of its month can be moved from the default
+// partition before attaching it.
+func (driver *Driver) createMETARPartition(ctx context.Context, month time.Time) error {
+	name := pgx.Identifier{metarPartitionName(month)}.Sanitize()
+	from, to := month.Unix(), month.AddDate(0, 1, 0).Unix()
+
+	txn, err := driver.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback(ctx)
+
+	if _, err := txn.Exec(ctx, fmt.Sprintf("CREATE TABLE %s (LIKE metars INCLUDING DEFAULTS)", name)); err != nil {
+		return err
+	}
+	_, err = txn.Exec(
+		ctx,
+		fmt.Sprintf("WITH moved AS (DELETE FROM metars_default WHERE issued_at >= $1 AND issued_at < $2 RETURNING *) INSERT INTO %s SELECT * FROM moved", name),
+		from,
+		to,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := txn.Exec(ctx, fmt.Sprintf("ALTER TABLE metars ATTACH PARTITION %s FOR VALUES FROM (%d) TO (%d)", name, from, to)); err != nil {
+		return err
+	}
+
+	return txn.Commit(ctx)
+}
+
+// retireMETARPartition detaches a partition and drops or archives it depending on the retention mode
+func (driver *Driver) retireMETARPartition(ctx context.Context, partition string, mode METARRetentionMode) error {
+	name := pgx.Identifier{partition}.Sanitize()
+
+	txn, err := driver.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback(ctx)
+
+	if _, err := txn.Exec(ctx, fmt.Sprintf("ALTER TABLE metars DETACH PARTITION %s", name)); err != nil {
+		return err
+	}
+	switch mode {
+	case METARRetentionModeDrop:
+		if _, err := txn.Exec(ctx, fmt.Sprintf("DROP TABLE %s", name)); err != nil {
+			return err
+		}
+	case METARRetentionModeArchive:
+		schema := pgx.Identifier{metarArchiveSchema}.Sanitize()
+		if _, err := txn.Exec(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", schema)); err != nil {
+			return err
+		}
+		if _, err := txn.Exec(ctx, fmt.Sprintf("ALTER TABLE %s SET SCHEMA %s", name, schema)); err != nil {
+			return err
+		}
+	}
+
+	return txn.Commit(ctx)
+}
+
+// retireExpiredDefaultMETARs deletes or moves the METARs of the default partition issued before the given unix timestamp
+// depending on the retention mode. It returns the amount of retired METARs.
+func (driver *Driver) retireExpiredDefaultMETARs(ctx context.Context, cutoff int64, mode METARRetentionMode) (int64, error) {
+	if mode == METARRetentionModeDrop {
+		tag, err := driver.db.Exec(ctx, "DELETE FROM metars_default WHERE issued_at < $1", cutoff)
+		if err != nil {
+			return 0, err
+		}
+		return tag.RowsAffected(), nil
+	}
+
+	table := pgx.Identifier{metarDefaultExpiredTable}
+	if mode == METARRetentionModeArchive {
+		table = pgx.Identifier{metarArchiveSchema, metarDefaultExpiredTable}
+	}
+	name := table.Sanitize()
+
+	txn, err := driver.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer txn.Rollback(ctx)
+
+	if mode == METARRetentionModeArchive {
+		if _, err := txn.Exec(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pgx.Identifier{metarArchiveSchema}.Sanitize())); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := txn.Exec(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (LIKE metars INCLUDING DEFAULTS)", name)); err != nil {
+		return 0, err
+	}
+	tag, err := txn.Exec(
+		ctx,
+		fmt.Sprintf("WITH moved AS (DELETE FROM metars_default WHERE issued_at < $1 RETURNING *) INSERT INTO %s SELECT * FROM moved", name),
+		cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := txn.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// metarPartitionName returns the name of the partition of the given month
+func metarPartitionName(month time.Time) string {
+	return metarPartitionPrefix + month.Format("200601")
+}
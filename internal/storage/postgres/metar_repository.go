@@ -8,9 +8,18 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/skybi/pluteo/internal/audit"
 	"github.com/skybi/pluteo/internal/metar"
+	"time"
 )
 
+// metarBackfillBatchSize defines how many METARs are updated at once when backfilling derived columns
+var metarBackfillBatchSize = 1000
+
+// metarWriterLockKey is the key of the transaction-level advisory lock serializing the transactions that insert METARs.
+// As only one of them can run at a time, sequence numbers become visible in the order they were assigned in.
+const metarWriterLockKey int64 = 0x4d45544152 // 'METAR'
+
 // METARRepository implements the metar.Repository interface using PostgreSQL
 type METARRepository struct {
 	db *pgxpool.Pool
@@ -18,23 +27,18 @@ type METARRepository struct {
 
 var _ metar.Repository = (*METARRepository)(nil)
 
-// GetByFilter retrieves multiple METARs following a filter, ordered by their issuing date (descending).
+// GetByFilter retrieves multiple METARs following a filter, ordered by their issuing date and ID (descending).
+// The returned count is the total amount of METARs following the filter (ignoring metar.Filter.Cursor); it is 0 if
+// metar.Filter.SkipCount is set.
 // If limit <= 0, a default limit value of 10 is used.
 func (repo *METARRepository) GetByFilter(ctx context.Context, filter *metar.Filter, limit uint64) ([]*metar.METAR, uint64, error) {
 	// Construct the SQL queries
-	countQuery := squirrel.Select("COUNT(*)").From("metars")
-	query := squirrel.Select("*").From("metars").OrderBy("issued_at DESC")
-	if filter.StationID != nil {
-		countQuery = countQuery.Where(squirrel.Eq{"station_id": *filter.StationID})
-		query = query.Where(squirrel.Eq{"station_id": *filter.StationID})
-	}
-	if filter.IssuedBefore != nil {
-		countQuery = countQuery.Where(squirrel.Lt{"issued_at": *filter.IssuedBefore})
-		query = query.Where(squirrel.Lt{"issued_at": *filter.IssuedBefore})
-	}
-	if filter.IssuedAfter != nil {
-		countQuery = countQuery.Where(squirrel.Gt{"issued_at": *filter.IssuedAfter})
-		query = query.Where(squirrel.Gt{"issued_at": *filter.IssuedAfter})
+	conditions := repo.filterConditions(filter)
+	countQuery := squirrel.Select("COUNT(*)").From("metars").Where(conditions)
+	query := squirrel.Select("*").From("metars").Where(conditions).OrderBy("issued_at DESC", "metar_id DESC")
+	if filter.Cursor != nil {
+		// Keyset pagination: continue right after the METAR the cursor points to
+		query = query.Where(squirrel.Expr("(issued_at, metar_id) < (?, ?)", filter.Cursor.IssuedAt, filter.Cursor.ID))
 	}
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -52,11 +56,13 @@ func (repo *METARRepository) GetByFilter(ctx context.Context, filter *metar.Filt
 
 	// Fetch the total amount of METARs that matches the given filter
 	var n uint64
-	if err := repo.db.QueryRow(ctx, countSQL, countVals...).Scan(&n); err != nil {
-		return nil, 0, err
-	}
-	if n == 0 {
-		return []*metar.METAR{}, 0, nil
+	if !filter.SkipCount {
+		if err := repo.db.QueryRow(ctx, countSQL, countVals...).Scan(&n); err != nil {
+			return nil, 0, err
+		}
+		if n == 0 {
+			return []*metar.METAR{}, 0, nil
+		}
 	}
 
 	// Fetch the METAR objects themselves
@@ -79,6 +85,85 @@ func (repo *METARRepository) GetByFilter(ctx context.Context, filter *metar.Filt
 	return objs, n, nil
 }
 
+// GetLatestByFilter retrieves the latest METAR of every station that has at least one METAR following a filter.
+// METARs superseded by a correction are only considered if Filter.IncludeSuperseded is set.
+func (repo *METARRepository) GetLatestByFilter(ctx context.Context, filter *metar.Filter) ([]*metar.METAR, error) {
+	sql, vals, err := squirrel.Select("DISTINCT ON (station_id) *").
+		From("metars").
+		Where(repo.filterConditions(filter)).
+		OrderBy("station_id", "issued_at DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(ctx, sql, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	objs := []*metar.METAR{}
+	for rows.Next() {
+		obj, err := repo.rowToMETAR(rows)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, rows.Err()
+}
+
+// GetLatestByStations retrieves the latest METAR that is not superseded by a correction of every given station.
+// Stations without any METAR are not contained in the resulting map.
+func (repo *METARRepository) GetLatestByStations(ctx context.Context, stationIDs []string) (map[string]*metar.METAR, error) {
+	metars, err := repo.GetLatestByFilter(ctx, &metar.Filter{StationIDs: stationIDs})
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]*metar.METAR, len(metars))
+	for _, obj := range metars {
+		latest[obj.StationID] = obj
+	}
+	return latest, nil
+}
+
+// GetSinceSequence retrieves up to limit METARs whose sequence number is greater than the given one, ordered by their
+// sequence number (ascending). If stationIDs is not nil, only METARs of the given stations are included.
+// METARs are inserted by a single writer at a time (see metarWriterLockKey), so no METAR with a lower sequence number
+// than a returned one can become visible afterwards.
+func (repo *METARRepository) GetSinceSequence(ctx context.Context, sequence int64, stationIDs []string, limit uint64) ([]*metar.METAR, error) {
+	conditions := squirrel.And{squirrel.Gt{"sequence": sequence}}
+	if stationIDs != nil {
+		conditions = append(conditions, squirrel.Expr("station_id = ANY(?)", stationIDs))
+	}
+	sql, vals, err := squirrel.Select("*").
+		From("metars").
+		Where(conditions).
+		OrderBy("sequence").
+		Limit(limit).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(ctx, sql, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	objs := []*metar.METAR{}
+	for rows.Next() {
+		obj, err := repo.rowToMETAR(rows)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, rows.Err()
+}
+
 // GetByID retrieves a METAR by its ID
 func (repo *METARRepository) GetByID(ctx context.Context, id uuid.UUID) (*metar.METAR, error) {
 	row := repo.db.QueryRow(ctx, "SELECT * FROM metars WHERE metar_id = $1", id)
@@ -94,61 +179,565 @@ func (repo *METARRepository) GetByID(ctx context.Context, id uuid.UUID) (*metar.
 
 // Create creates new METARs based on their raw text representation.
 // All raw strings are sanitized (leading and trailing spaces are trimmed).
-// This method also returns the indexes of the METARs that already exist in the database and thus were not inserted.
-func (repo *METARRepository) Create(ctx context.Context, raw []string) ([]*metar.METAR, []uint, error) {
+// The outcome of every raw string is returned (in the order of the raw strings); a single malformed one rejects all of
+// them with a *metar.FormatError. METARs that already exist in the database are not inserted; the API key feeding
+// them is added to their feeders instead.
+// fedBy is the ID of the API key feeding the METARs; it is nil if they were not fed through the data API.
+// The given parse options are passed to metar.OfString for every raw string.
+func (repo *METARRepository) Create(ctx context.Context, raw []string, fedBy *uuid.UUID, opts ...metar.ParseOption) ([]*metar.CreateResult, error) {
+	return repo.create(ctx, raw, fedBy, true, opts)
+}
+
+// CreatePartially creates new METARs based on their raw text representation just like Create, but malformed raw
+// strings do not prevent the other METARs from being stored. Instead, the outcome of every raw string is returned
+// (in the order of the raw strings).
+func (repo *METARRepository) CreatePartially(ctx context.Context, raw []string, fedBy *uuid.UUID, opts ...metar.ParseOption) ([]*metar.CreateResult, error) {
+	return repo.create(ctx, raw, fedBy, false, opts)
+}
+
+// create inserts the given raw METARs in a single transaction.
+// If atomic is set, the first malformed raw string aborts the transaction; otherwise it is reported as invalid.
+func (repo *METARRepository) create(ctx context.Context, raw []string, fedBy *uuid.UUID, atomic bool, opts []metar.ParseOption) ([]*metar.CreateResult, error) {
 	txn, err := repo.db.Begin(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	defer txn.Rollback(ctx)
 
-	metars := make([]*metar.METAR, 0, len(raw))
-	uniqueViolations := []uint{}
+	// Serialize the writers so that sequence numbers are committed in ascending order
+	if _, err := txn.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", metarWriterLockKey); err != nil {
+		return nil, err
+	}
+
+	results := make([]*metar.CreateResult, 0, len(raw))
+	receivedAt := time.Now().Unix()
 
 	for i, str := range raw {
 		// Parse the raw string into a metar.METAR object
-		obj, err := metar.OfString(str)
+		obj, err := metar.OfString(str, opts...)
 		if err != nil {
 			var formatErr *metar.FormatError
-			if errors.As(err, &formatErr) {
-				return nil, nil, &metar.FormatError{
+			if !errors.As(err, &formatErr) {
+				return nil, err
+			}
+			if atomic {
+				return nil, &metar.FormatError{
 					Wrapping: fmt.Errorf("error in METAR no. %d: %s", i, err.Error()),
 					Index:    i,
 				}
 			}
-			return nil, nil, err
+			results = append(results, &metar.CreateResult{
+				Status: metar.CreateStatusInvalid,
+				Error:  err,
+			})
+			continue
 		}
 
 		// Insert the METAR into the database
-		tag, err := txn.Exec(ctx, "INSERT INTO metars VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING", obj.ID, obj.StationID, obj.IssuedAt, obj.Raw)
+		obj.FedBy = fedBy
+		obj.ReceivedAt = &receivedAt
+		obj.FeedCount = 0
+		if fedBy != nil {
+			obj.FeedCount = 1
+		}
+		err = txn.QueryRow(
+			ctx,
+			"INSERT INTO metars VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULL, $9, DEFAULT, $10, $11, $12) ON CONFLICT DO NOTHING RETURNING sequence",
+			obj.ID,
+			obj.StationID,
+			obj.IssuedAt,
+			obj.Raw,
+			obj.Type,
+			obj.Corrected,
+			obj.Automated,
+			obj.Nil,
+			obj.FlightCategory,
+			obj.FedBy,
+			obj.ReceivedAt,
+			obj.FeedCount,
+		).Scan(&obj.Sequence)
 		if err != nil {
-			return nil, nil, err
+			if errors.Is(err, pgx.ErrNoRows) {
+				existingID, err := repo.addFeeder(ctx, txn, obj, fedBy, receivedAt)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, &metar.CreateResult{
+					Status:     metar.CreateStatusDuplicate,
+					ExistingID: &existingID,
+				})
+				continue
+			}
+			return nil, err
+		}
+		if fedBy != nil {
+			_, err := txn.Exec(ctx, "INSERT INTO metar_feeders VALUES ($1, $2, $3, $4)", obj.ID, *fedBy, obj.IssuedAt, receivedAt)
+			if err != nil {
+				return nil, err
+			}
 		}
-		if tag.RowsAffected() == 0 {
-			uniqueViolations = append(uniqueViolations, uint(i))
+
+		// Link the METAR to the ones it supersedes or is superseded by
+		if err := repo.linkSupersession(ctx, txn, obj); err != nil {
+			return nil, err
+		}
+
+		results = append(results, &metar.CreateResult{
+			Status: metar.CreateStatusCreated,
+			METAR:  obj,
+		})
+	}
+
+	if err := txn.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// addFeeder adds the given API key to the feeders of the already stored duplicate of a METAR and updates its feed
+// count. It returns the ID of the stored METAR.
+func (repo *METARRepository) addFeeder(ctx context.Context, txn pgx.Tx, duplicate *metar.METAR, fedBy *uuid.UUID, receivedAt int64) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := txn.QueryRow(
+		ctx,
+		"SELECT metar_id FROM metars WHERE station_id = $1 AND issued_at = $2 AND raw = $3",
+		duplicate.StationID,
+		duplicate.IssuedAt,
+		duplicate.Raw,
+	).Scan(&id)
+	if err != nil || fedBy == nil {
+		return id, err
+	}
+
+	// Repeated submissions of the same API key do not count
+	tag, err := txn.Exec(ctx, "INSERT INTO metar_feeders VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING", id, *fedBy, duplicate.IssuedAt, receivedAt)
+	if err != nil || tag.RowsAffected() == 0 {
+		return id, err
+	}
+	_, err = txn.Exec(
+		ctx,
+		"UPDATE metars SET feed_count = (SELECT COUNT(*) FROM metar_feeders WHERE metar_id = $1) WHERE metar_id = $1 AND issued_at = $2",
+		id,
+		duplicate.IssuedAt,
+	)
+	return id, err
+}
+
+// Delete deletes a METAR by its ID and issuing time (which determines the partition it is stored in).
+// If the deleted METAR is a correction, the supersession links of the remaining METARs of its station and issuing time
+// are restored.
+// If entry is not nil, it is appended to the audit trail in the same transaction.
+func (repo *METARRepository) Delete(ctx context.Context, id uuid.UUID, issuedAt int64, entry *audit.Create) error {
+	txn, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback(ctx)
+
+	var grp metarGroup
+	var corrected bool
+	err = txn.QueryRow(
+		ctx,
+		"DELETE FROM metars WHERE metar_id = $1 AND issued_at = $2 RETURNING station_id, issued_at, corrected",
+		id,
+		issuedAt,
+	).Scan(&grp.stationID, &grp.issuedAt, &corrected)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if _, err := txn.Exec(ctx, "DELETE FROM metar_feeders WHERE metar_id = $1", id); err != nil {
+		return err
+	}
+
+	// Only corrections supersede other METARs
+	if corrected {
+		if err := repo.relinkSupersession(ctx, txn, grp); err != nil {
+			return err
+		}
+	}
+	if entry != nil {
+		if _, err := insertAuditEntry(ctx, txn, entry); err != nil {
+			return err
+		}
+	}
+
+	return txn.Commit(ctx)
+}
+
+// DeleteByFilter deletes all METARs following a filter (ignoring metar.Filter.Cursor) and returns the amount of
+// deleted METARs.
+// The supersession links of the remaining METARs of the affected stations and issuing times are restored.
+// If entry is not nil, its affected count is set and it is appended to the audit trail in the same transaction.
+func (repo *METARRepository) DeleteByFilter(ctx context.Context, filter *metar.Filter, entry *audit.Create) (int64, error) {
+	deleteSQL, vals, err := squirrel.Delete("metars").
+		Where(repo.filterConditions(filter)).
+		Suffix("RETURNING metar_id, station_id, issued_at, corrected").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	// The feeders of the deleted METARs are deleted as well
+	sql := fmt.Sprintf(
+		"WITH deleted AS (%s), feeders AS (DELETE FROM metar_feeders WHERE metar_id IN (SELECT metar_id FROM deleted)) SELECT station_id, issued_at, corrected FROM deleted",
+		deleteSQL,
+	)
+
+	txn, err := repo.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer txn.Rollback(ctx)
+
+	rows, err := txn.Query(ctx, sql, vals...)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	var affected []metarGroup
+	seen := make(map[metarGroup]struct{})
+	for rows.Next() {
+		var grp metarGroup
+		var corrected bool
+		if err := rows.Scan(&grp.stationID, &grp.issuedAt, &corrected); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		n++
+
+		// Only corrections supersede other METARs
+		if !corrected {
 			continue
 		}
+		if _, ok := seen[grp]; !ok {
+			seen[grp] = struct{}{}
+			affected = append(affected, grp)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
 
-		metars = append(metars, obj)
+	for _, grp := range affected {
+		if err := repo.relinkSupersession(ctx, txn, grp); err != nil {
+			return 0, err
+		}
+	}
+	if entry != nil {
+		entry.Affected = n
+		if _, err := insertAuditEntry(ctx, txn, entry); err != nil {
+			return 0, err
+		}
 	}
 
 	if err := txn.Commit(ctx); err != nil {
-		return nil, nil, err
+		return 0, err
+	}
+	return n, nil
+}
+
+// Reparse re-derives the report modifiers and the flight category of all METARs following a filter (ignoring
+// metar.Filter.Cursor) from their raw text representation, e.g. after the decoder was improved.
+// The station, issuing time and report type are left untouched. METARs that cannot be decoded anymore are not changed.
+// The supersession links of the stations and issuing times whose corrections changed are restored.
+func (repo *METARRepository) Reparse(ctx context.Context, filter *metar.Filter) (*metar.ReparseResult, error) {
+	type change struct {
+		id             uuid.UUID
+		group          metarGroup
+		corrected      bool
+		automated      bool
+		nil            bool
+		flightCategory metar.FlightCategory
+		relink         bool
 	}
 
-	return metars, uniqueViolations, nil
+	// apply updates the changed METARs of a single batch and restores the affected supersession links
+	apply := func(changes []*change) error {
+		txn, err := repo.db.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer txn.Rollback(ctx)
+
+		var affected []metarGroup
+		seen := make(map[metarGroup]struct{})
+		for _, chg := range changes {
+			_, err := txn.Exec(
+				ctx,
+				"UPDATE metars SET corrected = $3, automated = $4, nil_report = $5, flight_category = $6 WHERE metar_id = $1 AND issued_at = $2",
+				chg.id,
+				chg.group.issuedAt,
+				chg.corrected,
+				chg.automated,
+				chg.nil,
+				chg.flightCategory,
+			)
+			if err != nil {
+				return err
+			}
+			if _, ok := seen[chg.group]; chg.relink && !ok {
+				seen[chg.group] = struct{}{}
+				affected = append(affected, chg.group)
+			}
+		}
+		for _, grp := range affected {
+			if err := repo.relinkSupersession(ctx, txn, grp); err != nil {
+				return err
+			}
+		}
+		return txn.Commit(ctx)
+	}
+
+	conditions := repo.filterConditions(filter)
+	result := new(metar.ReparseResult)
+	var lastID *uuid.UUID
+	for {
+		// The METARs are processed in batches ordered by their ID so that the batches do not depend on the changes
+		query := squirrel.Select("metar_id", "station_id", "issued_at", "raw", "corrected", "automated", "nil_report", "flight_category").
+			From("metars").
+			Where(conditions).
+			OrderBy("metar_id").
+			Limit(uint64(metarBackfillBatchSize))
+		if lastID != nil {
+			query = query.Where(squirrel.Gt{"metar_id": *lastID})
+		}
+		sql, vals, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := repo.db.Query(ctx, sql, vals...)
+		if err != nil {
+			return nil, err
+		}
+		var changes []*change
+		n := 0
+		for rows.Next() {
+			var id uuid.UUID
+			var grp metarGroup
+			var raw string
+			var corrected, automated, isNil bool
+			var flightCategory *string
+			if err := rows.Scan(&id, &grp.stationID, &grp.issuedAt, &raw, &corrected, &automated, &isNil, &flightCategory); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			n++
+			lastID = &id
+
+			report, err := metar.Decode(raw)
+			if err != nil {
+				result.Failed++
+				continue
+			}
+			category := report.FlightCategory()
+			if report.Corrected == corrected && report.Automated == automated && report.Nil == isNil && flightCategory != nil && metar.FlightCategory(*flightCategory) == category {
+				continue
+			}
+			changes = append(changes, &change{
+				id:             id,
+				group:          grp,
+				corrected:      report.Corrected,
+				automated:      report.Automated,
+				nil:            report.Nil,
+				flightCategory: category,
+				relink:         report.Corrected != corrected,
+			})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		result.Processed += int64(n)
+
+		if len(changes) > 0 {
+			if err := apply(changes); err != nil {
+				return nil, err
+			}
+			result.Changed += int64(len(changes))
+		}
+
+		if n < metarBackfillBatchSize {
+			return result, nil
+		}
+	}
 }
 
-// Delete deletes a METAR by its ID
-func (repo *METARRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := repo.db.Exec(ctx, "DELETE FROM metars WHERE metar_id = $1", id)
+// metarGroup identifies the METARs of a station that were issued at the same time and thus may supersede each other
+type metarGroup struct {
+	stationID string
+	issuedAt  int64
+}
+
+// relinkSupersession restores the supersession links of a group of METARs: all of them are superseded by the latest
+// correction of the group (if any)
+func (repo *METARRepository) relinkSupersession(ctx context.Context, txn pgx.Tx, grp metarGroup) error {
+	_, err := txn.Exec(ctx, "UPDATE metars SET superseded_by = NULL WHERE station_id = $1 AND issued_at = $2", grp.stationID, grp.issuedAt)
+	if err != nil {
+		return err
+	}
+	_, err = txn.Exec(
+		ctx,
+		`UPDATE metars SET superseded_by = latest.metar_id
+		FROM (SELECT metar_id FROM metars WHERE station_id = $1 AND issued_at = $2 AND corrected ORDER BY sequence DESC LIMIT 1) AS latest
+		WHERE metars.station_id = $1 AND metars.issued_at = $2 AND metars.metar_id <> latest.metar_id`,
+		grp.stationID,
+		grp.issuedAt,
+	)
 	return err
 }
 
+// linkSupersession links a freshly inserted METAR to the other METARs of the same station and issuing time following
+// the same rule as relinkSupersession. As the METAR has the highest sequence number of its group, a correction
+// supersedes all other METARs while a non-corrected METAR is superseded by the latest existing correction.
+func (repo *METARRepository) linkSupersession(ctx context.Context, txn pgx.Tx, obj *metar.METAR) error {
+	if obj.Corrected {
+		rows, err := txn.Query(
+			ctx,
+			"UPDATE metars SET superseded_by = $1 WHERE station_id = $2 AND issued_at = $3 AND metar_id <> $1 RETURNING metar_id",
+			obj.ID,
+			obj.StationID,
+			obj.IssuedAt,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			obj.Supersedes = append(obj.Supersedes, id)
+		}
+		return rows.Err()
+	}
+
+	var correctionID uuid.UUID
+	err := txn.QueryRow(
+		ctx,
+		"SELECT metar_id FROM metars WHERE station_id = $1 AND issued_at = $2 AND metar_id <> $3 AND corrected ORDER BY sequence DESC LIMIT 1",
+		obj.StationID,
+		obj.IssuedAt,
+		obj.ID,
+	).Scan(&correctionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if _, err := txn.Exec(ctx, "UPDATE metars SET superseded_by = $1 WHERE metar_id = $2 AND issued_at = $3", correctionID, obj.ID, obj.IssuedAt); err != nil {
+		return err
+	}
+	obj.SupersededBy = &correctionID
+	return nil
+}
+
+// backfillFlightCategories derives the flight category of every METAR that was stored before flight categories were
+// introduced
+func (repo *METARRepository) backfillFlightCategories(ctx context.Context) error {
+	for {
+		rows, err := repo.db.Query(ctx, "SELECT metar_id, issued_at, raw FROM metars WHERE flight_category IS NULL LIMIT $1", metarBackfillBatchSize)
+		if err != nil {
+			return err
+		}
+		batch := &pgx.Batch{}
+		for rows.Next() {
+			var id uuid.UUID
+			var issuedAt int64
+			var raw string
+			if err := rows.Scan(&id, &issuedAt, &raw); err != nil {
+				rows.Close()
+				return err
+			}
+
+			// Reports that cannot be decoded anymore are marked with an unknown category so that they are not selected again
+			category := metar.FlightCategoryUnknown
+			if report, err := metar.Decode(raw); err == nil {
+				category = report.FlightCategory()
+			}
+			batch.Queue("UPDATE metars SET flight_category = $3 WHERE metar_id = $1 AND issued_at = $2", id, issuedAt, category)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if batch.Len() == 0 {
+			return nil
+		}
+
+		if err := repo.db.SendBatch(ctx, batch).Close(); err != nil {
+			return err
+		}
+	}
+}
+
+func (repo *METARRepository) filterConditions(filter *metar.Filter) squirrel.And {
+	conditions := squirrel.And{}
+	if filter.StationID != nil {
+		conditions = append(conditions, squirrel.Eq{"station_id": *filter.StationID})
+	}
+	if filter.StationIDs != nil {
+		conditions = append(conditions, squirrel.Expr("station_id = ANY(?)", filter.StationIDs))
+	}
+	if filter.IssuedBefore != nil {
+		conditions = append(conditions, squirrel.Lt{"issued_at": *filter.IssuedBefore})
+	}
+	if filter.IssuedAfter != nil {
+		conditions = append(conditions, squirrel.Gt{"issued_at": *filter.IssuedAfter})
+	}
+	if filter.Type != nil {
+		conditions = append(conditions, squirrel.Eq{"report_type": *filter.Type})
+	}
+	if filter.Corrected != nil {
+		conditions = append(conditions, squirrel.Eq{"corrected": *filter.Corrected})
+	}
+	if filter.Automated != nil {
+		conditions = append(conditions, squirrel.Eq{"automated": *filter.Automated})
+	}
+	if filter.Nil != nil {
+		conditions = append(conditions, squirrel.Eq{"nil_report": *filter.Nil})
+	}
+	if filter.FlightCategory != nil {
+		conditions = append(conditions, squirrel.Eq{"flight_category": *filter.FlightCategory})
+	}
+	if filter.FedBy != nil {
+		conditions = append(conditions, squirrel.Eq{"fed_by": *filter.FedBy})
+	}
+	if filter.ReceivedBefore != nil {
+		conditions = append(conditions, squirrel.Lt{"received_at": *filter.ReceivedBefore})
+	}
+	if filter.ReceivedAfter != nil {
+		conditions = append(conditions, squirrel.Gt{"received_at": *filter.ReceivedAfter})
+	}
+	if !filter.IncludeSuperseded {
+		conditions = append(conditions, squirrel.Eq{"superseded_by": nil})
+	}
+	return conditions
+}
+
 func (repo *METARRepository) rowToMETAR(row pgx.Row) (*metar.METAR, error) {
 	obj := new(metar.METAR)
-	if err := row.Scan(&obj.ID, &obj.StationID, &obj.IssuedAt, &obj.Raw); err != nil {
+	var supersededBy uuid.NullUUID
+	var flightCategory *string
+	var fedBy uuid.NullUUID
+	if err := row.Scan(&obj.ID, &obj.StationID, &obj.IssuedAt, &obj.Raw, &obj.Type, &obj.Corrected, &obj.Automated, &obj.Nil, &supersededBy, &flightCategory, &obj.Sequence, &fedBy, &obj.ReceivedAt, &obj.FeedCount); err != nil {
 		return nil, err
 	}
+	if supersededBy.Valid {
+		obj.SupersededBy = &supersededBy.UUID
+	}
+	if fedBy.Valid {
+		obj.FedBy = &fedBy.UUID
+	}
+	if flightCategory != nil {
+		obj.FlightCategory = metar.FlightCategory(*flightCategory)
+	}
 	return obj, nil
 }
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_report_type_index;
+
+ALTER TABLE metars
+    DROP COLUMN IF EXISTS report_type,
+    DROP COLUMN IF EXISTS corrected,
+    DROP COLUMN IF EXISTS automated,
+    DROP COLUMN IF EXISTS nil_report;
+
+COMMIT;
\ No newline at end of file
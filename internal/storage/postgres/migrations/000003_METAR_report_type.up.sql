@@ -0,0 +1,19 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_report_type_index;
+
+ALTER TABLE metars
+    ADD COLUMN report_type text NOT NULL DEFAULT 'METAR',
+    ADD COLUMN corrected boolean NOT NULL DEFAULT false,
+    ADD COLUMN automated boolean NOT NULL DEFAULT false,
+    ADD COLUMN nil_report boolean NOT NULL DEFAULT false;
+
+-- The report type of already stored METARs is unknown, but their modifiers can be derived from their raw header
+UPDATE metars SET
+    corrected = raw ~ '^COR\s' OR raw ~ '^\S{4}\s+\d{6}Z(\s+(NIL|AUTO))*\s+COR(\s|$)',
+    automated = raw ~ '^(COR\s+)?\S{4}\s+\d{6}Z(\s+(NIL|COR))*\s+AUTO(\s|$)',
+    nil_report = raw ~ '^(COR\s+)?\S{4}\s+\d{6}Z(\s+(AUTO|COR))*\s+NIL(\s|$)';
+
+CREATE INDEX metars_report_type_index ON metars USING HASH (report_type);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_superseded_by_index;
+
+ALTER TABLE metars DROP COLUMN IF EXISTS superseded_by;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,21 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_superseded_by_index;
+
+ALTER TABLE metars ADD COLUMN superseded_by uuid;
+
+-- Link already stored METARs to an already stored correction of the same station and issuing time
+UPDATE metars AS original SET superseded_by = (
+    SELECT correction.metar_id FROM metars AS correction
+    WHERE correction.station_id = original.station_id AND correction.issued_at = original.issued_at AND correction.corrected
+    ORDER BY correction.metar_id
+    LIMIT 1
+)
+WHERE NOT original.corrected AND EXISTS (
+    SELECT 1 FROM metars AS correction
+    WHERE correction.station_id = original.station_id AND correction.issued_at = original.issued_at AND correction.corrected
+);
+
+CREATE INDEX metars_superseded_by_index ON metars (superseded_by);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS tafs_validity_index;
+DROP INDEX IF EXISTS tafs_issued_at_index;
+DROP INDEX IF EXISTS tafs_station_id_index;
+DROP TABLE IF EXISTS tafs;
+
+COMMIT;
\ No newline at end of file
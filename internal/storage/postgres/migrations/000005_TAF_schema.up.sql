@@ -0,0 +1,27 @@
+BEGIN;
+
+DROP INDEX IF EXISTS tafs_validity_index;
+DROP INDEX IF EXISTS tafs_issued_at_index;
+DROP INDEX IF EXISTS tafs_station_id_index;
+DROP TABLE IF EXISTS tafs;
+
+CREATE TABLE tafs (
+    taf_id uuid NOT NULL,
+    station_id text NOT NULL,
+    issued_at bigint NOT NULL,
+    valid_from bigint NOT NULL,
+    valid_until bigint NOT NULL,
+    raw text NOT NULL,
+    amended boolean NOT NULL DEFAULT false,
+    corrected boolean NOT NULL DEFAULT false,
+    cancelled boolean NOT NULL DEFAULT false,
+    nil_report boolean NOT NULL DEFAULT false,
+    PRIMARY KEY (taf_id),
+    UNIQUE (station_id, issued_at, raw)
+);
+
+CREATE INDEX tafs_station_id_index ON tafs USING HASH (station_id);
+CREATE INDEX tafs_issued_at_index ON tafs (issued_at);
+CREATE INDEX tafs_validity_index ON tafs (valid_from, valid_until);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS stations_country_index;
+DROP TABLE IF EXISTS stations;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,22 @@
+BEGIN;
+
+DROP INDEX IF EXISTS stations_country_index;
+DROP TABLE IF EXISTS stations;
+
+CREATE TABLE stations (
+    icao text NOT NULL,
+    iata text NOT NULL DEFAULT '',
+    name text NOT NULL,
+    station_type text NOT NULL,
+    latitude double precision NOT NULL,
+    longitude double precision NOT NULL,
+    elevation integer,
+    country text NOT NULL,
+    region text NOT NULL DEFAULT '',
+    municipality text NOT NULL DEFAULT '',
+    PRIMARY KEY (icao)
+);
+
+CREATE INDEX stations_country_index ON stations USING HASH (country);
+
+COMMIT;
\ No newline at end of file
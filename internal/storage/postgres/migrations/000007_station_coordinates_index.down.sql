@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS stations_coordinates_index;
+
+COMMIT;
\ No newline at end of file
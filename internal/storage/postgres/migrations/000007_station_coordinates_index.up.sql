@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS stations_coordinates_index;
+
+CREATE INDEX stations_coordinates_index ON stations (latitude, longitude);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_flight_category_index;
+
+ALTER TABLE metars DROP COLUMN IF EXISTS flight_category;
+
+COMMIT;
\ No newline at end of file
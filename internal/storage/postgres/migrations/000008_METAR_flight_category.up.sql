@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_flight_category_index;
+
+-- The flight category can only be derived by decoding the raw METAR; it is NULL until it was computed
+ALTER TABLE metars ADD COLUMN flight_category text;
+
+CREATE INDEX metars_flight_category_index ON metars USING HASH (flight_category);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_keyset_index;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_keyset_index;
+
+-- Supports the keyset pagination over the issuing time using the METAR ID as a tie-breaker
+CREATE INDEX metars_keyset_index ON metars (issued_at, metar_id);
+
+COMMIT;
\ No newline at end of file
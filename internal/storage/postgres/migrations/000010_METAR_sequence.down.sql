@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_sequence_index;
+
+ALTER TABLE metars DROP COLUMN IF EXISTS sequence;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_sequence_index;
+
+-- The sequence number reflects the order METARs were inserted in (used to resume event streams)
+ALTER TABLE metars ADD COLUMN sequence bigserial;
+
+CREATE UNIQUE INDEX metars_sequence_index ON metars (sequence);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS webhook_delivery_attempts_attempted_at_index;
+DROP INDEX IF EXISTS webhook_delivery_attempts_webhook_index;
+DROP INDEX IF EXISTS webhooks_user_id_index;
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP TABLE IF EXISTS webhooks;
+
+COMMIT;
\ No newline at end of file
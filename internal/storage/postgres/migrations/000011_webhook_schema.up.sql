@@ -0,0 +1,42 @@
+BEGIN;
+
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP TABLE IF EXISTS webhooks;
+
+CREATE TABLE webhooks (
+    webhook_id uuid NOT NULL,
+    user_id text NOT NULL,
+    key_id uuid NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    station_ids text[] NOT NULL,
+    enabled boolean NOT NULL DEFAULT true,
+    consecutive_failures integer NOT NULL DEFAULT 0,
+    created_at bigint NOT NULL,
+    PRIMARY KEY (webhook_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (key_id) REFERENCES api_keys(key_id) ON DELETE CASCADE
+);
+
+CREATE INDEX webhooks_user_id_index ON webhooks USING HASH (user_id);
+
+CREATE TABLE webhook_delivery_attempts (
+    attempt_id uuid NOT NULL,
+    webhook_id uuid NOT NULL,
+    delivery_id uuid NOT NULL,
+    attempt integer NOT NULL,
+    attempted_at bigint NOT NULL,
+    metar_count integer NOT NULL,
+    status_code integer,
+    error text NOT NULL DEFAULT '',
+    success boolean NOT NULL,
+    duration_ms bigint NOT NULL,
+    PRIMARY KEY (attempt_id),
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(webhook_id) ON DELETE CASCADE
+);
+
+CREATE INDEX webhook_delivery_attempts_webhook_index ON webhook_delivery_attempts (webhook_id, attempted_at DESC);
+CREATE INDEX webhook_delivery_attempts_attempted_at_index ON webhook_delivery_attempts (attempted_at);
+
+COMMIT;
\ No newline at end of file
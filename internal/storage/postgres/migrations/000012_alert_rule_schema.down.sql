@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS alert_rules_station_id_index;
+DROP INDEX IF EXISTS alert_rules_user_id_index;
+DROP TABLE IF EXISTS alert_rules;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,25 @@
+BEGIN;
+
+DROP TABLE IF EXISTS alert_rules;
+
+CREATE TABLE alert_rules (
+    rule_id uuid NOT NULL,
+    user_id text NOT NULL,
+    name text NOT NULL,
+    station_id text NOT NULL,
+    conditions jsonb NOT NULL,
+    webhook_id uuid,
+    enabled boolean NOT NULL DEFAULT true,
+    triggered boolean NOT NULL DEFAULT false,
+    last_evaluated_at bigint NOT NULL DEFAULT 0,
+    last_triggered_at bigint,
+    created_at bigint NOT NULL,
+    PRIMARY KEY (rule_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(webhook_id) ON DELETE SET NULL
+);
+
+CREATE INDEX alert_rules_user_id_index ON alert_rules USING HASH (user_id);
+CREATE INDEX alert_rules_station_id_index ON alert_rules USING HASH (station_id);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_fed_by_index;
+
+ALTER TABLE metars DROP COLUMN IF EXISTS feed_count;
+ALTER TABLE metars DROP COLUMN IF EXISTS received_at;
+ALTER TABLE metars DROP COLUMN IF EXISTS fed_by;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_fed_by_index;
+
+-- The provenance of a METAR: the API key that first fed it, the time it was received at and how often it was fed.
+-- METARs stored before the provenance was tracked have no feeding API key and receiving time.
+ALTER TABLE metars ADD COLUMN fed_by uuid;
+ALTER TABLE metars ADD COLUMN received_at bigint;
+ALTER TABLE metars ADD COLUMN feed_count integer NOT NULL DEFAULT 1;
+
+CREATE INDEX metars_fed_by_index ON metars (fed_by, received_at);
+
+COMMIT;
\ No newline at end of file
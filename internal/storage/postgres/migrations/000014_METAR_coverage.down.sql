@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metar_coverage_day_index;
+DROP TABLE IF EXISTS metar_coverage;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,23 @@
+BEGIN;
+
+DROP TABLE IF EXISTS metar_coverage;
+
+-- Daily coverage and latency statistics per station, refreshed periodically from the metars table.
+-- All durations are given in seconds.
+CREATE TABLE metar_coverage (
+    station_id text NOT NULL,
+    day bigint NOT NULL,
+    reports bigint NOT NULL,
+    first_issued_at bigint NOT NULL,
+    last_issued_at bigint NOT NULL,
+    largest_gap bigint,
+    latency_p50 bigint,
+    latency_p90 bigint,
+    latency_p99 bigint,
+    refreshed_at bigint NOT NULL,
+    PRIMARY KEY (station_id, day)
+);
+
+CREATE INDEX metar_coverage_day_index ON metar_coverage (day);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS audit_log_created_at_index;
+DROP INDEX IF EXISTS audit_log_action_index;
+DROP TABLE IF EXISTS audit_log;
+
+COMMIT;
\ No newline at end of file
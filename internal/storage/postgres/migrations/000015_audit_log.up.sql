@@ -0,0 +1,20 @@
+BEGIN;
+
+DROP TABLE IF EXISTS audit_log;
+
+-- Entries are kept when the user who performed the action is deleted, so there is no foreign key to users
+CREATE TABLE audit_log (
+    entry_id uuid NOT NULL,
+    user_id text NOT NULL,
+    action text NOT NULL,
+    target text,
+    parameters jsonb NOT NULL,
+    affected bigint NOT NULL,
+    created_at bigint NOT NULL,
+    PRIMARY KEY (entry_id)
+);
+
+CREATE INDEX audit_log_action_index ON audit_log USING HASH (action);
+CREATE INDEX audit_log_created_at_index ON audit_log (created_at);
+
+COMMIT;
\ No newline at end of file
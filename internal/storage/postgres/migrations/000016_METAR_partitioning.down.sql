@@ -0,0 +1,40 @@
+BEGIN;
+
+-- Partitions that were detached by the retention policy are not restored
+ALTER TABLE metars RENAME TO metars_partitioned;
+ALTER SEQUENCE metars_sequence_seq OWNED BY NONE;
+
+CREATE TABLE metars (
+    metar_id uuid NOT NULL,
+    station_id text NOT NULL,
+    issued_at bigint NOT NULL,
+    raw text NOT NULL,
+    report_type text NOT NULL DEFAULT 'METAR',
+    corrected boolean NOT NULL DEFAULT false,
+    automated boolean NOT NULL DEFAULT false,
+    nil_report boolean NOT NULL DEFAULT false,
+    superseded_by uuid,
+    flight_category text,
+    sequence bigint NOT NULL DEFAULT nextval('metars_sequence_seq'),
+    fed_by uuid,
+    received_at bigint,
+    feed_count integer NOT NULL DEFAULT 1
+);
+
+INSERT INTO metars SELECT * FROM metars_partitioned;
+DROP TABLE metars_partitioned;
+ALTER SEQUENCE metars_sequence_seq OWNED BY metars.sequence;
+
+ALTER TABLE metars ADD PRIMARY KEY (metar_id);
+ALTER TABLE metars ADD UNIQUE (station_id, issued_at, raw);
+
+CREATE INDEX metars_station_id_index ON metars USING HASH (station_id);
+CREATE INDEX metars_issued_at_index ON metars (issued_at);
+CREATE INDEX metars_report_type_index ON metars USING HASH (report_type);
+CREATE INDEX metars_superseded_by_index ON metars (superseded_by);
+CREATE INDEX metars_flight_category_index ON metars USING HASH (flight_category);
+CREATE INDEX metars_keyset_index ON metars (issued_at, metar_id);
+CREATE UNIQUE INDEX metars_sequence_index ON metars (sequence);
+CREATE INDEX metars_fed_by_index ON metars (fed_by, received_at);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,68 @@
+BEGIN;
+
+-- METARs are range-partitioned by their issuing time with one partition ('metars_pYYYYMM') per month (UTC). Unique
+-- constraints of partitioned tables have to contain the partition key, so the METAR ID is only guaranteed to be unique
+-- together with the issuing time; the sequence numbers are still drawn from the same sequence.
+-- All stored METARs are copied within this transaction, which blocks reading and writing METARs until it completes.
+ALTER TABLE metars RENAME TO metars_unpartitioned;
+ALTER SEQUENCE metars_sequence_seq OWNED BY NONE;
+
+CREATE TABLE metars (
+    metar_id uuid NOT NULL,
+    station_id text NOT NULL,
+    issued_at bigint NOT NULL,
+    raw text NOT NULL,
+    report_type text NOT NULL DEFAULT 'METAR',
+    corrected boolean NOT NULL DEFAULT false,
+    automated boolean NOT NULL DEFAULT false,
+    nil_report boolean NOT NULL DEFAULT false,
+    superseded_by uuid,
+    flight_category text,
+    sequence bigint NOT NULL DEFAULT nextval('metars_sequence_seq'),
+    fed_by uuid,
+    received_at bigint,
+    feed_count integer NOT NULL DEFAULT 1
+) PARTITION BY RANGE (issued_at);
+
+-- Holds the METARs that are not covered by a monthly partition (yet)
+CREATE TABLE metars_default PARTITION OF metars DEFAULT;
+
+-- Create the monthly partitions covering the already stored METARs and the next three months
+DO $$
+DECLARE
+    current_month timestamp := date_trunc('month', now() AT TIME ZONE 'UTC');
+    partition_month timestamp;
+BEGIN
+    SELECT date_trunc('month', to_timestamp(MIN(issued_at)) AT TIME ZONE 'UTC') INTO partition_month FROM metars_unpartitioned;
+    IF partition_month IS NULL OR partition_month > current_month THEN
+        partition_month := current_month;
+    END IF;
+    WHILE partition_month <= current_month + interval '3 months' LOOP
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF metars FOR VALUES FROM (%s) TO (%s)',
+            'metars_p' || to_char(partition_month, 'YYYYMM'),
+            extract(epoch FROM partition_month AT TIME ZONE 'UTC')::bigint,
+            extract(epoch FROM (partition_month + interval '1 month') AT TIME ZONE 'UTC')::bigint
+        );
+        partition_month := partition_month + interval '1 month';
+    END LOOP;
+END
+$$;
+
+INSERT INTO metars SELECT * FROM metars_unpartitioned;
+DROP TABLE metars_unpartitioned;
+ALTER SEQUENCE metars_sequence_seq OWNED BY metars.sequence;
+
+ALTER TABLE metars ADD PRIMARY KEY (metar_id, issued_at);
+ALTER TABLE metars ADD UNIQUE (station_id, issued_at, raw);
+
+CREATE INDEX metars_station_id_index ON metars USING HASH (station_id);
+CREATE INDEX metars_issued_at_index ON metars (issued_at);
+CREATE INDEX metars_report_type_index ON metars USING HASH (report_type);
+CREATE INDEX metars_superseded_by_index ON metars (superseded_by);
+CREATE INDEX metars_flight_category_index ON metars USING HASH (flight_category);
+CREATE INDEX metars_keyset_index ON metars (issued_at, metar_id);
+CREATE INDEX metars_sequence_index ON metars (sequence);
+CREATE INDEX metars_fed_by_index ON metars (fed_by, received_at);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE metars ALTER COLUMN feed_count SET DEFAULT 1;
+
+DROP INDEX IF EXISTS metar_feeders_issued_at_index;
+DROP TABLE IF EXISTS metar_feeders;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,27 @@
+BEGIN;
+
+DROP TABLE IF EXISTS metar_feeders;
+
+-- The distinct API keys that fed a METAR. As METARs live in monthly partitions and are retired together with them,
+-- there is no foreign key to metars; the issuing time allows retiring the feeders together with their METARs.
+CREATE TABLE metar_feeders (
+    metar_id uuid NOT NULL,
+    api_key_id uuid NOT NULL,
+    issued_at bigint NOT NULL,
+    received_at bigint NOT NULL,
+    PRIMARY KEY (metar_id, api_key_id)
+);
+
+CREATE INDEX metar_feeders_issued_at_index ON metar_feeders (issued_at);
+
+-- Only the first feeder of the existing METARs is known. Their feed count used to include repeated submissions of the
+-- same API key, so it is derived from the first feeder as well.
+INSERT INTO metar_feeders
+SELECT metar_id, fed_by, issued_at, COALESCE(received_at, 0) FROM metars WHERE fed_by IS NOT NULL;
+
+UPDATE metars SET feed_count = CASE WHEN fed_by IS NULL THEN 0 ELSE 1 END
+WHERE feed_count <> CASE WHEN fed_by IS NULL THEN 0 ELSE 1 END;
+
+ALTER TABLE metars ALTER COLUMN feed_count SET DEFAULT 0;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_flight_category_pending_index;
+
+COMMIT;
\ No newline at end of file
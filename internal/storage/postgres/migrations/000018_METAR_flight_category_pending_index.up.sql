@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metars_flight_category_pending_index;
+
+-- The HASH index on flight_category cannot serve IS NULL conditions; this index lets the flight category backfill find
+-- the remaining METARs without scanning the whole table
+CREATE INDEX metars_flight_category_pending_index ON metars (metar_id) WHERE flight_category IS NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Existing API keys and API key policies that may read METARs may read stations as well (bit 1 = reading METARs,
+-- bit 16 = reading stations)
+UPDATE api_keys SET capabilities = capabilities | 16 WHERE capabilities & 1 <> 0;
+UPDATE user_api_key_policies SET allowed_capabilities = allowed_capabilities | 16 WHERE allowed_capabilities & 1 <> 0;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN;
+
+-- The granted capabilities are kept as they cannot be told apart from the ones granted afterwards
+
+COMMIT;
\ No newline at end of file
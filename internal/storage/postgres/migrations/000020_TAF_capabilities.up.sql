@@ -0,0 +1,10 @@
+BEGIN;
+
+-- Existing API keys and API key policies that may read or feed METARs may read or feed TAFs as well (bit 1 = reading
+-- METARs, bit 2 = feeding METARs, bit 4 = reading TAFs, bit 8 = feeding TAFs)
+UPDATE api_keys SET capabilities = capabilities | 4 WHERE capabilities & 1 <> 0;
+UPDATE api_keys SET capabilities = capabilities | 8 WHERE capabilities & 2 <> 0;
+UPDATE user_api_key_policies SET allowed_capabilities = allowed_capabilities | 4 WHERE allowed_capabilities & 1 <> 0;
+UPDATE user_api_key_policies SET allowed_capabilities = allowed_capabilities | 8 WHERE allowed_capabilities & 2 <> 0;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN;
+
+-- The previous supersession links are not restored
+
+COMMIT;
\ No newline at end of file
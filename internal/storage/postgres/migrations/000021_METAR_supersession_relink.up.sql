@@ -0,0 +1,26 @@
+BEGIN;
+
+-- Migration 000004 linked the originals to an arbitrary correction and left older corrections effective. Every METAR is
+-- relinked to the latest correction (by sequence number) of its station and issuing time, just like the repository
+-- does when corrections are deleted or re-parsed.
+CREATE TEMPORARY TABLE latest_metar_corrections ON COMMIT DROP AS
+    SELECT DISTINCT ON (station_id, issued_at) station_id, issued_at, metar_id
+    FROM metars
+    WHERE corrected
+    ORDER BY station_id, issued_at, sequence DESC;
+
+UPDATE metars SET superseded_by = NULL
+WHERE superseded_by IS NOT NULL AND (
+    metar_id IN (SELECT metar_id FROM latest_metar_corrections)
+    OR NOT EXISTS (
+        SELECT 1 FROM latest_metar_corrections AS latest
+        WHERE latest.station_id = metars.station_id AND latest.issued_at = metars.issued_at
+    )
+);
+
+UPDATE metars SET superseded_by = latest.metar_id
+FROM latest_metar_corrections AS latest
+WHERE metars.station_id = latest.station_id AND metars.issued_at = latest.issued_at AND metars.metar_id <> latest.metar_id
+    AND metars.superseded_by IS DISTINCT FROM latest.metar_id;
+
+COMMIT;
\ No newline at end of file
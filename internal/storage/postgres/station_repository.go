@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/skybi/pluteo/internal/station"
+	"strings"
+)
+
+// stationUpsertBatchSize defines how many stations are sent to the database in a single batch
+var stationUpsertBatchSize = 1000
+
+// StationRepository implements the station.Repository interface using PostgreSQL
+type StationRepository struct {
+	db *pgxpool.Pool
+}
+
+var _ station.Repository = (*StationRepository)(nil)
+
+// GetByFilter retrieves multiple stations following a filter, ordered by their ICAO code
+func (repo *StationRepository) GetByFilter(ctx context.Context, filter *station.Filter, offset, limit uint64) ([]*station.Station, uint64, error) {
+	// Construct the SQL queries
+	conditions := repo.filterConditions(filter)
+	countQuery := squirrel.Select("COUNT(*)").From("stations").Where(conditions)
+	query := squirrel.Select("*").From("stations").Where(conditions).OrderBy("icao").Offset(offset).Limit(limit)
+	countSQL, countVals, err := countQuery.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	sql, vals, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Fetch the total amount of stations that matches the given filter
+	var n uint64
+	if err := repo.db.QueryRow(ctx, countSQL, countVals...).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+	if n == 0 {
+		return []*station.Station{}, 0, nil
+	}
+
+	// Fetch the station objects themselves
+	rows, err := repo.db.Query(ctx, sql, vals...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []*station.Station{}, n, nil
+		}
+		return nil, 0, err
+	}
+	defer rows.Close()
+	objs := []*station.Station{}
+	for rows.Next() {
+		obj, err := repo.rowToStation(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		objs = append(objs, obj)
+	}
+
+	return objs, n, nil
+}
+
+// GetByICAO retrieves a station by its ICAO code
+func (repo *StationRepository) GetByICAO(ctx context.Context, icao string) (*station.Station, error) {
+	row := repo.db.QueryRow(ctx, "SELECT * FROM stations WHERE icao = $1", icao)
+	obj, err := repo.rowToStation(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+// GetByICAOs retrieves multiple stations by their ICAO codes.
+// Unknown stations are not contained in the resulting map.
+func (repo *StationRepository) GetByICAOs(ctx context.Context, icaos []string) (map[string]*station.Station, error) {
+	objs := make(map[string]*station.Station, len(icaos))
+	if len(icaos) == 0 {
+		return objs, nil
+	}
+
+	rows, err := repo.db.Query(ctx, "SELECT * FROM stations WHERE icao = ANY($1)", icaos)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		obj, err := repo.rowToStation(rows)
+		if err != nil {
+			return nil, err
+		}
+		objs[obj.ICAO] = obj
+	}
+	return objs, rows.Err()
+}
+
+// GetWithinBounds retrieves the stations located inside the given bounding box, at most limit ones
+func (repo *StationRepository) GetWithinBounds(ctx context.Context, bounds *station.Bounds, limit uint64) ([]*station.Station, error) {
+	conditions := squirrel.And{
+		squirrel.GtOrEq{"latitude": bounds.MinLatitude},
+		squirrel.LtOrEq{"latitude": bounds.MaxLatitude},
+	}
+	if bounds.MinLongitude > bounds.MaxLongitude {
+		conditions = append(conditions, squirrel.Or{
+			squirrel.GtOrEq{"longitude": bounds.MinLongitude},
+			squirrel.LtOrEq{"longitude": bounds.MaxLongitude},
+		})
+	} else {
+		conditions = append(conditions, squirrel.GtOrEq{"longitude": bounds.MinLongitude}, squirrel.LtOrEq{"longitude": bounds.MaxLongitude})
+	}
+	sql, vals, err := squirrel.Select("*").From("stations").Where(conditions).OrderBy("icao").Limit(limit).PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(ctx, sql, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	objs := []*station.Station{}
+	for rows.Next() {
+		obj, err := repo.rowToStation(rows)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, rows.Err()
+}
+
+// Upsert creates the given stations or updates them if a station with the same ICAO code already exists.
+// It returns the amount of affected stations.
+func (repo *StationRepository) Upsert(ctx context.Context, stations []*station.Station) (int, error) {
+	txn, err := repo.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer txn.Rollback(ctx)
+
+	n := 0
+	for start := 0; start < len(stations); start += stationUpsertBatchSize {
+		end := start + stationUpsertBatchSize
+		if end > len(stations) {
+			end = len(stations)
+		}
+
+		batch := &pgx.Batch{}
+		for _, obj := range stations[start:end] {
+			batch.Queue(
+				`INSERT INTO stations VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+				ON CONFLICT (icao) DO UPDATE SET iata = $2, name = $3, station_type = $4, latitude = $5, longitude = $6, elevation = $7, country = $8, region = $9, municipality = $10`,
+				obj.ICAO,
+				obj.IATA,
+				obj.Name,
+				obj.Type,
+				obj.Latitude,
+				obj.Longitude,
+				obj.Elevation,
+				obj.Country,
+				obj.Region,
+				obj.Municipality,
+			)
+		}
+
+		results := txn.SendBatch(ctx, batch)
+		for i := start; i < end; i++ {
+			tag, err := results.Exec()
+			if err != nil {
+				results.Close()
+				return 0, err
+			}
+			n += int(tag.RowsAffected())
+		}
+		if err := results.Close(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := txn.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Delete deletes a station by its ICAO code
+func (repo *StationRepository) Delete(ctx context.Context, icao string) error {
+	_, err := repo.db.Exec(ctx, "DELETE FROM stations WHERE icao = $1", icao)
+	return err
+}
+
+func (repo *StationRepository) filterConditions(filter *station.Filter) squirrel.And {
+	conditions := squirrel.And{}
+	if filter.Country != nil {
+		conditions = append(conditions, squirrel.Eq{"country": *filter.Country})
+	}
+	if filter.Type != nil {
+		conditions = append(conditions, squirrel.Eq{"station_type": *filter.Type})
+	}
+	if filter.Search != nil {
+		pattern := "%" + escapeLikePattern(*filter.Search) + "%"
+		conditions = append(conditions, squirrel.Or{
+			squirrel.ILike{"icao": pattern},
+			squirrel.ILike{"iata": pattern},
+			squirrel.ILike{"name": pattern},
+		})
+	}
+	return conditions
+}
+
+func (repo *StationRepository) rowToStation(row pgx.Row) (*station.Station, error) {
+	obj := new(station.Station)
+	if err := row.Scan(&obj.ICAO, &obj.IATA, &obj.Name, &obj.Type, &obj.Latitude, &obj.Longitude, &obj.Elevation, &obj.Country, &obj.Region, &obj.Municipality); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// escapeLikePattern escapes all characters that have a special meaning inside a LIKE pattern
+func escapeLikePattern(str string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(str)
+}
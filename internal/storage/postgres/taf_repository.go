@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/skybi/pluteo/internal/taf"
+)
+
+// TAFRepository implements the taf.Repository interface using PostgreSQL
+type TAFRepository struct {
+	db *pgxpool.Pool
+}
+
+var _ taf.Repository = (*TAFRepository)(nil)
+
+// GetByFilter retrieves multiple TAFs following a filter, ordered by their issuing date (descending).
+// If limit <= 0, a default limit value of 10 is used.
+func (repo *TAFRepository) GetByFilter(ctx context.Context, filter *taf.Filter, limit uint64) ([]*taf.TAF, uint64, error) {
+	// Construct the SQL queries
+	conditions := repo.filterConditions(filter)
+	countQuery := squirrel.Select("COUNT(*)").From("tafs").Where(conditions)
+	query := squirrel.Select("*").From("tafs").Where(conditions).OrderBy("issued_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	} else if limit <= 0 {
+		query = query.Limit(10)
+	}
+	countSQL, countVals, err := countQuery.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	sql, vals, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Fetch the total amount of TAFs that matches the given filter
+	var n uint64
+	if err := repo.db.QueryRow(ctx, countSQL, countVals...).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+	if n == 0 {
+		return []*taf.TAF{}, 0, nil
+	}
+
+	// Fetch the TAF objects themselves
+	rows, err := repo.db.Query(ctx, sql, vals...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []*taf.TAF{}, n, nil
+		}
+		return nil, 0, err
+	}
+	objs := []*taf.TAF{}
+	for rows.Next() {
+		obj, err := repo.rowToTAF(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		objs = append(objs, obj)
+	}
+
+	return objs, n, nil
+}
+
+// GetByID retrieves a TAF by its ID
+func (repo *TAFRepository) GetByID(ctx context.Context, id uuid.UUID) (*taf.TAF, error) {
+	row := repo.db.QueryRow(ctx, "SELECT * FROM tafs WHERE taf_id = $1", id)
+	obj, err := repo.rowToTAF(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Create creates new TAFs based on their raw text representation.
+// All raw strings are sanitized (leading and trailing spaces are trimmed).
+// This method also returns the indexes of the TAFs that already exist in the database and thus were not inserted.
+// The given parse options are passed to taf.OfString for every raw string.
+func (repo *TAFRepository) Create(ctx context.Context, raw []string, opts ...taf.ParseOption) ([]*taf.TAF, []uint, error) {
+	txn, err := repo.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer txn.Rollback(ctx)
+
+	tafs := make([]*taf.TAF, 0, len(raw))
+	uniqueViolations := []uint{}
+
+	for i, str := range raw {
+		// Parse the raw string into a taf.TAF object
+		obj, err := taf.OfString(str, opts...)
+		if err != nil {
+			var formatErr *taf.FormatError
+			if errors.As(err, &formatErr) {
+				return nil, nil, &taf.FormatError{
+					Wrapping: fmt.Errorf("error in TAF no. %d: %s", i, err.Error()),
+					Index:    i,
+				}
+			}
+			return nil, nil, err
+		}
+
+		// Insert the TAF into the database
+		tag, err := txn.Exec(
+			ctx,
+			"INSERT INTO tafs VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT DO NOTHING",
+			obj.ID,
+			obj.StationID,
+			obj.IssuedAt,
+			obj.ValidFrom,
+			obj.ValidUntil,
+			obj.Raw,
+			obj.Amended,
+			obj.Corrected,
+			obj.Cancelled,
+			obj.Nil,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tag.RowsAffected() == 0 {
+			uniqueViolations = append(uniqueViolations, uint(i))
+			continue
+		}
+
+		tafs = append(tafs, obj)
+	}
+
+	if err := txn.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return tafs, uniqueViolations, nil
+}
+
+// Delete deletes a TAF by its ID
+func (repo *TAFRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := repo.db.Exec(ctx, "DELETE FROM tafs WHERE taf_id = $1", id)
+	return err
+}
+
+func (repo *TAFRepository) filterConditions(filter *taf.Filter) squirrel.And {
+	conditions := squirrel.And{}
+	if filter.StationID != nil {
+		conditions = append(conditions, squirrel.Eq{"station_id": *filter.StationID})
+	}
+	if filter.IssuedBefore != nil {
+		conditions = append(conditions, squirrel.Lt{"issued_at": *filter.IssuedBefore})
+	}
+	if filter.IssuedAfter != nil {
+		conditions = append(conditions, squirrel.Gt{"issued_at": *filter.IssuedAfter})
+	}
+	if filter.ValidAt != nil {
+		conditions = append(conditions, squirrel.LtOrEq{"valid_from": *filter.ValidAt}, squirrel.Gt{"valid_until": *filter.ValidAt})
+	}
+	return conditions
+}
+
+func (repo *TAFRepository) rowToTAF(row pgx.Row) (*taf.TAF, error) {
+	obj := new(taf.TAF)
+	if err := row.Scan(&obj.ID, &obj.StationID, &obj.IssuedAt, &obj.ValidFrom, &obj.ValidUntil, &obj.Raw, &obj.Amended, &obj.Corrected, &obj.Cancelled, &obj.Nil); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
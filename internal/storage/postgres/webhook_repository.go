@@ -0,0 +1,267 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/skybi/pluteo/internal/secret"
+	"github.com/skybi/pluteo/internal/webhook"
+	"time"
+)
+
+var webhookSecretLength = 32
+
+// WebhookRepository implements the webhook.Repository interface using PostgreSQL
+type WebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+var _ webhook.Repository = (*WebhookRepository)(nil)
+
+// Get retrieves multiple webhooks
+func (repo *WebhookRepository) Get(ctx context.Context, offset, limit uint64) ([]*webhook.Webhook, uint64, error) {
+	return repo.getByConditions(ctx, squirrel.And{}, offset, limit)
+}
+
+// GetByUserID retrieves multiple webhooks of a specific user
+func (repo *WebhookRepository) GetByUserID(ctx context.Context, userID string, offset, limit uint64) ([]*webhook.Webhook, uint64, error) {
+	return repo.getByConditions(ctx, squirrel.And{squirrel.Eq{"user_id": userID}}, offset, limit)
+}
+
+func (repo *WebhookRepository) getByConditions(ctx context.Context, conditions squirrel.And, offset, limit uint64) ([]*webhook.Webhook, uint64, error) {
+	if limit == 0 {
+		limit = 10
+	}
+
+	// Construct the SQL queries
+	countQuery := squirrel.Select("COUNT(*)").From("webhooks").Where(conditions)
+	query := squirrel.Select("*").From("webhooks").Where(conditions).OrderBy("created_at", "webhook_id").Offset(offset).Limit(limit)
+	countSQL, countVals, err := countQuery.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	sql, vals, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Fetch the total amount of webhooks that matches the given conditions
+	var n uint64
+	if err := repo.db.QueryRow(ctx, countSQL, countVals...).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+	if n == 0 {
+		return []*webhook.Webhook{}, 0, nil
+	}
+
+	// Fetch the webhook objects themselves
+	rows, err := repo.db.Query(ctx, sql, vals...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []*webhook.Webhook{}, n, nil
+		}
+		return nil, 0, err
+	}
+	defer rows.Close()
+	hooks := []*webhook.Webhook{}
+	for rows.Next() {
+		hook, err := repo.rowToWebhook(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, n, nil
+}
+
+// GetByID retrieves a webhook by its ID
+func (repo *WebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*webhook.Webhook, error) {
+	row := repo.db.QueryRow(ctx, "SELECT * FROM webhooks WHERE webhook_id = $1", id)
+	hook, err := repo.rowToWebhook(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return hook, nil
+}
+
+// GetEnabled retrieves all enabled webhooks
+func (repo *WebhookRepository) GetEnabled(ctx context.Context) ([]*webhook.Webhook, error) {
+	rows, err := repo.db.Query(ctx, "SELECT * FROM webhooks WHERE enabled")
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []*webhook.Webhook{}, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+	hooks := []*webhook.Webhook{}
+	for rows.Next() {
+		hook, err := repo.rowToWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
+
+// Create creates a new webhook with a newly generated secret
+func (repo *WebhookRepository) Create(ctx context.Context, create *webhook.Create) (*webhook.Webhook, error) {
+	hookSecret, _ := secret.MustNew(webhookSecretLength)
+	hook := &webhook.Webhook{
+		ID:                  uuid.New(),
+		UserID:              create.UserID,
+		KeyID:               create.KeyID,
+		URL:                 create.URL,
+		Secret:              hookSecret,
+		Description:         create.Description,
+		StationIDs:          create.StationIDs,
+		Enabled:             true,
+		ConsecutiveFailures: 0,
+		CreatedAt:           time.Now().Unix(),
+	}
+
+	_, err := repo.db.Exec(
+		ctx,
+		"INSERT INTO webhooks VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		hook.ID,
+		hook.UserID,
+		hook.KeyID,
+		hook.URL,
+		hook.Secret,
+		hook.Description,
+		hook.StationIDs,
+		hook.Enabled,
+		hook.ConsecutiveFailures,
+		hook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// Update updates a webhook
+func (repo *WebhookRepository) Update(ctx context.Context, id uuid.UUID, update *webhook.Update) (*webhook.Webhook, error) {
+	// Simply re-fetch the webhook if nothing should be changed
+	if update.URL == nil && update.Description == nil && update.StationIDs == nil && update.Enabled == nil && update.ConsecutiveFailures == nil {
+		return repo.GetByID(ctx, id)
+	}
+
+	// Build the SQL query
+	query := squirrel.Update("webhooks").Where(squirrel.Eq{"webhook_id": id})
+	if update.URL != nil {
+		query = query.Set("url", *update.URL)
+	}
+	if update.Description != nil {
+		query = query.Set("description", *update.Description)
+	}
+	if update.StationIDs != nil {
+		query = query.Set("station_ids", update.StationIDs)
+	}
+	if update.Enabled != nil {
+		query = query.Set("enabled", *update.Enabled)
+	}
+	if update.ConsecutiveFailures != nil {
+		query = query.Set("consecutive_failures", *update.ConsecutiveFailures)
+	}
+	sql, values, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	// Perform the SQL query
+	if _, err := repo.db.Exec(ctx, sql, values...); err != nil {
+		return nil, err
+	}
+
+	// Re-fetch the webhook
+	return repo.GetByID(ctx, id)
+}
+
+// Delete deletes a webhook and its delivery attempts by its ID
+func (repo *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := repo.db.Exec(ctx, "DELETE FROM webhooks WHERE webhook_id = $1", id)
+	return err
+}
+
+// GetAttempts retrieves multiple delivery attempts of a specific webhook, ordered by their time (descending)
+func (repo *WebhookRepository) GetAttempts(ctx context.Context, webhookID uuid.UUID, offset, limit uint64) ([]*webhook.DeliveryAttempt, uint64, error) {
+	if limit == 0 {
+		limit = 10
+	}
+
+	var n uint64
+	if err := repo.db.QueryRow(ctx, "SELECT COUNT(*) FROM webhook_delivery_attempts WHERE webhook_id = $1", webhookID).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+	if n == 0 {
+		return []*webhook.DeliveryAttempt{}, 0, nil
+	}
+
+	rows, err := repo.db.Query(
+		ctx,
+		"SELECT * FROM webhook_delivery_attempts WHERE webhook_id = $1 ORDER BY attempted_at DESC, attempt DESC OFFSET $2 LIMIT $3",
+		webhookID,
+		offset,
+		limit,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []*webhook.DeliveryAttempt{}, n, nil
+		}
+		return nil, 0, err
+	}
+	defer rows.Close()
+	attempts := []*webhook.DeliveryAttempt{}
+	for rows.Next() {
+		attempt := new(webhook.DeliveryAttempt)
+		if err := rows.Scan(&attempt.ID, &attempt.WebhookID, &attempt.DeliveryID, &attempt.Attempt, &attempt.AttemptedAt, &attempt.METARCount, &attempt.StatusCode, &attempt.Error, &attempt.Success, &attempt.Duration); err != nil {
+			return nil, 0, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, n, nil
+}
+
+// RecordAttempt stores a delivery attempt
+func (repo *WebhookRepository) RecordAttempt(ctx context.Context, attempt *webhook.DeliveryAttempt) error {
+	_, err := repo.db.Exec(
+		ctx,
+		"INSERT INTO webhook_delivery_attempts VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		attempt.ID,
+		attempt.WebhookID,
+		attempt.DeliveryID,
+		attempt.Attempt,
+		attempt.AttemptedAt,
+		attempt.METARCount,
+		attempt.StatusCode,
+		attempt.Error,
+		attempt.Success,
+		attempt.Duration,
+	)
+	return err
+}
+
+// DeleteAttemptsBefore deletes all delivery attempts that took place before the given unix timestamp
+func (repo *WebhookRepository) DeleteAttemptsBefore(ctx context.Context, timestamp int64) (int64, error) {
+	result, err := repo.db.Exec(ctx, "DELETE FROM webhook_delivery_attempts WHERE attempted_at < $1", timestamp)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+func (repo *WebhookRepository) rowToWebhook(row pgx.Row) (*webhook.Webhook, error) {
+	obj := new(webhook.Webhook)
+	if err := row.Scan(&obj.ID, &obj.UserID, &obj.KeyID, &obj.URL, &obj.Secret, &obj.Description, &obj.StationIDs, &obj.Enabled, &obj.ConsecutiveFailures, &obj.CreatedAt); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,45 @@
+package taf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// FormatError represents an error in a TAF format
+type FormatError struct {
+	Wrapping error
+	Index    int
+}
+
+func (err *FormatError) Error() string {
+	return err.Wrapping.Error()
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (err *FormatError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"index":   err.Index,
+		"message": err.Error(),
+	})
+}
+
+var (
+	ErrNoASCIIString         = &FormatError{Wrapping: errors.New("the given string has non-ASCII characters")}
+	ErrTAFIncomplete         = &FormatError{Wrapping: errors.New("the TAF is incomplete (end of string reached before validity period was found)")}
+	ErrInvalidStationID      = &FormatError{Wrapping: errors.New("the TAF's station ID is formatted incorrectly (expected 4 alphanumeric characters)")}
+	ErrInvalidTAFTime        = &FormatError{Wrapping: errors.New("the TAF's issuing time is formatted incorrectly (expected 'ddddddZ')")}
+	ErrInvalidValidityPeriod = &FormatError{Wrapping: errors.New("the TAF's validity period is formatted incorrectly (expected 'dddd/dddd')")}
+	errInvalidChangeGroup    = func(group, reason string, index int) *FormatError {
+		return &FormatError{
+			Wrapping: fmt.Errorf("invalid change group '%s' at offset %d: %s", group, index, reason),
+			Index:    index,
+		}
+	}
+	errInvalidTemperatureGroup = func(group string, index int) *FormatError {
+		return &FormatError{
+			Wrapping: fmt.Errorf("invalid temperature group '%s' at offset %d", group, index),
+			Index:    index,
+		}
+	}
+)
@@ -0,0 +1,68 @@
+package taf
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVHeader returns the columns of the CSV representation of a TAF
+func (obj *TAF) CSVHeader() []string {
+	return []string{"id", "station_id", "issued_at", "valid_from", "valid_until", "amended", "corrected", "cancelled", "nil", "raw"}
+}
+
+// CSVRecord returns the CSV representation of the TAF (see CSVHeader)
+func (obj *TAF) CSVRecord() []string {
+	return []string{
+		obj.ID.String(),
+		obj.StationID,
+		strconv.FormatInt(obj.IssuedAt, 10),
+		strconv.FormatInt(obj.ValidFrom, 10),
+		strconv.FormatInt(obj.ValidUntil, 10),
+		strconv.FormatBool(obj.Amended),
+		strconv.FormatBool(obj.Corrected),
+		strconv.FormatBool(obj.Cancelled),
+		strconv.FormatBool(obj.Nil),
+		obj.Raw,
+	}
+}
+
+// xmlTAF represents the XML representation of a TAF; its layout follows the one of the aviation weather data server
+type xmlTAF struct {
+	XMLName       xml.Name `xml:"TAF"`
+	RawText       string   `xml:"raw_text"`
+	StationID     string   `xml:"station_id"`
+	IssueTime     string   `xml:"issue_time"`
+	ValidTimeFrom string   `xml:"valid_time_from"`
+	ValidTimeTo   string   `xml:"valid_time_to"`
+	Remarks       string   `xml:"remarks,omitempty"`
+	ID            string   `xml:"id"`
+}
+
+// MarshalXML writes the XML representation of the TAF as a 'TAF' element
+func (obj *TAF) MarshalXML(encoder *xml.Encoder, _ xml.StartElement) error {
+	element := &xmlTAF{
+		RawText:       obj.Raw,
+		StationID:     obj.StationID,
+		IssueTime:     time.Unix(obj.IssuedAt, 0).UTC().Format(time.RFC3339),
+		ValidTimeFrom: time.Unix(obj.ValidFrom, 0).UTC().Format(time.RFC3339),
+		ValidTimeTo:   time.Unix(obj.ValidUntil, 0).UTC().Format(time.RFC3339),
+		ID:            obj.ID.String(),
+	}
+	var remarks []string
+	if obj.Amended {
+		remarks = append(remarks, "AMD")
+	}
+	if obj.Corrected {
+		remarks = append(remarks, "COR")
+	}
+	if obj.Cancelled {
+		remarks = append(remarks, "CNL")
+	}
+	if obj.Nil {
+		remarks = append(remarks, "NIL")
+	}
+	element.Remarks = strings.Join(remarks, " ")
+	return encoder.Encode(element)
+}
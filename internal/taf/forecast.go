@@ -0,0 +1,400 @@
+package taf
+
+import (
+	"github.com/skybi/pluteo/internal/metar"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ChangeType represents the type of a TAF change group
+type ChangeType string
+
+const (
+	// ChangeTypeFrom represents a rapid and permanent change ('FMddhhmm') that replaces all previous conditions
+	ChangeTypeFrom ChangeType = "FM"
+
+	// ChangeTypeBecoming represents a gradual change ('BECMG') that takes place at some point during its period
+	ChangeTypeBecoming ChangeType = "BECMG"
+
+	// ChangeTypeTemporary represents temporary fluctuations ('TEMPO') during its period
+	ChangeTypeTemporary ChangeType = "TEMPO"
+
+	// ChangeTypeProbability represents conditions that occur with a given probability ('PROBnn') during its period
+	ChangeTypeProbability ChangeType = "PROB"
+)
+
+var (
+	fromPattern        = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	probabilityPattern = regexp.MustCompile(`^PROB(\d{2})$`)
+	temperaturePattern = regexp.MustCompile(`^(TX|TN)(M?)(\d{2})/(\d{2})(\d{2})Z$`)
+	windShearPattern   = regexp.MustCompile(`^WS\d{3}/(?:\d{3}|VRB)\d{2,3}(?:KT|MPS|KMH)$`)
+)
+
+// Forecast represents a fully decoded TAF
+type Forecast struct {
+	StationID  string `json:"station_id"`
+	IssuedAt   int64  `json:"issued_at"`
+	ValidFrom  int64  `json:"valid_from"`
+	ValidUntil int64  `json:"valid_until"`
+	Amended    bool   `json:"amended"`
+	Corrected  bool   `json:"corrected"`
+	Cancelled  bool   `json:"cancelled"`
+	Nil        bool   `json:"nil"`
+
+	// Base contains the conditions forecast at the start of the validity period; nil for NIL and cancelled TAFs
+	Base    *Conditions    `json:"base"`
+	Changes []*ChangeGroup `json:"changes"`
+
+	MaxTemperatures []*Temperature `json:"max_temperatures"`
+	MinTemperatures []*Temperature `json:"min_temperatures"`
+	Remarks         string         `json:"remarks,omitempty"`
+
+	// Errors contains an error for every group that could not be decoded.
+	// The index of every error points to the byte offset of the group inside the raw TAF (without the 'TAF' prefix).
+	Errors []*FormatError `json:"errors"`
+}
+
+// Conditions represents the weather conditions forecast by a TAF for a specific period.
+// Every group that is not forecast (or could not be decoded) is left at its zero value.
+type Conditions struct {
+	Wind                 *metar.Wind         `json:"wind,omitempty"`
+	CAVOK                bool                `json:"cavok"`
+	Visibility           *metar.Visibility   `json:"visibility,omitempty"`
+	Weather              []*metar.Weather    `json:"weather"`
+	NoSignificantWeather bool                `json:"no_significant_weather"`
+	SkyCondition         string              `json:"sky_condition,omitempty"`
+	VerticalVisibility   *int                `json:"vertical_visibility,omitempty"`
+	Clouds               []*metar.CloudLayer `json:"clouds"`
+
+	// WindShear contains the raw low-level wind shear groups ('WShhh/dddffKT')
+	WindShear []string `json:"wind_shear"`
+}
+
+// ChangeGroup represents a single time-bounded change group of a TAF
+type ChangeGroup struct {
+	Type ChangeType `json:"type"`
+
+	// Probability is the probability in percent of PROB groups and TEMPO groups prefixed with one
+	Probability int `json:"probability,omitempty"`
+
+	// From and Until represent the period the change group applies to.
+	// FM groups last until the next FM group or the end of the validity period.
+	From  int64 `json:"from"`
+	Until int64 `json:"until"`
+
+	Conditions *Conditions `json:"conditions"`
+	Raw        string      `json:"raw"`
+}
+
+// Temperature represents a forecast maximum ('TX') or minimum ('TN') temperature
+type Temperature struct {
+	Value int   `json:"value"`
+	At    int64 `json:"at"`
+}
+
+// Decode fully decodes a raw TAF string.
+// Only errors in the header (station ID, issuing time and validity period) cause this function to fail. Every other
+// group that could not be decoded is skipped and recorded in Forecast.Errors.
+// The options are the same as for OfString.
+func Decode(raw string, opts ...ParseOption) (*Forecast, error) {
+	obj, groups, i, err := decodeHeader(raw, buildParseOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &Forecast{
+		StationID:       obj.StationID,
+		IssuedAt:        obj.IssuedAt,
+		ValidFrom:       obj.ValidFrom,
+		ValidUntil:      obj.ValidUntil,
+		Amended:         obj.Amended,
+		Corrected:       obj.Corrected,
+		Cancelled:       obj.Cancelled,
+		Nil:             obj.Nil,
+		Changes:         []*ChangeGroup{},
+		MaxTemperatures: []*Temperature{},
+		MinTemperatures: []*Temperature{},
+		Errors:          []*FormatError{},
+	}
+
+	// NIL and cancelled TAFs do not contain a forecast
+	if obj.Nil || obj.Cancelled {
+		return forecast, nil
+	}
+
+	decodeForecast(obj.Raw, groups[i:], forecast)
+	return forecast, nil
+}
+
+// Decode fully decodes the raw representation of the TAF (see Decode).
+// The issuing time is resolved relative to the one already stored in the TAF object.
+func (obj *TAF) Decode() (*Forecast, error) {
+	return Decode(obj.Raw, WithReferenceTime(time.Unix(obj.IssuedAt, 0)))
+}
+
+// decodeForecast splits the given forecast groups into the base forecast and its change groups and decodes them into
+// the given forecast
+func decodeForecast(raw string, groups []metar.Token, forecast *Forecast) {
+	validFrom := time.Unix(forecast.ValidFrom, 0).UTC()
+
+	// Every section starts with its change indicator (or the first group of the base forecast) and ends right before
+	// the next one
+	var change *ChangeGroup
+	var section []metar.Token
+	flush := func() {
+		conditions := forecast.decodeConditions(raw, section)
+		if change == nil {
+			forecast.Base = conditions
+		} else {
+			change.Conditions = conditions
+			forecast.Changes = append(forecast.Changes, change)
+		}
+		section = nil
+	}
+
+	for i := 0; i < len(groups); i++ {
+		grp := groups[i]
+
+		// Everything after 'RMK' is kept as the unparsed remarks section
+		if grp.Value == "RMK" {
+			if i+1 < len(groups) {
+				last := groups[len(groups)-1]
+				forecast.Remarks = raw[groups[i+1].Index : last.Index+len(last.Value)]
+			}
+			break
+		}
+
+		if match := fromPattern.FindStringSubmatch(grp.Value); match != nil {
+			flush()
+			day, _ := strconv.Atoi(match[1])
+			hour, _ := strconv.Atoi(match[2])
+			minute, _ := strconv.Atoi(match[3])
+			from, ok := resolveDayHour(day, hour, minute, validFrom)
+			if !ok {
+				forecast.Errors = append(forecast.Errors, errInvalidChangeGroup(grp.Value, "invalid time", grp.Index))
+				from = validFrom
+			}
+			change = &ChangeGroup{
+				Type: ChangeTypeFrom,
+				From: from.Unix(),
+				Raw:  grp.Value,
+			}
+			continue
+		}
+
+		changeType := ChangeType("")
+		probability := 0
+		start := grp
+		if grp.Value == string(ChangeTypeBecoming) || grp.Value == string(ChangeTypeTemporary) {
+			changeType = ChangeType(grp.Value)
+		} else if match := probabilityPattern.FindStringSubmatch(grp.Value); match != nil {
+			changeType = ChangeTypeProbability
+			probability, _ = strconv.Atoi(match[1])
+
+			// A probability may be attached to temporary fluctuations ('PROB30 TEMPO')
+			if i+1 < len(groups) && groups[i+1].Value == string(ChangeTypeTemporary) {
+				changeType = ChangeTypeTemporary
+				i++
+			}
+		}
+		if changeType == "" {
+			section = append(section, grp)
+			continue
+		}
+
+		flush()
+		change = &ChangeGroup{
+			Type:        changeType,
+			Probability: probability,
+			From:        forecast.ValidFrom,
+			Until:       forecast.ValidUntil,
+		}
+
+		// Every change group except FM is followed by its period in the format 'ddhh/ddhh'
+		if i+1 >= len(groups) {
+			forecast.Errors = append(forecast.Errors, errInvalidChangeGroup(start.Value, "missing period", start.Index))
+		} else if from, until, ok := parseValidityPeriod(groups[i+1].Value, validFrom); !ok {
+			forecast.Errors = append(forecast.Errors, errInvalidChangeGroup(start.Value, "missing or invalid period", start.Index))
+		} else {
+			change.From = from.Unix()
+			change.Until = until.Unix()
+			i++
+		}
+		last := groups[i]
+		change.Raw = raw[start.Index : last.Index+len(last.Value)]
+	}
+	flush()
+
+	// FM groups last until the next FM group or the end of the validity period
+	var lastFrom *ChangeGroup
+	for _, change := range forecast.Changes {
+		if change.Type != ChangeTypeFrom {
+			continue
+		}
+		if lastFrom != nil {
+			lastFrom.Until = change.From
+		}
+		lastFrom = change
+	}
+	if lastFrom != nil {
+		lastFrom.Until = forecast.ValidUntil
+	}
+}
+
+// decodeConditions decodes the given groups of a single forecast section into a set of conditions.
+// TAF-specific groups are decoded directly; the remaining ones are decoded using the METAR body decoder.
+func (forecast *Forecast) decodeConditions(raw string, groups []metar.Token) *Conditions {
+	conditions := &Conditions{
+		WindShear: []string{},
+	}
+
+	// The groups passed to the METAR decoder are placed at their original offsets so that error offsets remain valid
+	masked := make([]byte, len(raw))
+	for i := range masked {
+		masked[i] = ' '
+	}
+	for _, grp := range groups {
+		if windShearPattern.MatchString(grp.Value) {
+			conditions.WindShear = append(conditions.WindShear, grp.Value)
+			continue
+		}
+		if match := temperaturePattern.FindStringSubmatch(grp.Value); match != nil {
+			forecast.decodeTemperature(grp, match)
+			continue
+		}
+		copy(masked[grp.Index:], grp.Value)
+	}
+
+	report := metar.DecodeGroups(string(masked))
+	for _, err := range report.Errors {
+		forecast.Errors = append(forecast.Errors, &FormatError{
+			Wrapping: err.Wrapping,
+			Index:    err.Index,
+		})
+	}
+
+	conditions.Wind = report.Wind
+	conditions.CAVOK = report.CAVOK
+	conditions.Visibility = report.Visibility
+	conditions.Weather = report.Weather
+	conditions.NoSignificantWeather = report.NoSignificantWeather
+	conditions.SkyCondition = report.SkyCondition
+	conditions.VerticalVisibility = report.VerticalVisibility
+	conditions.Clouds = report.Clouds
+	return conditions
+}
+
+// decodeTemperature decodes a maximum or minimum temperature group in the format 'TXddd/ddhhZ' or 'TNMdd/ddhhZ'
+func (forecast *Forecast) decodeTemperature(grp metar.Token, match []string) {
+	value, _ := strconv.Atoi(match[3])
+	if match[2] == "M" {
+		value = -value
+	}
+	day, _ := strconv.Atoi(match[4])
+	hour, _ := strconv.Atoi(match[5])
+	at, ok := resolveDayHour(day, hour, 0, time.Unix(forecast.ValidFrom, 0))
+	if !ok {
+		forecast.Errors = append(forecast.Errors, errInvalidTemperatureGroup(grp.Value, grp.Index))
+		return
+	}
+
+	temperature := &Temperature{
+		Value: value,
+		At:    at.Unix(),
+	}
+	if match[1] == "TX" {
+		forecast.MaxTemperatures = append(forecast.MaxTemperatures, temperature)
+	} else {
+		forecast.MinTemperatures = append(forecast.MinTemperatures, temperature)
+	}
+}
+
+// ConditionsAt resolves the conditions forecast at the given unix timestamp into a single set of conditions.
+// Starting with the base forecast, FM groups replace all previous conditions and BECMG groups are applied once their
+// period has ended. TEMPO and PROB groups are only applied if includeTemporary is set.
+// The returned change groups are the ones that are active at the given time; this includes BECMG groups whose
+// transition is still in progress and TEMPO and PROB groups regardless of includeTemporary.
+// ok is false if the timestamp lies outside the validity period or the TAF does not contain a forecast.
+func (forecast *Forecast) ConditionsAt(timestamp int64, includeTemporary bool) (*Conditions, []*ChangeGroup, bool) {
+	if forecast.Base == nil || timestamp < forecast.ValidFrom || timestamp >= forecast.ValidUntil {
+		return nil, nil, false
+	}
+
+	conditions := forecast.Base.clone()
+	active := []*ChangeGroup{}
+	for _, change := range forecast.Changes {
+		switch change.Type {
+		case ChangeTypeFrom:
+			if timestamp < change.From {
+				continue
+			}
+			conditions = change.Conditions.clone()
+			active = []*ChangeGroup{}
+			if timestamp < change.Until {
+				active = append(active, change)
+			}
+		case ChangeTypeBecoming:
+			if timestamp >= change.Until {
+				conditions.apply(change.Conditions)
+			} else if timestamp >= change.From {
+				active = append(active, change)
+			}
+		default:
+			if timestamp < change.From || timestamp >= change.Until {
+				continue
+			}
+			active = append(active, change)
+			if includeTemporary {
+				conditions.apply(change.Conditions)
+			}
+		}
+	}
+	return conditions, active, true
+}
+
+func (conditions *Conditions) clone() *Conditions {
+	cloned := *conditions
+	cloned.Weather = append([]*metar.Weather{}, conditions.Weather...)
+	cloned.Clouds = append([]*metar.CloudLayer{}, conditions.Clouds...)
+	cloned.WindShear = append([]string{}, conditions.WindShear...)
+	return &cloned
+}
+
+// apply overrides the conditions with every element that is forecast by the given change
+func (conditions *Conditions) apply(change *Conditions) {
+	if change.Wind != nil {
+		conditions.Wind = change.Wind
+	}
+	if len(change.WindShear) > 0 {
+		conditions.WindShear = append([]string{}, change.WindShear...)
+	}
+
+	// CAVOK implies a visibility of 10 km or more, no significant weather and no significant clouds
+	if change.CAVOK {
+		conditions.CAVOK = true
+		conditions.Visibility = nil
+		conditions.Weather = []*metar.Weather{}
+		conditions.NoSignificantWeather = false
+		conditions.SkyCondition = ""
+		conditions.VerticalVisibility = nil
+		conditions.Clouds = []*metar.CloudLayer{}
+		return
+	}
+
+	if change.Visibility != nil {
+		conditions.CAVOK = false
+		conditions.Visibility = change.Visibility
+	}
+	if len(change.Weather) > 0 || change.NoSignificantWeather {
+		conditions.CAVOK = false
+		conditions.Weather = append([]*metar.Weather{}, change.Weather...)
+		conditions.NoSignificantWeather = change.NoSignificantWeather
+	}
+	if len(change.Clouds) > 0 || change.SkyCondition != "" || change.VerticalVisibility != nil {
+		conditions.CAVOK = false
+		conditions.SkyCondition = change.SkyCondition
+		conditions.VerticalVisibility = change.VerticalVisibility
+		conditions.Clouds = append([]*metar.CloudLayer{}, change.Clouds...)
+	}
+}
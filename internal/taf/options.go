@@ -0,0 +1,44 @@
+package taf
+
+import (
+	"github.com/skybi/pluteo/internal/metar"
+	"time"
+)
+
+// ParseOption represents an option that changes the behaviour of OfString
+type ParseOption func(options *parseOptions)
+
+type parseOptions struct {
+	referenceTime time.Time
+	maxFutureSkew time.Duration
+}
+
+func buildParseOptions(opts []ParseOption) *parseOptions {
+	options := &parseOptions{
+		referenceTime: time.Now(),
+		maxFutureSkew: metar.DefaultMaxFutureSkew,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithReferenceTime makes OfString resolve the month and year of the issuing time relative to the given reference time
+// instead of the current wall-clock time. This is useful for backfilling historical TAFs.
+func WithReferenceTime(reference time.Time) ParseOption {
+	return func(options *parseOptions) {
+		options.referenceTime = reference
+	}
+}
+
+// WithMaxFutureSkew sets how far in the future (relative to the reference time) an issuing time may lie before it is
+// assumed to belong to the previous month
+func WithMaxFutureSkew(skew time.Duration) ParseOption {
+	return func(options *parseOptions) {
+		if skew < 0 {
+			skew = 0
+		}
+		options.maxFutureSkew = skew
+	}
+}
@@ -0,0 +1,35 @@
+package taf
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// Repository defines the TAF repository API
+type Repository interface {
+	// GetByFilter retrieves multiple TAFs following a filter, ordered by their issuing date (descending).
+	// If limit <= 0, a default limit value of 10 is used.
+	GetByFilter(ctx context.Context, filter *Filter, limit uint64) ([]*TAF, uint64, error)
+
+	// GetByID retrieves a TAF by its ID
+	GetByID(ctx context.Context, id uuid.UUID) (*TAF, error)
+
+	// Create creates new TAFs based on their raw text representation.
+	// All raw strings are sanitized (leading and trailing spaces are trimmed).
+	// This method also returns the indexes of the TAFs that already exist in the database and thus were not inserted.
+	// The given parse options are passed to OfString for every raw string.
+	Create(ctx context.Context, raw []string, opts ...ParseOption) ([]*TAF, []uint, error)
+
+	// Delete deletes a TAF by its ID
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// Filter is used to query TAFs based on a filter
+type Filter struct {
+	StationID    *string
+	IssuedBefore *int64
+	IssuedAfter  *int64
+
+	// ValidAt matches all TAFs whose validity period contains the given unix timestamp
+	ValidAt *int64
+}
@@ -0,0 +1,174 @@
+package taf
+
+import (
+	"github.com/google/uuid"
+	"github.com/skybi/pluteo/internal/metar"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// validityPeriodSkew defines how far the boundaries of a validity period may lie after the time they are resolved
+// relative to (the issuing time for the start and the start for the end)
+var validityPeriodSkew = 48 * time.Hour
+
+var (
+	issuingTimePattern    = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	validityPeriodPattern = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+)
+
+// TAF represents a stored TAF (terminal aerodrome forecast) data point
+type TAF struct {
+	ID         uuid.UUID `json:"id"`
+	StationID  string    `json:"station_id"`
+	IssuedAt   int64     `json:"issued_at"`
+	ValidFrom  int64     `json:"valid_from"`
+	ValidUntil int64     `json:"valid_until"`
+	Raw        string    `json:"raw"`
+	Amended    bool      `json:"amended"`
+	Corrected  bool      `json:"corrected"`
+	Cancelled  bool      `json:"cancelled"`
+	Nil        bool      `json:"nil"`
+}
+
+// OfString tries to decode a raw TAF string into a TAF object.
+// This method only reads and validates the TAF header (station ID, issuing time and validity period); the forecast
+// itself is not validated.
+// The month and year of the issuing time are resolved relative to the current time by default (see
+// metar.ResolveIssuingTime); use WithReferenceTime and WithMaxFutureSkew to change this behaviour. The validity period
+// is resolved relative to the issuing time.
+func OfString(raw string, opts ...ParseOption) (*TAF, error) {
+	obj, _, _, err := decodeHeader(raw, buildParseOptions(opts))
+	return obj, err
+}
+
+// decodeHeader decodes the header of the given raw TAF string and returns the resulting TAF object together with the
+// groups of its raw representation and the index of the first group of the forecast itself
+func decodeHeader(raw string, options *parseOptions) (*TAF, []metar.Token, int, error) {
+	// TAFs only consist of ASCII characters
+	for i := 0; i < len(raw); i++ {
+		if raw[i] > unicode.MaxASCII {
+			return nil, nil, 0, ErrNoASCIIString
+		}
+	}
+
+	raw = strings.TrimSpace(raw)
+
+	// Just like the report type of METARs, the 'TAF' prefix is optional and trimmed from the raw representation
+	if strings.HasPrefix(raw, "TAF ") || strings.HasPrefix(raw, "TAF\n") {
+		raw = strings.TrimSpace(strings.TrimPrefix(raw, "TAF"))
+	}
+
+	obj := &TAF{
+		ID:  uuid.New(),
+		Raw: raw,
+	}
+	groups := metar.Tokenize(raw)
+	i := 0
+
+	// The modifiers may be placed in front of the station ID
+	for ; i < len(groups) && (groups[i].Value == "AMD" || groups[i].Value == "COR"); i++ {
+		obj.Amended = obj.Amended || groups[i].Value == "AMD"
+		obj.Corrected = obj.Corrected || groups[i].Value == "COR"
+	}
+
+	// The next group represents the station's ICAO code
+	if i >= len(groups) {
+		return nil, nil, 0, ErrTAFIncomplete
+	}
+	if len(groups[i].Value) != 4 {
+		return nil, nil, 0, ErrInvalidStationID
+	}
+	for _, char := range groups[i].Value {
+		if !unicode.IsUpper(char) && !unicode.IsDigit(char) {
+			return nil, nil, 0, ErrInvalidStationID
+		}
+	}
+	obj.StationID = groups[i].Value
+	i++
+
+	// The next group represents the issuing time in the format 'ddhhmmZ'
+	if i >= len(groups) {
+		return nil, nil, 0, ErrTAFIncomplete
+	}
+	match := issuingTimePattern.FindStringSubmatch(groups[i].Value)
+	if match == nil {
+		return nil, nil, 0, ErrInvalidTAFTime
+	}
+	day, _ := strconv.Atoi(match[1])
+	hour, _ := strconv.Atoi(match[2])
+	minute, _ := strconv.Atoi(match[3])
+	issuedAt, ok := metar.ResolveIssuingTime(day, hour, minute, options.referenceTime, options.maxFutureSkew)
+	if !ok {
+		return nil, nil, 0, ErrInvalidTAFTime
+	}
+	obj.IssuedAt = issuedAt.Unix()
+	i++
+
+	// A NIL TAF does not contain a validity period
+	if i < len(groups) && groups[i].Value == "NIL" {
+		obj.Nil = true
+		obj.ValidFrom = obj.IssuedAt
+		obj.ValidUntil = obj.IssuedAt
+		return obj, groups, i + 1, nil
+	}
+
+	// The next group represents the validity period in the format 'ddhh/ddhh'
+	if i >= len(groups) {
+		return nil, nil, 0, ErrTAFIncomplete
+	}
+	validFrom, validUntil, ok := parseValidityPeriod(groups[i].Value, issuedAt)
+	if !ok {
+		return nil, nil, 0, ErrInvalidValidityPeriod
+	}
+	obj.ValidFrom = validFrom.Unix()
+	obj.ValidUntil = validUntil.Unix()
+	i++
+
+	// A cancelled TAF is marked with 'CNL' directly after its validity period
+	if i < len(groups) && groups[i].Value == "CNL" {
+		obj.Cancelled = true
+		i++
+	}
+
+	return obj, groups, i, nil
+}
+
+// IsValidAt returns whether the given unix timestamp lies inside the validity period of the TAF
+func (obj *TAF) IsValidAt(timestamp int64) bool {
+	return timestamp >= obj.ValidFrom && timestamp < obj.ValidUntil
+}
+
+// parseValidityPeriod parses a period in the format 'ddhh/ddhh' relative to the given reference time
+func parseValidityPeriod(raw string, reference time.Time) (time.Time, time.Time, bool) {
+	match := validityPeriodPattern.FindStringSubmatch(raw)
+	if match == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	fromDay, _ := strconv.Atoi(match[1])
+	fromHour, _ := strconv.Atoi(match[2])
+	untilDay, _ := strconv.Atoi(match[3])
+	untilHour, _ := strconv.Atoi(match[4])
+
+	from, ok := resolveDayHour(fromDay, fromHour, 0, reference)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	until, ok := resolveDayHour(untilDay, untilHour, 0, from)
+	if !ok || !until.After(from) {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, until, true
+}
+
+// resolveDayHour resolves a day, hour and minute triple inside a TAF relative to the given reference time.
+// In contrast to issuing times, TAF periods may use '24' as the hour to refer to the end of the day.
+func resolveDayHour(day, hour, minute int, reference time.Time) (time.Time, bool) {
+	if hour == 24 && minute == 0 {
+		resolved, ok := metar.ResolveIssuingTime(day, 0, 0, reference.Add(-24*time.Hour), validityPeriodSkew)
+		return resolved.Add(24 * time.Hour), ok
+	}
+	return metar.ResolveIssuingTime(day, hour, minute, reference, validityPeriodSkew)
+}
@@ -12,6 +12,8 @@ func DefaultAPIKeyPolicy() *APIKeyPolicy {
 		MaxRateLimit: 60, // Max. 60 requests per minute
 		AllowedCapabilities: bitflag.EmptyContainer.With(
 			apikey.CapabilityReadMETARs,
+			apikey.CapabilityReadTAFs,
+			apikey.CapabilityReadStations,
 		),
 	}
 }
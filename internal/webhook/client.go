@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRedirects defines how many redirects a webhook request follows at most
+const maxRedirects = 3
+
+// ErrForbiddenAddress is returned if a webhook request would be sent to a non-public address
+var ErrForbiddenAddress = errors.New("webhooks must not target non-public addresses")
+
+// nonPublicNetworks contains the special-purpose networks that are not covered by the net.IP classification methods
+var nonPublicNetworks = mustParseNetworks(
+	"0.0.0.0/8",       // "This network"
+	"100.64.0.0/10",   // Shared address space (carrier-grade NAT)
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // Documentation (TEST-NET-1)
+	"198.18.0.0/15",   // Benchmarking
+	"198.51.100.0/24", // Documentation (TEST-NET-2)
+	"203.0.113.0/24",  // Documentation (TEST-NET-3)
+	"240.0.0.0/4",     // Reserved (including the limited broadcast address)
+	"64:ff9b::/96",    // IPv4/IPv6 translation
+	"2001:db8::/32",   // Documentation
+)
+
+// IsPublicIP returns whether the given IP address is a globally reachable unicast address.
+// Loopback, private, link-local (including cloud metadata endpoints), multicast and other special-purpose addresses
+// are not public.
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient creates the HTTP client used to send webhook requests.
+// Its dialer rejects every connection to a non-public address (see IsPublicIP). As the check is performed on the
+// resolved address right before connecting, it cannot be bypassed using DNS rebinding or redirects.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Webhook requests never use a proxy as it would be the address the dialer checks
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// checkRedirect only follows a limited amount of redirects to URLs a webhook may be registered for
+func checkRedirect(request *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if !IsValidURL(request.URL.String()) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// isForbiddenHost returns whether the given URL host obviously refers to a non-public address.
+// Host names are resolved when the request is sent, so they are checked by the dialer of NewClient instead.
+func isForbiddenHost(parsed *url.URL) bool {
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return !IsPublicIP(ip)
+	}
+	return false
+}
+
+func mustParseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,455 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/skybi/pluteo/internal/apikey"
+	"github.com/skybi/pluteo/internal/apikey/quota"
+	"github.com/skybi/pluteo/internal/event"
+	"github.com/skybi/pluteo/internal/metar"
+	"github.com/skybi/pluteo/internal/task"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// EventMETAR is the event type of the deliveries of newly stored METARs; their data is a list of METARs
+const EventMETAR = "metar"
+
+// Payload represents the JSON body of a webhook request
+type Payload struct {
+	Event      string    `json:"event"`
+	DeliveryID uuid.UUID `json:"delivery_id"`
+	Data       any       `json:"data"`
+}
+
+// delivery represents an event that has to be delivered to a single webhook
+type delivery struct {
+	id      uuid.UUID
+	hook    *Webhook
+	event   string
+	data    any
+	attempt int
+	dueAt   time.Time
+
+	// units is the amount of quota units a successful delivery consumes
+	units int
+}
+
+// directDelivery represents an event that was enqueued for a specific webhook
+type directDelivery struct {
+	hookID uuid.UUID
+	event  string
+	data   any
+}
+
+// Dispatcher delivers newly stored METARs to the matching webhooks and other events to the webhooks they were enqueued
+// for. METARs are collected from an event broker and delivered in batches by a repeating task; failed deliveries are
+// retried with an exponential backoff.
+type Dispatcher struct {
+	// Client is the HTTP client used to send the webhook requests
+	Client *http.Client
+
+	// Interval defines how often pending deliveries are sent
+	Interval time.Duration
+
+	// MaxAttempts defines how often a single delivery is attempted before it is dropped
+	MaxAttempts int
+
+	// RetryDelay defines the delay before the first retry; it doubles with every further attempt
+	RetryDelay time.Duration
+
+	// MaxConsecutiveFailures defines after how many consecutive failed attempts a webhook is disabled
+	MaxConsecutiveFailures int
+
+	// MaxBatchSize defines how many METARs are sent in a single request at most
+	MaxBatchSize int
+
+	// Concurrency defines how many webhooks are served concurrently
+	Concurrency int
+
+	// AttemptRetention defines how long recorded delivery attempts are kept
+	AttemptRetention time.Duration
+
+	repo   Repository
+	keys   apikey.Repository
+	quota  *quota.Tracker
+	events *event.Broker[*metar.METAR]
+
+	mu         sync.Mutex
+	pending    []*metar.METAR
+	direct     []*directDelivery
+	retries    []*delivery
+	sub        *event.Subscription[*metar.METAR]
+	dispatcher *task.RepeatingTask
+	cleaner    *task.RepeatingTask
+}
+
+// NewDispatcher creates a new webhook dispatcher with default settings.
+// Every delivered METAR consumes one unit of the quota of the API key the webhook is bound to.
+func NewDispatcher(repo Repository, keys apikey.Repository, quotaTracker *quota.Tracker, events *event.Broker[*metar.METAR]) *Dispatcher {
+	return &Dispatcher{
+		Client:                 NewClient(10 * time.Second),
+		Interval:               5 * time.Second,
+		MaxAttempts:            5,
+		RetryDelay:             30 * time.Second,
+		MaxConsecutiveFailures: 20,
+		MaxBatchSize:           100,
+		Concurrency:            8,
+		AttemptRetention:       7 * 24 * time.Hour,
+		repo:                   repo,
+		keys:                   keys,
+		quota:                  quotaTracker,
+		events:                 events,
+	}
+}
+
+// Start starts collecting newly stored METARs and the repeating delivery tasks
+func (dispatcher *Dispatcher) Start() {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	if dispatcher.sub != nil {
+		return
+	}
+	dispatcher.sub = dispatcher.events.Subscribe(4096)
+	go dispatcher.collect(dispatcher.sub)
+
+	dispatcher.dispatcher = task.NewRepeating(dispatcher.dispatch, dispatcher.Interval)
+	dispatcher.dispatcher.Start()
+	dispatcher.cleaner = task.NewRepeating(dispatcher.cleanup, time.Hour)
+	dispatcher.cleaner.Start()
+}
+
+// Stop stops the dispatcher after sending the pending deliveries one last time.
+// Retries that are not due yet are dropped.
+func (dispatcher *Dispatcher) Stop() {
+	dispatcher.mu.Lock()
+	sub := dispatcher.sub
+	dispatcher.sub = nil
+	dispatcher.mu.Unlock()
+	if sub == nil {
+		return
+	}
+	sub.Unsubscribe()
+	dispatcher.cleaner.Stop(false)
+	dispatcher.dispatcher.Stop(true)
+}
+
+// Enqueue enqueues the delivery of a single event to a specific webhook, regardless of its stations.
+// The delivery consumes one unit of the quota of the API key the webhook is bound to.
+func (dispatcher *Dispatcher) Enqueue(hookID uuid.UUID, eventType string, data any) {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	dispatcher.direct = append(dispatcher.direct, &directDelivery{
+		hookID: hookID,
+		event:  eventType,
+		data:   data,
+	})
+}
+
+// collect queues every METAR received by the given subscription until it is cancelled
+func (dispatcher *Dispatcher) collect(sub *event.Subscription[*metar.METAR]) {
+	for obj := range sub.Events() {
+		dispatcher.mu.Lock()
+		dispatcher.pending = append(dispatcher.pending, obj)
+		dispatcher.mu.Unlock()
+	}
+	if !sub.Lagged() {
+		return
+	}
+
+	// Re-subscribe if the dispatcher was not stopped in the meantime
+	log.Warn().Msg("the webhook dispatcher did not keep up with the newly stored METARs; some may not be delivered")
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	if dispatcher.sub == sub {
+		dispatcher.sub = dispatcher.events.Subscribe(cap(sub.Events()))
+		go dispatcher.collect(dispatcher.sub)
+	}
+}
+
+// dispatch sends all pending deliveries and the retries that are due
+func (dispatcher *Dispatcher) dispatch() {
+	now := time.Now()
+
+	dispatcher.mu.Lock()
+	metars := dispatcher.pending
+	dispatcher.pending = nil
+	direct := dispatcher.direct
+	dispatcher.direct = nil
+	var due []*delivery
+	remaining := dispatcher.retries[:0]
+	for _, retry := range dispatcher.retries {
+		if retry.dueAt.After(now) {
+			remaining = append(remaining, retry)
+		} else {
+			due = append(due, retry)
+		}
+	}
+	dispatcher.retries = remaining
+	dispatcher.mu.Unlock()
+
+	if len(metars) > 0 {
+		hooks, err := dispatcher.repo.GetEnabled(context.Background())
+		if err != nil {
+			log.Error().Err(err).Msg("could not retrieve the enabled webhooks")
+			dispatcher.mu.Lock()
+			dispatcher.pending = append(metars, dispatcher.pending...)
+			dispatcher.mu.Unlock()
+		} else {
+			due = append(due, dispatcher.buildDeliveries(hooks, metars)...)
+		}
+	}
+	for _, del := range direct {
+		hook, err := dispatcher.repo.GetByID(context.Background(), del.hookID)
+		if err != nil {
+			log.Error().Err(err).Str("webhook", del.hookID.String()).Msg("could not retrieve a webhook")
+			continue
+		}
+		if hook == nil || !hook.Enabled {
+			continue
+		}
+		due = append(due, &delivery{
+			id:    uuid.New(),
+			hook:  hook,
+			event: del.event,
+			data:  del.data,
+			units: 1,
+		})
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	// Deliveries of the same webhook are sent sequentially so that its failure counter stays consistent
+	byHook := make(map[uuid.UUID][]*delivery)
+	var order []uuid.UUID
+	for _, del := range due {
+		if _, ok := byHook[del.hook.ID]; !ok {
+			order = append(order, del.hook.ID)
+		}
+		byHook[del.hook.ID] = append(byHook[del.hook.ID], del)
+	}
+
+	semaphore := make(chan struct{}, dispatcher.Concurrency)
+	var wg sync.WaitGroup
+	for _, id := range order {
+		deliveries := byHook[id]
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			dispatcher.deliverAll(deliveries)
+		}()
+	}
+	wg.Wait()
+}
+
+// buildDeliveries splits the given METARs into deliveries to the matching webhooks
+func (dispatcher *Dispatcher) buildDeliveries(hooks []*Webhook, metars []*metar.METAR) []*delivery {
+	var deliveries []*delivery
+	for _, hook := range hooks {
+		var matching []*metar.METAR
+		for _, obj := range metars {
+			if hook.Matches(obj.StationID) {
+				matching = append(matching, obj)
+			}
+		}
+		for len(matching) > 0 {
+			n := len(matching)
+			if n > dispatcher.MaxBatchSize {
+				n = dispatcher.MaxBatchSize
+			}
+			deliveries = append(deliveries, &delivery{
+				id:    uuid.New(),
+				hook:  hook,
+				event: EventMETAR,
+				data:  matching[:n],
+				units: n,
+			})
+			matching = matching[n:]
+		}
+	}
+	return deliveries
+}
+
+// deliverAll attempts the given deliveries of a single webhook one after another
+func (dispatcher *Dispatcher) deliverAll(deliveries []*delivery) {
+	ctx := context.Background()
+
+	// Retries carry the state of the webhook at the time of their first attempt, so it has to be refreshed
+	hook := deliveries[0].hook
+	for _, del := range deliveries {
+		if del.attempt == 0 {
+			continue
+		}
+		refreshed, err := dispatcher.repo.GetByID(ctx, hook.ID)
+		if err != nil {
+			log.Error().Err(err).Str("webhook", hook.ID.String()).Msg("could not refresh a webhook")
+			dispatcher.scheduleRetries(deliveries)
+			return
+		}
+		if refreshed == nil || !refreshed.Enabled {
+			return
+		}
+		hook = refreshed
+		break
+	}
+
+	// The API key the webhook is bound to has to be allowed to read METARs
+	key, err := dispatcher.keys.GetByID(ctx, hook.KeyID)
+	if err != nil {
+		log.Error().Err(err).Str("webhook", hook.ID.String()).Msg("could not retrieve the API key of a webhook")
+		dispatcher.scheduleRetries(deliveries)
+		return
+	}
+
+	for i, del := range deliveries {
+		del.hook = hook
+		del.attempt++
+
+		// Deliveries that cannot be sent because of the API key count as failed attempts
+		var attempt *DeliveryAttempt
+		if key == nil || !key.Capabilities.Has(apikey.CapabilityReadMETARs) {
+			attempt = &DeliveryAttempt{Error: "the API key of the webhook does not exist or lacks the capability to read METARs"}
+		} else if key.Quota >= 0 && dispatcher.quota.Get(key)+int64(del.units) > key.Quota {
+			attempt = &DeliveryAttempt{Error: "the API key of the webhook has not enough quota left"}
+		} else {
+			attempt = dispatcher.send(ctx, del)
+		}
+		dispatcher.record(ctx, del, attempt)
+		if attempt.Success {
+			dispatcher.quota.AccumulateBy(key, int64(del.units))
+			if hook.ConsecutiveFailures > 0 {
+				hook = dispatcher.updateFailures(ctx, hook, 0)
+			}
+			continue
+		}
+
+		hook = dispatcher.updateFailures(ctx, hook, hook.ConsecutiveFailures+1)
+		if !hook.Enabled {
+			log.Warn().Str("webhook", hook.ID.String()).Int("failures", hook.ConsecutiveFailures).Msg("disabled a webhook after too many consecutive failures")
+			return
+		}
+		if del.attempt < dispatcher.MaxAttempts {
+			del.dueAt = time.Now().Add(dispatcher.RetryDelay * time.Duration(1<<(del.attempt-1)))
+			dispatcher.scheduleRetries([]*delivery{del})
+		}
+
+		// The remaining deliveries are postponed as the endpoint is likely to fail again
+		if i+1 < len(deliveries) {
+			for _, remaining := range deliveries[i+1:] {
+				remaining.dueAt = del.dueAt
+			}
+			dispatcher.scheduleRetries(deliveries[i+1:])
+		}
+		return
+	}
+}
+
+// send performs a single delivery attempt
+func (dispatcher *Dispatcher) send(ctx context.Context, del *delivery) *DeliveryAttempt {
+	attempt := new(DeliveryAttempt)
+
+	body, err := json.Marshal(&Payload{
+		Event:      del.event,
+		DeliveryID: del.id,
+		Data:       del.data,
+	})
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, del.hook.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Pluteo-Webhooks")
+	request.Header.Set(EventHeader, del.event)
+	request.Header.Set(DeliveryHeader, del.id.String())
+	request.Header.Set(TimestampHeader, fmt.Sprint(timestamp))
+	request.Header.Set(SignatureHeader, "sha256="+Sign(del.hook.Secret, timestamp, body))
+
+	start := time.Now()
+	response, err := dispatcher.Client.Do(request)
+	attempt.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	attempt.StatusCode = &response.StatusCode
+	attempt.Success = response.StatusCode >= 200 && response.StatusCode < 300
+	if !attempt.Success {
+		attempt.Error = fmt.Sprintf("unexpected status code %d", response.StatusCode)
+	}
+	return attempt
+}
+
+// record completes and stores a delivery attempt
+func (dispatcher *Dispatcher) record(ctx context.Context, del *delivery, attempt *DeliveryAttempt) {
+	attempt.ID = uuid.New()
+	attempt.WebhookID = del.hook.ID
+	attempt.DeliveryID = del.id
+	attempt.Attempt = del.attempt
+	attempt.AttemptedAt = time.Now().Unix()
+	if metars, ok := del.data.([]*metar.METAR); ok {
+		attempt.METARCount = len(metars)
+	}
+	if err := dispatcher.repo.RecordAttempt(ctx, attempt); err != nil {
+		log.Error().Err(err).Str("webhook", del.hook.ID.String()).Msg("could not record a webhook delivery attempt")
+	}
+}
+
+// updateFailures updates the consecutive failure counter of a webhook and disables it if the counter reached its
+// maximum. It returns the updated webhook.
+func (dispatcher *Dispatcher) updateFailures(ctx context.Context, hook *Webhook, failures int) *Webhook {
+	update := &Update{
+		ConsecutiveFailures: &failures,
+	}
+	if failures >= dispatcher.MaxConsecutiveFailures {
+		disabled := false
+		update.Enabled = &disabled
+	}
+	updated, err := dispatcher.repo.Update(ctx, hook.ID, update)
+	if err != nil || updated == nil {
+		if err != nil {
+			log.Error().Err(err).Str("webhook", hook.ID.String()).Msg("could not update the failure counter of a webhook")
+		}
+		copied := *hook
+		copied.ConsecutiveFailures = failures
+		copied.Enabled = update.Enabled == nil && hook.Enabled
+		return &copied
+	}
+	return updated
+}
+
+func (dispatcher *Dispatcher) scheduleRetries(deliveries []*delivery) {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	dispatcher.retries = append(dispatcher.retries, deliveries...)
+}
+
+// cleanup deletes the delivery attempts that are older than the configured retention
+func (dispatcher *Dispatcher) cleanup() {
+	n, err := dispatcher.repo.DeleteAttemptsBefore(context.Background(), time.Now().Add(-dispatcher.AttemptRetention).Unix())
+	if err != nil {
+		log.Error().Err(err).Msg("could not delete old webhook delivery attempts")
+		return
+	}
+	log.Debug().Int64("amount", n).Msg("deleted old webhook delivery attempts")
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// Repository defines the webhook repository API
+type Repository interface {
+	// Get retrieves multiple webhooks
+	Get(ctx context.Context, offset, limit uint64) ([]*Webhook, uint64, error)
+
+	// GetByUserID retrieves multiple webhooks of a specific user
+	GetByUserID(ctx context.Context, userID string, offset, limit uint64) ([]*Webhook, uint64, error)
+
+	// GetByID retrieves a webhook by its ID
+	GetByID(ctx context.Context, id uuid.UUID) (*Webhook, error)
+
+	// GetEnabled retrieves all enabled webhooks
+	GetEnabled(ctx context.Context) ([]*Webhook, error)
+
+	// Create creates a new webhook with a newly generated secret
+	Create(ctx context.Context, create *Create) (*Webhook, error)
+
+	// Update updates a webhook
+	Update(ctx context.Context, id uuid.UUID, update *Update) (*Webhook, error)
+
+	// Delete deletes a webhook and its delivery attempts by its ID
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// GetAttempts retrieves multiple delivery attempts of a specific webhook, ordered by their time (descending)
+	GetAttempts(ctx context.Context, webhookID uuid.UUID, offset, limit uint64) ([]*DeliveryAttempt, uint64, error)
+
+	// RecordAttempt stores a delivery attempt
+	RecordAttempt(ctx context.Context, attempt *DeliveryAttempt) error
+
+	// DeleteAttemptsBefore deletes all delivery attempts that took place before the given unix timestamp.
+	// It returns the amount of deleted attempts.
+	DeleteAttemptsBefore(ctx context.Context, timestamp int64) (int64, error)
+}
+
+// Create is used to create a new webhook
+type Create struct {
+	UserID      string
+	KeyID       uuid.UUID
+	URL         string
+	Description string
+	StationIDs  []string
+}
+
+// Update is used to update an existing webhook
+type Update struct {
+	URL         *string
+	Description *string
+
+	// StationIDs is left unchanged if it is nil
+	StationIDs          []string
+	Enabled             *bool
+	ConsecutiveFailures *int
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// EventHeader contains the type of the event a webhook request was sent for
+	EventHeader = "X-Pluteo-Event"
+
+	// DeliveryHeader contains the ID of the delivery a webhook request belongs to; it is the same for every retry
+	DeliveryHeader = "X-Pluteo-Delivery"
+
+	// TimestampHeader contains the unix timestamp the webhook request was signed at
+	TimestampHeader = "X-Pluteo-Timestamp"
+
+	// SignatureHeader contains the signature of the webhook request in the format 'sha256=<hex>' (see Sign)
+	SignatureHeader = "X-Pluteo-Signature"
+)
+
+// MaxStations defines the maximum amount of stations a single webhook may be bound to
+var MaxStations = 100
+
+// Webhook represents an outgoing webhook that receives the newly stored METARs of a set of stations
+type Webhook struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      string    `json:"user_id"`
+	KeyID       uuid.UUID `json:"api_key_id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"-"`
+	Description string    `json:"description"`
+	StationIDs  []string  `json:"station_ids"`
+
+	// Enabled is unset automatically once too many consecutive delivery attempts failed
+	Enabled             bool  `json:"enabled"`
+	ConsecutiveFailures int   `json:"consecutive_failures"`
+	CreatedAt           int64 `json:"created_at"`
+}
+
+// Matches returns whether the webhook is bound to the given station
+func (hook *Webhook) Matches(stationID string) bool {
+	for _, candidate := range hook.StationIDs {
+		if candidate == stationID {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryAttempt represents a single attempt to deliver a set of METARs to a webhook
+type DeliveryAttempt struct {
+	ID         uuid.UUID `json:"id"`
+	WebhookID  uuid.UUID `json:"webhook_id"`
+	DeliveryID uuid.UUID `json:"delivery_id"`
+
+	// Attempt is the number of the attempt inside its delivery, starting at 1
+	Attempt     int   `json:"attempt"`
+	AttemptedAt int64 `json:"attempted_at"`
+	METARCount  int   `json:"metar_count"`
+
+	// StatusCode is nil if no response was received
+	StatusCode *int   `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+	Success    bool   `json:"success"`
+
+	// Duration is the time the attempt took in milliseconds
+	Duration int64 `json:"duration_ms"`
+}
+
+// IsValidURL returns whether the given string is an absolute HTTP(S) URL a webhook may be registered for.
+// URLs whose host is a non-public IP address or 'localhost' are rejected; the addresses host names resolve to are
+// checked whenever a request is sent (see NewClient).
+func IsValidURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "" && parsed.User == nil &&
+		!isForbiddenHost(parsed)
+}
+
+// Sign calculates the signature of a webhook request body.
+// The signature is the hex-encoded HMAC-SHA256 of the string '<timestamp>.<body>' using the secret of the webhook.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}